@@ -1,36 +1,101 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/zs0c131y/burrow/internal/analyzer"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/explorer"
+	"github.com/zs0c131y/burrow/internal/export"
+	"github.com/zs0c131y/burrow/internal/quarantine"
+	"github.com/zs0c131y/burrow/internal/trash"
 	"github.com/zs0c131y/burrow/pkg/utils"
 )
 
 var (
-	analyzePath  string
-	analyzeDepth int
-	showHidden   bool
-	minSize      int64
+	analyzePath    string
+	analyzeDepth   int
+	showHidden     bool
+	minSize        int64
+	followLinks    bool
+	noCache        bool
+	rescan         bool
+	analyzeWorkers int
+	interactive    bool
+	readOnly       bool
+	staleDays      int
+	exportStale    string
+	byType         bool
+	fileType       string
+	exportPath     string
+	exportFormat   string
+	sizeMode       string
+	excludes       []string
+	excludeFrom    []string
+	oneFileSystem  bool
 )
 
+// fullScanDepth is deep enough to expand every file into the tree, for
+// reports that need each file's own size and modification time.
+const fullScanDepth = 1 << 10
+
 var analyzeCmd = &cobra.Command{
 	Use:   "analyze [path]",
 	Short: "Visual disk space analyzer",
 	Long: `Analyze disk usage with visual tree display:
-  - Interactive directory explorer
+  - Interactive directory explorer (--interactive)
   - Size-based sorting and filtering
   - Large file identification
-  - Visual percentage bars`,
+  - Visual percentage bars
+
+The explorer opens folders with Enter or the right arrow and goes back with
+Backspace or the left arrow; s changes the sort order (size, item count, last
+modified). Space marks items, d deletes and m quarantines them after
+confirmation. Quarantined items can be restored with 'wm quarantine'.
+--read-only disables marking.
+
+Scans are cached per path. On the next run, directories whose modification
+time is unchanged are not read again, and sizes are shown with the change
+since the previous scan. The files in a reused listing are not checked
+again; use --rescan to pick up files written in place.
+
+--stale N lists files not modified in N days, grouped by folder, with a
+histogram of bytes by age for each top-level folder. --export-candidates
+writes that list to a file that 'wm clean --candidates' can delete.
+
+--by-type ranks space by file type group (video, images, archives,
+installers, vm-disks, iso, logs, source) and by extension. --type lists the
+files of one group or extension, e.g. --type video --min-size 500.
+
+--export writes the scanned tree to a file as a JSON snapshot, CSV (path,
+size, count, mtime), an ncdu export ('ncdu -f') or a self-contained HTML
+treemap. The format follows the file extension unless --export-format is
+set; ncdu exports always scan every file.
+
+--size allocated sorts and filters by the space files take on disk instead
+of their apparent size: cluster slack is included, and compressed and sparse
+files count only what they occupy. Files with several hard links are counted
+once either way.
+
+--exclude skips entries matching a glob pattern and everything beneath them.
+A pattern without a separator matches names anywhere (node_modules, *.vhdx);
+one with a separator matches the end of a path (build\cache) or, when
+absolute, the whole path (D:\VMs\*). --exclude-from reads patterns from a
+file, one per line. --one-file-system stays on the volume of the scanned
+path, skipping mounted drives and network shares below it.`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) > 0 {
 			analyzePath = args[0]
 		}
+		applyAnalyzeSettings(cmd)
 		runAnalyze()
 	},
 }
@@ -39,6 +104,21 @@ func init() {
 	analyzeCmd.Flags().IntVarP(&analyzeDepth, "depth", "d", 3, "Maximum depth to analyze (1-10)")
 	analyzeCmd.Flags().BoolVar(&showHidden, "hidden", false, "Show hidden files and folders")
 	analyzeCmd.Flags().Int64Var(&minSize, "min-size", 0, "Minimum size in MB to display")
+	analyzeCmd.Flags().BoolVar(&followLinks, "follow-links", false, "Follow symbolic links and junctions (loops are detected)")
+	analyzeCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "Browse the results in a full-screen explorer")
+	analyzeCmd.Flags().BoolVar(&readOnly, "read-only", false, "Disable deleting and quarantining in the explorer")
+	analyzeCmd.Flags().IntVar(&analyzeWorkers, "workers", 0, "Directories to scan concurrently (0 = automatic, 1 = sequential)")
+	analyzeCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or update the scan cache")
+	analyzeCmd.Flags().BoolVar(&rescan, "rescan", false, "Read every directory again, ignoring cached listings")
+	analyzeCmd.Flags().IntVar(&staleDays, "stale", 0, "Report files not modified in this many days")
+	analyzeCmd.Flags().StringVar(&exportStale, "export-candidates", "", "Write the --stale files to a deletion candidate list for 'wm clean --candidates'")
+	analyzeCmd.Flags().BoolVar(&byType, "by-type", false, "Break down space by file type and extension")
+	analyzeCmd.Flags().StringVar(&fileType, "type", "", "List files of a type group or extension (e.g. video, .iso)")
+	analyzeCmd.Flags().StringVar(&exportPath, "export", "", "Write the scanned tree to a file")
+	analyzeCmd.Flags().StringVar(&exportFormat, "export-format", "", "Export format: json, csv, ncdu or html (default from the file extension)")
+	analyzeCmd.Flags().StringVar(&sizeMode, "size", analyzer.SizeApparent, "Size that drives sorting: apparent or allocated (on disk)")
+	analyzeCmd.Flags().BoolVar(&useTrash, "trash", false, "Make deleting in the explorer send items to the Recycle Bin")
+	addScanFilterFlags(analyzeCmd)
 }
 
 func runAnalyze() {
@@ -68,9 +148,80 @@ func runAnalyze() {
 		return
 	}
 
-	color.Cyan("\n╔════════════════════════════════════════════════════════╗")
-	color.Cyan("║              Burrow Disk Space Analyzer                ║")
-	color.Cyan("╚════════════════════════════════════════════════════════╝\n")
+	if sizeMode != analyzer.SizeApparent && sizeMode != analyzer.SizeAllocated {
+		color.Red("Error: --size must be %s or %s", analyzer.SizeApparent, analyzer.SizeAllocated)
+		return
+	}
+
+	if exportStale != "" && staleDays <= 0 {
+		color.Red("Error: --export-candidates needs --stale")
+		return
+	}
+	if staleDays > 0 {
+		runStaleReport(absPath)
+		return
+	}
+	if byType || fileType != "" {
+		runTypeReport(absPath)
+		return
+	}
+
+	var format string
+	if exportPath != "" {
+		format, err = export.FormatFor(exportPath, exportFormat)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		if format == export.FormatNCDU {
+			analyzeDepth = fullScanDepth
+			noCache = true
+		}
+	}
+
+	a := analyzer.NewAnalyzer(debugMode, showHidden, analyzeDepth, minSize*1024*1024)
+	a.SetFollowLinks(followLinks)
+	a.SetWorkers(analyzeWorkers)
+	a.SetSizeMode(sizeMode)
+	if err := applyScanFilters(a); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	var cache *analyzer.ScanCache
+	if !noCache {
+		cache, err = analyzer.OpenCache(absPath)
+		if err != nil && debugMode {
+			color.Yellow("Warning: scan cache unavailable: %v", err)
+		}
+		if cache != nil {
+			if rescan {
+				cache.Reset()
+			}
+			a.SetCache(cache)
+		}
+	}
+
+	scanned := time.Now()
+
+	if getOutputFormat() == config.FormatJSON {
+		tree, err := a.AnalyzePath(absPath)
+		if err != nil {
+			color.Red("Error analyzing path: %v", err)
+			return
+		}
+		saveScanCache(cache)
+		if format != "" {
+			if err := writeExport(tree, format, scanned); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+		}
+		printAnalysisJSON(tree, a.GetLargestFiles(tree, 10), cache)
+		return
+	}
+
+	printAnalyzerBanner()
 
 	color.White("Analyzing: %s\n", color.CyanString(absPath))
 	color.White("Depth: %d | Min size: %s | Hidden: %v\n",
@@ -80,15 +231,27 @@ func runAnalyze() {
 	)
 	color.White("Please wait, scanning directory tree...\n\n")
 
-	a := analyzer.NewAnalyzer(debugMode, showHidden, analyzeDepth, minSize*1024*1024)
-
 	tree, err := a.AnalyzePath(absPath)
 	if err != nil {
 		color.Red("Error analyzing path: %v", err)
 		return
 	}
+	saveScanCache(cache)
 
-	displayAnalysis(tree, absPath)
+	if format != "" {
+		if err := writeExport(tree, format, scanned); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green("Exported %s report to %s\n", format, exportPath)
+	}
+
+	if interactive {
+		runExplorer(a, tree)
+		return
+	}
+
+	displayAnalysis(tree, absPath, cache)
 
 	largeFiles := a.GetLargestFiles(tree, 10)
 	if len(largeFiles) > 0 {
@@ -96,23 +259,237 @@ func runAnalyze() {
 	}
 }
 
-func displayAnalysis(tree *analyzer.DiskNode, rootPath string) {
+// writeExport writes tree, scanned at the given time, to exportPath in the
+// given format.
+func writeExport(tree *analyzer.DiskNode, format string, scanned time.Time) error {
+	f, err := os.Create(exportPath)
+	if err != nil {
+		return fmt.Errorf("failed to create export: %w", err)
+	}
+
+	info := export.Info{Scanned: scanned, Version: appVersion}
+	if err := export.Write(f, format, tree, info); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// fullScan analyzes absPath down to every file, without the scan cache, for
+// reports that need each file's own size and modification time.
+func fullScan(absPath string) (*analyzer.Analyzer, *analyzer.DiskNode, error) {
+	a := analyzer.NewAnalyzer(debugMode, showHidden, fullScanDepth, minSize*1024*1024)
+	a.SetFollowLinks(followLinks)
+	a.SetWorkers(analyzeWorkers)
+	a.SetSizeMode(sizeMode)
+	if err := applyScanFilters(a); err != nil {
+		return nil, nil, err
+	}
+
+	tree, err := a.AnalyzePath(absPath)
+	return a, tree, err
+}
+
+// addScanFilterFlags registers the options that limit what a scan covers.
+func addScanFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&excludes, "exclude", nil, "Skip paths matching a glob pattern (repeatable)")
+	cmd.Flags().StringArrayVar(&excludeFrom, "exclude-from", nil, "Read exclude patterns from a file, one per line (repeatable)")
+	cmd.Flags().BoolVar(&oneFileSystem, "one-file-system", false, "Do not cross into other volumes or mounted filesystems")
+}
+
+// applyScanFilters sets up a with the --exclude, --exclude-from and
+// --one-file-system options.
+func applyScanFilters(a *analyzer.Analyzer) error {
+	patterns := append([]string{}, excludes...)
+	for _, file := range excludeFrom {
+		more, err := utils.ReadExcludeFile(file)
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, more...)
+	}
+
+	list, err := utils.NewExcludeList(patterns)
+	if err != nil {
+		return err
+	}
+	a.SetExclude(list)
+	a.SetOneFileSystem(oneFileSystem)
+	return nil
+}
+
+func printAnalyzerBanner() {
+	color.Cyan("\n╔════════════════════════════════════════════════════════╗")
+	color.Cyan("║              Burrow Disk Space Analyzer                ║")
+	color.Cyan("╚════════════════════════════════════════════════════════╝\n")
+}
+
+func runExplorer(a *analyzer.Analyzer, tree *analyzer.DiskNode) {
+	opts := explorer.Options{
+		ReadOnly: readOnly || dryRun,
+		Expand: func(path string) (*analyzer.DiskNode, error) {
+			a.SetCache(nil)
+			return a.Expand(path)
+		},
+	}
+	if !opts.ReadOnly {
+		retries := loadSettings().Clean.Retries
+		opts.Delete = func(path string) error {
+			if useTrash {
+				return trash.Move(path)
+			}
+			return utils.SafeDelete(path, retries)
+		}
+		opts.Quarantine = func(path string) error {
+			_, err := quarantine.Move(path)
+			return err
+		}
+	}
+
+	if err := explorer.Run(tree, opts); err != nil {
+		color.Red("Error running explorer: %v", err)
+	}
+}
+
+func saveScanCache(cache *analyzer.ScanCache) {
+	if cache == nil {
+		return
+	}
+	if err := cache.Save(); err != nil && debugMode {
+		color.Yellow("Warning: %v", err)
+	}
+}
+
+// nodeSize returns the size of n selected by --size.
+func nodeSize(n *analyzer.DiskNode) int64 {
+	if sizeMode == analyzer.SizeAllocated {
+		return n.Allocated
+	}
+	return n.Size
+}
+
+// showDeltas reports whether the tree carries changes since a previous scan.
+func showDeltas(cache *analyzer.ScanCache) bool {
+	return cache != nil && cache.HasDeltas()
+}
+
+// formatDelta renders a size change as "+1.2 GB", "-300 KB" or "new".
+func formatDelta(n *analyzer.DiskNode) string {
+	switch {
+	case n.IsNew:
+		return "new"
+	case n.Delta > 0:
+		return "+" + utils.FormatBytes(n.Delta)
+	case n.Delta < 0:
+		return "-" + utils.FormatBytes(-n.Delta)
+	}
+	return ""
+}
+
+// analysisEntry is the JSON form of a DiskNode without its subtree.
+type analysisEntry struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	Allocated   int64     `json:"allocated"`
+	ItemCount   int       `json:"item_count"`
+	IsDirectory bool      `json:"is_directory"`
+	IsLink      bool      `json:"is_link,omitempty"`
+	Attributes  string    `json:"attributes,omitempty"`
+	ModTime     time.Time `json:"mod_time"`
+	Delta       *int64    `json:"delta,omitempty"`
+	IsNew       bool      `json:"is_new,omitempty"`
+}
+
+func newAnalysisEntry(n *analyzer.DiskNode, deltas bool) analysisEntry {
+	e := analysisEntry{
+		Name:        n.Name,
+		Path:        n.Path,
+		Size:        n.Size,
+		Allocated:   n.Allocated,
+		ItemCount:   n.ItemCount,
+		IsDirectory: n.IsDirectory,
+		IsLink:      n.IsLink,
+		Attributes:  n.Attrs.String(),
+		ModTime:     n.ModTime,
+	}
+	if deltas {
+		delta := n.Delta
+		e.Delta = &delta
+		e.IsNew = n.IsNew
+	}
+	return e
+}
+
+func printAnalysisJSON(tree *analyzer.DiskNode, largest []*analyzer.DiskNode, cache *analyzer.ScanCache) {
+	deltas := showDeltas(cache)
+
+	report := struct {
+		analysisEntry
+		PreviousScan *time.Time      `json:"previous_scan,omitempty"`
+		LargeFiles   int             `json:"large_files"`
+		Children     []analysisEntry `json:"children"`
+		LargestFiles []analysisEntry `json:"largest_files"`
+	}{
+		analysisEntry: newAnalysisEntry(tree, deltas),
+		LargeFiles:    tree.LargeFiles,
+		Children:      []analysisEntry{},
+		LargestFiles:  []analysisEntry{},
+	}
+
+	if deltas {
+		prev := cache.PreviousScan()
+		report.PreviousScan = &prev
+	}
+
+	for _, child := range tree.Children {
+		report.Children = append(report.Children, newAnalysisEntry(child, deltas))
+	}
+	for _, f := range largest {
+		report.LargestFiles = append(report.LargestFiles, newAnalysisEntry(f, deltas))
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		color.Red("Error encoding analysis: %v", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+func displayAnalysis(tree *analyzer.DiskNode, rootPath string, cache *analyzer.ScanCache) {
+	deltas := showDeltas(cache)
+
 	color.White("════════════════════════════════════════════════════════\n")
 	fmt.Printf("Path: %s\n", color.CyanString(rootPath))
-	fmt.Printf("Total Size: %s\n", color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(tree.Size)))
+	fmt.Printf("Total Size: %s (on disk: %s)\n",
+		color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(tree.Size)),
+		utils.FormatBytes(tree.Allocated))
 	fmt.Printf("Items: %s files and folders\n", color.WhiteString("%d", tree.ItemCount))
+	if deltas {
+		change := formatDelta(tree)
+		if change == "" {
+			change = "no change"
+		}
+		fmt.Printf("Changed since last scan (%s): %s\n",
+			cache.PreviousScan().Local().Format("2006-01-02 15:04"),
+			color.YellowString(change))
+	}
 	color.White("════════════════════════════════════════════════════════\n\n")
 
-	displayTopItems(tree, 20)
+	displayTopItems(tree, 20, deltas)
 }
 
-func displayTopItems(node *analyzer.DiskNode, limit int) {
+func displayTopItems(node *analyzer.DiskNode, limit int, deltas bool) {
 	if len(node.Children) == 0 {
 		color.Yellow("No items to display")
 		return
 	}
 
-	totalSize := node.Size
+	totalSize := nodeSize(node)
 	if totalSize <= 0 {
 		totalSize = 1
 	}
@@ -128,7 +505,7 @@ func displayTopItems(node *analyzer.DiskNode, limit int) {
 	for i := 0; i < displayCount; i++ {
 		child := node.Children[i]
 
-		percentage := float64(child.Size) / float64(totalSize) * 100
+		percentage := float64(nodeSize(child)) / float64(totalSize) * 100
 		if percentage > 100 {
 			percentage = 100
 		}
@@ -148,6 +525,9 @@ func displayTopItems(node *analyzer.DiskNode, limit int) {
 		if child.IsDirectory {
 			icon = "D"
 		}
+		if child.IsLink {
+			icon = "L"
+		}
 
 		barColor := color.GreenString
 		if percentage > 20 {
@@ -163,13 +543,27 @@ func displayTopItems(node *analyzer.DiskNode, limit int) {
 			percentage,
 			icon,
 			utils.TruncateString(child.Name, 40),
-			color.CyanString(utils.FormatBytes(child.Size)),
+			color.CyanString(utils.FormatBytes(nodeSize(child))),
 		)
 
 		if child.IsDirectory && child.ItemCount > 0 {
 			fmt.Printf("  (%d items)", child.ItemCount)
 		}
 
+		if child.IsLink && child.LinkTarget != "" {
+			fmt.Printf("  -> %s", child.LinkTarget)
+		}
+
+		if child.Attrs.Has(utils.AttrOffline) {
+			fmt.Printf("  %s", color.BlueString("(cloud only)"))
+		}
+
+		if deltas {
+			if change := formatDelta(child); change != "" {
+				fmt.Printf("  %s", color.YellowString(change))
+			}
+		}
+
 		fmt.Println()
 	}
 
@@ -190,7 +584,7 @@ func displayLargeFiles(files []*analyzer.DiskNode) {
 		fmt.Printf("  %2d. %-50s %10s\n",
 			i+1,
 			utils.TruncateString(f.Path, 50),
-			color.CyanString(utils.FormatBytes(f.Size)),
+			color.CyanString(utils.FormatBytes(nodeSize(f))),
 		)
 	}
 
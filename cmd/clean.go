@@ -1,18 +1,35 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/zs0c131y/burrow/internal/cleanup"
+	"github.com/zs0c131y/burrow/internal/history"
+	"github.com/zs0c131y/burrow/internal/locks"
+	"github.com/zs0c131y/burrow/pkg/models"
 	"github.com/zs0c131y/burrow/pkg/utils"
 )
 
 var (
-	whitelistMode bool
-	categories    []string
+	whitelistMode  bool
+	categories     []string
+	candidatesFile string
+	useTrash       bool
+
+	recycleOlderThan int
+
+	logAction         string
+	compressOlderThan int
+	compressFormat    string
+	compressDir       string
+
+	cleanReport    string
+	deleteOnReboot bool
 )
 
 var cleanCmd = &cobra.Command{
@@ -23,11 +40,38 @@ var cleanCmd = &cobra.Command{
   - Browser caches (Chrome, Firefox, Edge, Brave)
   - Windows Update cache
   - Application caches
-  - System logs and event logs
-  - Recycle Bin
+  - System logs (event logs are archived and cleared with 'wm logs')
+  - Recycle Bin (the current user's bin on every fixed drive)
   - Thumbnails and icon cache
-  - Prefetch files`,
+  - Prefetch files
+
+Before cleaning, every file is probed: the estimate leaves out whitelisted
+entries, and files found open or without delete access are reported
+separately, so the guaranteed figure is what nothing stands in the way of
+removing. After the cleanup the space freed is compared with the estimate.
+
+--log-action compress keeps log files instead of deleting them: files older
+than --compress-older-than days are gzipped (or zipped) in place or into
+--compress-dir, each archive is read back and verified, and only then is the
+original removed. Files whose archive would not be smaller are left as they
+are. The space this reclaims is reported separately.
+
+--report writes a manifest of every file the cleanup removed, compressed or
+left behind, with the reason (locked, permission_denied, not_found, changed,
+incompressible, protected). A path ending in .csv writes CSV, anything else JSON.
+With --dry-run it lists what the cleanup would do and is marked as a dry run.
+
+Files another process keeps open are listed with the process holding them.
+--delete-on-reboot schedules them to be deleted when Windows next starts;
+this covers only files being deleted outright, never Recycle Bin items or
+logs being compressed.
+
+--candidates deletes the files in a candidate list written by
+'wm analyze --stale --export-candidates' instead. Files that changed since
+the list was written, and whitelisted files, are skipped. With --trash they
+are sent to the Recycle Bin instead of being deleted.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		applyCleanSettings(cmd)
 		runCleanup()
 	},
 }
@@ -35,6 +79,15 @@ var cleanCmd = &cobra.Command{
 func init() {
 	cleanCmd.Flags().BoolVar(&whitelistMode, "whitelist", false, "Manage protected paths that won't be cleaned")
 	cleanCmd.Flags().StringSliceVar(&categories, "categories", []string{}, "Specific categories to clean (temp,cache,logs,browser,updates)")
+	cleanCmd.Flags().StringVar(&candidatesFile, "candidates", "", "Delete the files in a candidate list instead of the cleanup categories")
+	cleanCmd.Flags().BoolVar(&useTrash, "trash", false, "Send --candidates files to the Recycle Bin instead of deleting them")
+	cleanCmd.Flags().IntVar(&recycleOlderThan, "recycle-older-than", 0, "Only empty Recycle Bin items deleted more than this many days ago")
+	cleanCmd.Flags().StringVar(&logAction, "log-action", "delete", "What to do with log files: delete or compress")
+	cleanCmd.Flags().IntVar(&compressOlderThan, "compress-older-than", 7, "Only compress log files last modified more than this many days ago")
+	cleanCmd.Flags().StringVar(&compressFormat, "compress-format", "gzip", "Archive format for compressed logs: gzip or zip")
+	cleanCmd.Flags().StringVar(&compressDir, "compress-dir", "", "Folder to keep compressed logs in (default: next to the originals)")
+	cleanCmd.Flags().StringVar(&cleanReport, "report", "", "Write a per-file manifest of the cleanup to this file (.json or .csv)")
+	cleanCmd.Flags().BoolVar(&deleteOnReboot, "delete-on-reboot", false, "Schedule files that are locked by other processes for deletion at the next restart")
 }
 
 func runCleanup() {
@@ -43,6 +96,20 @@ func runCleanup() {
 		return
 	}
 
+	if deleteOnReboot && useTrash {
+		color.Red("Error: --delete-on-reboot deletes files and cannot be used with --trash")
+		return
+	}
+
+	if candidatesFile != "" {
+		runCandidateCleanup()
+		return
+	}
+	if useTrash {
+		color.Red("Error: --trash only applies with --candidates")
+		return
+	}
+
 	if !dryRun {
 		if err := utils.RequireAdmin(); err != nil {
 			color.Red("Error: %v", err)
@@ -58,11 +125,29 @@ func runCleanup() {
 		color.Yellow("DRY RUN MODE - No files will be deleted\n")
 	}
 
+	if !dryRun {
+		lock, err := acquireCleanupLock()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		defer lock.Release()
+	}
+
 	color.White("Scanning system for cleanup targets...\n")
 
 	startTime := time.Now()
 
 	manager := cleanup.NewCleanupManager(debugMode, dryRun)
+	manager.SetRetries(loadSettings().Clean.Retries)
+	manager.SetRecycleBinAge(recycleOlderThan)
+	if err := setLogAction(manager, logAction, compressOlderThan, compressFormat, compressDir); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	if cleanReport != "" {
+		manager.KeepManifest()
+	}
 
 	targets, err := manager.DiscoverTargets(categories)
 	if err != nil {
@@ -80,6 +165,11 @@ func runCleanup() {
 
 	totalSize := int64(0)
 	totalFiles := 0
+	totalProtected := 0
+	compressSize := int64(0)
+	compressFiles := 0
+	blockedSize := int64(0)
+	blockedFiles := 0
 
 	for _, target := range targets {
 		statusIcon := "*"
@@ -89,24 +179,57 @@ func runCleanup() {
 			statusColor = color.YellowString
 		}
 
-		fmt.Printf("  %s %-40s %10s (%d files)\n",
+		fmt.Printf("  %s %-40s %10s (%d files)",
 			statusColor(statusIcon),
 			utils.TruncateString(target.Name, 40),
 			color.CyanString(utils.FormatBytes(target.Size)),
 			target.ItemCount,
 		)
+		if target.Action == models.ActionCompress {
+			fmt.Printf(" %s", color.CyanString("[compress]"))
+		}
+		if target.ProtectedItems > 0 {
+			fmt.Printf(" %s", color.YellowString("[%d protected]", target.ProtectedItems))
+		}
+		if target.BlockedItems > 0 && !target.Protected {
+			fmt.Printf(" %s", color.YellowString("[%d in use]", target.BlockedItems))
+		}
+		fmt.Println()
 
-		if !target.Protected {
+		if target.Protected {
+			continue
+		}
+		totalProtected += target.ProtectedItems
+		if target.Action == models.ActionCompress {
+			compressSize += target.Size
+			compressFiles += target.ItemCount
+		} else {
 			totalSize += target.Size
 			totalFiles += target.ItemCount
+			blockedSize += target.BlockedSize
+			blockedFiles += target.BlockedItems
 		}
 	}
 
 	color.White("\n════════════════════════════════════════════════════════\n")
-	fmt.Printf("Total Space to Free: %s | Files: %d\n",
+	fmt.Printf("Estimated Space to Free: %s | Files: %d\n",
 		color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(totalSize)),
 		totalFiles,
 	)
+	fmt.Printf("Guaranteed Space to Free: %s\n", color.GreenString(utils.FormatBytes(totalSize-blockedSize)))
+	if blockedFiles > 0 {
+		fmt.Printf("In use or not deletable: %s\n",
+			color.YellowString("%d files (%s)", blockedFiles, utils.FormatBytes(blockedSize)))
+	}
+	if compressFiles > 0 {
+		fmt.Printf("Logs to Compress: %s | Files: %d\n",
+			color.CyanString(utils.FormatBytes(compressSize)),
+			compressFiles,
+		)
+	}
+	if totalProtected > 0 {
+		fmt.Printf("Protected by whitelist (skipped): %s\n", color.YellowString("%d", totalProtected))
+	}
 	color.White("════════════════════════════════════════════════════════\n\n")
 
 	if dryRun {
@@ -124,7 +247,136 @@ func runCleanup() {
 
 	summary := manager.ExecuteCleanup(targets)
 
+	entry := history.Entry{
+		Time:     startTime,
+		Command:  "clean",
+		Trigger:  history.TriggerManual,
+		Profile:  profileName,
+		Duration: time.Since(startTime),
+	}
+	fillHistoryEntry(&entry, summary)
+	if err := history.Append(entry); err != nil && debugMode {
+		color.Yellow("Warning: cannot record history: %v", err)
+	}
+
 	displayCleanupResults(summary, time.Since(startTime))
+	handleLockedFiles(summary)
+	writeCleanReport(manager)
+}
+
+// runCandidateCleanup deletes the files listed in candidatesFile.
+func runCandidateCleanup() {
+	list, err := cleanup.ReadCandidates(candidatesFile)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if len(list.Items) == 0 {
+		color.Green("The candidate list is empty.")
+		return
+	}
+
+	if dryRun {
+		color.Yellow("DRY RUN MODE - No files will be deleted\n")
+	}
+
+	color.White("Candidate list: %s\n", color.CyanString(candidatesFile))
+	if list.Source != "" {
+		color.White("Created by: %s (%s)\n", list.Source, list.Generated.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("Files: %d | Size: %s\n\n",
+		len(list.Items),
+		color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(list.TotalSize())),
+	)
+
+	if !dryRun && !confirmAction("Delete these files?") {
+		color.Yellow("Cleanup cancelled.")
+		return
+	}
+	if !dryRun {
+		lock, err := acquireCleanupLock()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		defer lock.Release()
+	}
+
+	startTime := time.Now()
+
+	manager := cleanup.NewCleanupManager(debugMode, dryRun)
+	manager.SetRetries(loadSettings().Clean.Retries)
+	if useTrash {
+		manager.SetDisposal(cleanup.DisposeTrash)
+	}
+	if cleanReport != "" {
+		manager.KeepManifest()
+	}
+	summary := manager.CleanCandidates(list, "Candidate list")
+
+	if !dryRun {
+		entry := history.Entry{
+			Time:     startTime,
+			Command:  "clean --candidates",
+			Trigger:  history.TriggerManual,
+			Profile:  profileName,
+			Duration: time.Since(startTime),
+		}
+		fillHistoryEntry(&entry, summary)
+		if err := history.Append(entry); err != nil && debugMode {
+			color.Yellow("Warning: cannot record history: %v", err)
+		}
+	}
+
+	displayCleanupResults(summary, time.Since(startTime))
+	handleLockedFiles(summary)
+	writeCleanReport(manager)
+}
+
+// handleLockedFiles schedules the files other processes kept open for
+// deletion at the next restart when --delete-on-reboot is given, and
+// otherwise points out the option.
+func handleLockedFiles(summary *cleanup.CleanupSummary) {
+	locked := summary.LockedFiles()
+	if len(locked) == 0 || dryRun {
+		return
+	}
+	if !deleteOnReboot {
+		color.White("Use --delete-on-reboot to delete the locked files when Windows next starts.")
+		return
+	}
+
+	var scheduled int
+	var size int64
+	for _, f := range locked {
+		if err := locks.DeleteOnReboot(f.Path); err != nil {
+			if errors.Is(err, locks.ErrUnsupported) {
+				color.Yellow("Deleting files at the next restart is not supported on this system.")
+				return
+			}
+			if debugMode {
+				color.Yellow("  Warning: %v", err)
+			}
+			continue
+		}
+		scheduled++
+		size += f.Size
+	}
+	color.Green("Scheduled %d locked files (%s) for deletion at the next restart.", scheduled, utils.FormatBytes(size))
+}
+
+// writeCleanReport saves the manager's manifest to the --report file.
+func writeCleanReport(manager *cleanup.CleanupManager) {
+	m := manager.Manifest()
+	if cleanReport == "" || m == nil {
+		return
+	}
+	if err := cleanup.WriteManifest(cleanReport, m); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	color.White("Report: %s (%d files)", color.CyanString(cleanReport), len(m.Entries))
 }
 
 func displayCleanupResults(summary *cleanup.CleanupSummary, duration time.Duration) {
@@ -144,6 +396,26 @@ func displayCleanupResults(summary *cleanup.CleanupSummary, duration time.Durati
 		color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(summary.TotalSpaceFreed)),
 	)
 	fmt.Printf("Files Removed: %s\n", color.CyanString("%d", summary.TotalFilesRemoved))
+	if accuracy, ok := summary.EstimateAccuracy(); ok && !dryRun {
+		fmt.Printf("Estimate Accuracy: %s of the estimated %s (guaranteed %s)\n",
+			color.CyanString("%.0f%%", accuracy),
+			utils.FormatBytes(summary.EstimatedSpace),
+			utils.FormatBytes(summary.GuaranteedSpace),
+		)
+	}
+	if summary.TotalFilesCompressed > 0 {
+		fmt.Printf("Space Reclaimed by Compression: %s (%d files)\n",
+			color.GreenString(utils.FormatBytes(summary.TotalSpaceCompressed)),
+			summary.TotalFilesCompressed,
+		)
+	}
+	if summary.TotalProtected > 0 {
+		fmt.Printf("Protected/Skipped: %s\n", color.YellowString("%d", summary.TotalProtected))
+	}
+	if reasons := cleanup.DescribeSkipped(summary.TotalSkipped); reasons != "" {
+		fmt.Printf("Not Removed: %s\n", color.YellowString(reasons))
+	}
+	displayLockedFiles(summary.LockedFiles())
 	fmt.Printf("Duration: %s\n", color.WhiteString(utils.FormatDuration(duration)))
 
 	if debugMode && len(summary.Results) > 0 {
@@ -160,6 +432,56 @@ func displayCleanupResults(summary *cleanup.CleanupSummary, duration time.Durati
 	color.White("════════════════════════════════════════════════════════\n")
 }
 
+// setLogAction configures whether manager deletes or compresses log files.
+func setLogAction(manager *cleanup.CleanupManager, action string, days int, format, dir string) error {
+	switch action {
+	case "delete", "":
+		return nil
+	case "compress":
+	default:
+		return fmt.Errorf("unknown log action %q (use delete or compress)", action)
+	}
+	if format != cleanup.CompressGzip && format != cleanup.CompressZip {
+		return fmt.Errorf("unknown compression format %q (use gzip or zip)", format)
+	}
+	if days < 0 {
+		days = 0
+	}
+	manager.SetLogCompression(cleanup.CompressOptions{
+		Format: format,
+		MinAge: time.Duration(days) * 24 * time.Hour,
+		Dir:    utils.ExpandEnvPath(dir),
+	})
+	return nil
+}
+
+// maxLockedShown caps the locked files listed after a cleanup.
+const maxLockedShown = 10
+
+// displayLockedFiles lists locked files with the processes holding them.
+func displayLockedFiles(locked []cleanup.LockedFile) {
+	if len(locked) == 0 {
+		return
+	}
+
+	color.Yellow("\nLocked by other processes:")
+	for i, f := range locked {
+		if i == maxLockedShown {
+			fmt.Printf("  ... and %d more\n", len(locked)-maxLockedShown)
+			break
+		}
+		holder := "unknown process"
+		if len(f.Holders) > 0 {
+			names := make([]string, len(f.Holders))
+			for j, p := range f.Holders {
+				names[j] = p.String()
+			}
+			holder = strings.Join(names, ", ")
+		}
+		fmt.Printf("  %s\n    held by %s\n", utils.TruncateString(f.Path, 70), color.CyanString(holder))
+	}
+}
+
 func confirmAction(message string) bool {
 	fmt.Printf("%s (y/N): ", message)
 	var response string
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/cleanup"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/optimize"
+)
+
+var (
+	profileName  string
+	outputFormat string
+	settings     *config.Settings
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and edit Burrow settings",
+	Long: `Manage settings stored in config.toml in the Burrow config directory.
+
+Settings are resolved from built-in defaults, then config.toml, then the
+selected profile (--profile or BURROW_PROFILE), then environment variables
+named after the key (e.g. BURROW_ANALYZE_DEPTH). Command-line flags always win.
+
+Keys: ` + strings.Join(config.Keys(), ", "),
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective settings",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigShow()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a single effective setting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigGet(args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Store a setting in config.toml (in a profile with --profile)",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigSet(args[0], args[1])
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check config.toml and all profiles for errors",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigValidate()
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
+
+// loadSettings returns the effective settings, loading them on first use.
+// Without a config file the defaults apply. An unknown profile or an invalid
+// config file is reported and the command stops, rather than going on with
+// settings the user did not ask for.
+func loadSettings() *config.Settings {
+	if settings != nil {
+		return settings
+	}
+
+	s, err := config.Load(profileName)
+	if err != nil {
+		color.Red("Error: %v", err)
+		color.White("Run 'wm config validate' to check the configuration.")
+		os.Exit(1)
+	}
+
+	settings = s
+	return settings
+}
+
+// getOutputFormat returns the --output flag if given, else the configured format.
+func getOutputFormat() string {
+	if outputFormat != "" {
+		return outputFormat
+	}
+	return loadSettings().Output.Format
+}
+
+// applyCleanSettings fills clean flags the user did not set from the config.
+func applyCleanSettings(cmd *cobra.Command) {
+	s := loadSettings()
+	if !cmd.Flags().Changed("categories") {
+		categories = s.Clean.Categories
+	}
+	if !cmd.Flags().Changed("recycle-older-than") {
+		recycleOlderThan = s.Clean.RecycleBinDays
+	}
+	if !cmd.Flags().Changed("log-action") {
+		logAction = s.Clean.LogAction
+	}
+	if !cmd.Flags().Changed("compress-older-than") {
+		compressOlderThan = s.Clean.CompressDays
+	}
+	if !cmd.Flags().Changed("compress-format") {
+		compressFormat = s.Clean.CompressFormat
+	}
+	if !cmd.Flags().Changed("compress-dir") {
+		compressDir = s.Clean.CompressDir
+	}
+}
+
+// applyAnalyzeSettings fills analyze flags the user did not set from the config.
+func applyAnalyzeSettings(cmd *cobra.Command) {
+	s := loadSettings()
+	flags := cmd.Flags()
+	if !flags.Changed("depth") {
+		analyzeDepth = s.Analyze.Depth
+	}
+	if !flags.Changed("min-size") {
+		minSize = s.Analyze.MinSize
+	}
+	if !flags.Changed("hidden") {
+		showHidden = s.Analyze.Hidden
+	}
+	if !flags.Changed("follow-links") {
+		followLinks = s.Analyze.FollowLinks
+	}
+	if !flags.Changed("workers") {
+		analyzeWorkers = s.Analyze.Workers
+	}
+	if !flags.Changed("no-cache") {
+		noCache = !s.Analyze.Cache
+	}
+	if !flags.Changed("size") {
+		sizeMode = s.Analyze.Size
+	}
+	if !flags.Changed("exclude") {
+		excludes = s.Analyze.Exclude
+	}
+	if !flags.Changed("one-file-system") {
+		oneFileSystem = s.Analyze.OneFileSystem
+	}
+}
+
+func runConfigShow() {
+	s := loadSettings()
+
+	if getOutputFormat() == config.FormatJSON {
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			color.Red("Error encoding settings: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	path, _ := config.Path()
+	color.White("# Config file: %s", path)
+	if profileName != "" {
+		color.White("# Profile: %s", profileName)
+	}
+	if names, err := config.Profiles(); err == nil && len(names) > 0 {
+		color.White("# Available profiles: %s", strings.Join(names, ", "))
+	}
+	fmt.Println()
+
+	out, err := s.Encode()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	fmt.Print(out)
+}
+
+func runConfigGet(key string) {
+	value, err := loadSettings().Get(key)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	fmt.Println(value)
+}
+
+func runConfigSet(key, value string) {
+	if err := config.SetInFile(profileName, key, value); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if profileName != "" {
+		color.Green("Set %s = %s in profile %q", key, value, profileName)
+	} else {
+		color.Green("Set %s = %s", key, value)
+	}
+}
+
+func runConfigValidate() {
+	path, _ := config.Path()
+
+	if err := config.ValidateFile(); err != nil {
+		color.Red("x %s: %v", path, err)
+		return
+	}
+
+	s, err := config.Load(profileName)
+	if err != nil {
+		color.Red("x %v", err)
+		return
+	}
+
+	valid := true
+	for _, c := range s.Clean.Categories {
+		if !cleanup.IsKnownCategory(c) {
+			color.Red("x clean.categories: unknown category %q", c)
+			valid = false
+		}
+	}
+	for _, name := range s.Optimize.DisabledTasks {
+		if !optimize.IsKnownTask(name) {
+			color.Red("x optimize.disabled_tasks: unknown task %q", name)
+			valid = false
+		}
+	}
+
+	if valid {
+		color.Green("* %s is valid", path)
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/cleanup"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/history"
+	"github.com/zs0c131y/burrow/internal/schedule"
+	"github.com/zs0c131y/burrow/internal/watch"
+	"github.com/zs0c131y/burrow/pkg/models"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run scheduled cleanup jobs in the foreground",
+	Long: `Runs the jobs defined with 'wm schedule' until interrupted.
+
+Only one daemon may run at a time; a lockfile in the Burrow config
+directory guards against a second instance. Each run is recorded in the
+run history (see 'wm history') and logged to daemon.log in the logs folder
+of the config directory. Interrupting the daemon (Ctrl+C or SIGTERM) stops
+a running cleanup after the file it is removing, and the run is recorded as
+interrupted. Use --dry-run to exercise the schedule without
+deleting anything.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runDaemon()
+	},
+}
+
+func runDaemon() {
+	if !dryRun {
+		if err := utils.RequireAdmin(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	}
+
+	lockPath, err := schedule.DefaultLockPath()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	lock, err := schedule.AcquireLock(lockPath)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	defer lock.Release()
+
+	state, err := schedule.LoadState()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger, closeLog := openLogger("daemon.log")
+	defer closeLog()
+
+	d := schedule.NewDaemon(state, schedule.LoadJobs, func(ctx context.Context, job schedule.Job) history.Entry {
+		return runCleanupJob(ctx, job.Categories, job.Profile, "")
+	})
+	d.Logf = logger.Printf
+
+	logger.Printf("burrow daemon started (pid %d)", os.Getpid())
+	if dryRun {
+		logger.Printf("dry run: no files will be deleted")
+	}
+	d.Run(ctx)
+	logger.Printf("burrow daemon stopped")
+}
+
+// runCleanupJob runs an unattended cleanup with the settings of profile and
+// returns the outcome as a history entry. An empty category list falls back
+// to the profile's clean.categories. A non-empty mount limits the cleanup to
+// the targets on that volume. Once ctx is done the cleanup stops after the
+// file it is removing. The shared cleanup lock is held throughout, so a job
+// that starts while another cleanup runs fails instead.
+func runCleanupJob(ctx context.Context, categories []string, profile, mount string) history.Entry {
+	start := time.Now()
+	entry := history.Entry{
+		Time:    start,
+		Command: "clean",
+		Profile: profile,
+		DryRun:  dryRun,
+	}
+
+	lock, err := acquireCleanupLock()
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	defer lock.Release()
+
+	s, err := config.Load(profile)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	if len(categories) == 0 {
+		categories = s.Clean.Categories
+	}
+
+	manager := cleanup.NewCleanupManager(debugMode, dryRun)
+	manager.SetContext(ctx)
+	manager.SetRetries(s.Clean.Retries)
+	manager.SetRecycleBinAge(s.Clean.RecycleBinDays)
+	if err := setLogAction(manager, s.Clean.LogAction, s.Clean.CompressDays, s.Clean.CompressFormat, s.Clean.CompressDir); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	targets, err := manager.DiscoverTargets(categories)
+	if err != nil {
+		entry.Error = fmt.Sprintf("discovering targets: %v", err)
+		entry.Duration = time.Since(start)
+		return entry
+	}
+	if mount != "" {
+		var onVolume []*models.CleanupTarget
+		for _, target := range targets {
+			if watch.OnVolume(target.Path, mount) {
+				onVolume = append(onVolume, target)
+			}
+		}
+		targets = onVolume
+	}
+
+	summary := manager.ExecuteCleanup(targets)
+	fillHistoryEntry(&entry, summary)
+	if ctx.Err() != nil {
+		entry.Success = false
+		entry.Error = "interrupted"
+	}
+	entry.Duration = time.Since(start)
+	return entry
+}
+
+// acquireCleanupLock takes the lock held while any cleanup runs.
+func acquireCleanupLock() (*schedule.Lock, error) {
+	path, err := schedule.CleanupLockPath()
+	if err != nil {
+		return nil, err
+	}
+	return schedule.AcquireLock(path)
+}
+
+// fillHistoryEntry copies the totals of a cleanup run into entry.
+func fillHistoryEntry(entry *history.Entry, summary *cleanup.CleanupSummary) {
+	entry.SpaceFreed = summary.TotalSpaceFreed
+	entry.SpaceCompressed = summary.TotalSpaceCompressed
+	entry.Skipped = summary.TotalSkipped
+	entry.FilesRemoved = summary.TotalFilesRemoved
+	entry.Success = summary.FailedCleans == 0
+	if summary.FailedCleans > 0 {
+		entry.Error = fmt.Sprintf("%d of %d targets failed", summary.FailedCleans, summary.TotalTargets)
+	}
+}
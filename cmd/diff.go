@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/analyzer"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/export"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var diffTop int
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [path | snapshotA snapshotB]",
+	Short: "Show what grew or shrank between two scans",
+	Long: `Compares two scans and lists the folders and files that were added,
+removed, grew or shrank, ranked by how many bytes changed, with the growth
+rate of the whole tree between the two scans.
+
+With two arguments, both are JSON snapshots written by
+'wm analyze --export file.json'. With a path (default C:\), the path is
+scanned again and compared with the previous cached scan of it.
+
+Only the depth reached by both scans is compared.`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		applyAnalyzeSettings(cmd)
+
+		var d *analyzer.Diff
+		var err error
+		if len(args) == 2 {
+			d, err = diffSnapshots(args[0], args[1])
+		} else {
+			path := "C:\\"
+			if len(args) == 1 {
+				path = args[0]
+			}
+			d, err = diffWithCache(path)
+		}
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if getOutputFormat() == config.FormatJSON {
+			printDiffJSON(d)
+			return
+		}
+		displayDiff(d)
+	},
+}
+
+func init() {
+	diffCmd.Flags().IntVarP(&diffTop, "top", "n", 20, "Number of changes to show")
+	diffCmd.Flags().IntVarP(&analyzeDepth, "depth", "d", 3, "Maximum depth to scan when comparing with the cached scan (1-10)")
+	addScanFilterFlags(diffCmd)
+
+	analyzeCmd.AddCommand(diffCmd)
+}
+
+func readSnapshot(path string) (*analyzer.DiskNode, time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	root, scanned, err := export.ReadJSON(f)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return root, scanned, nil
+}
+
+func diffSnapshots(pathA, pathB string) (*analyzer.Diff, error) {
+	a, fromTime, err := readSnapshot(pathA)
+	if err != nil {
+		return nil, err
+	}
+	b, toTime, err := readSnapshot(pathB)
+	if err != nil {
+		return nil, err
+	}
+	if !sameRoot(a.Path, b.Path) {
+		return nil, fmt.Errorf("snapshots are of different paths (%s and %s)", a.Path, b.Path)
+	}
+
+	return analyzer.Compare(a.Path, analyzer.Sizes(a), analyzer.Sizes(b), fromTime, toTime), nil
+}
+
+func diffWithCache(path string) (*analyzer.Diff, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	if !utils.PathExists(absPath) {
+		return nil, fmt.Errorf("path does not exist: %s", absPath)
+	}
+
+	if analyzeDepth < 1 {
+		analyzeDepth = 1
+	}
+	if analyzeDepth > 10 {
+		analyzeDepth = 10
+	}
+
+	cache, err := analyzer.OpenCache(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("scan cache unavailable: %w", err)
+	}
+
+	a := analyzer.NewAnalyzer(debugMode, showHidden, analyzeDepth, minSize*1024*1024)
+	a.SetFollowLinks(followLinks)
+	a.SetWorkers(analyzeWorkers)
+	a.SetSizeMode(sizeMode)
+	if err := applyScanFilters(a); err != nil {
+		return nil, err
+	}
+	a.SetCache(cache)
+
+	tree, err := a.AnalyzePath(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if !cache.HasDeltas() {
+		saveScanCache(cache)
+		return nil, fmt.Errorf("no earlier scan of %s with the same settings; this scan was saved, run the diff again later", absPath)
+	}
+
+	d := analyzer.Compare(absPath, cache.PreviousSizes(), analyzer.Sizes(tree), cache.PreviousScan(), time.Now())
+	saveScanCache(cache)
+	return d, nil
+}
+
+// sameRoot compares two paths the way Windows does, ignoring case.
+func sameRoot(a, b string) bool {
+	return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+}
+
+// formatSigned renders a byte count with an explicit sign.
+func formatSigned(n int64) string {
+	if n < 0 {
+		return "-" + utils.FormatBytes(-n)
+	}
+	return "+" + utils.FormatBytes(n)
+}
+
+func displayDiff(d *analyzer.Diff) {
+	color.White("════════════════════════════════════════════════════════\n")
+	fmt.Printf("Path: %s\n", color.CyanString(d.Root))
+	if !d.From.IsZero() && !d.To.IsZero() {
+		fmt.Printf("Between: %s and %s\n",
+			d.From.Local().Format("2006-01-02 15:04"), d.To.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("Total: %s -> %s (%s)\n",
+		utils.FormatBytes(d.Before), utils.FormatBytes(d.After),
+		color.YellowString(formatSigned(d.After-d.Before)))
+	if rate, ok := d.GrowthPerDay(); ok {
+		fmt.Printf("Growth rate: %s per day\n", color.YellowString(formatSigned(int64(rate))))
+	}
+	color.White("════════════════════════════════════════════════════════\n\n")
+
+	if len(d.Changes) == 0 {
+		color.Green("No changes.")
+		return
+	}
+
+	for i, c := range d.Changes {
+		if i == diffTop {
+			color.White("\n... and %d more changes (use -n to show more)", len(d.Changes)-i)
+			break
+		}
+
+		kind := color.YellowString
+		switch c.Kind {
+		case analyzer.ChangeAdded:
+			kind = color.RedString
+		case analyzer.ChangeRemoved, analyzer.ChangeShrunk:
+			kind = color.GreenString
+		}
+
+		fmt.Printf(" %-8s %12s  %10s -> %-10s %s\n",
+			kind(c.Kind),
+			formatSigned(c.Delta()),
+			utils.FormatBytes(c.Before),
+			utils.FormatBytes(c.After),
+			c.Path,
+		)
+	}
+}
+
+func printDiffJSON(d *analyzer.Diff) {
+	type change struct {
+		Path   string `json:"path"`
+		Kind   string `json:"kind"`
+		Before int64  `json:"before"`
+		After  int64  `json:"after"`
+		Delta  int64  `json:"delta"`
+	}
+
+	report := struct {
+		Path         string    `json:"path"`
+		From         time.Time `json:"from"`
+		To           time.Time `json:"to"`
+		Before       int64     `json:"before"`
+		After        int64     `json:"after"`
+		GrowthPerDay *float64  `json:"growth_per_day,omitempty"`
+		Changes      []change  `json:"changes"`
+	}{
+		Path:    d.Root,
+		From:    d.From,
+		To:      d.To,
+		Before:  d.Before,
+		After:   d.After,
+		Changes: []change{},
+	}
+	if rate, ok := d.GrowthPerDay(); ok {
+		report.GrowthPerDay = &rate
+	}
+	for i, c := range d.Changes {
+		if i == diffTop {
+			break
+		}
+		report.Changes = append(report.Changes, change{c.Path, c.Kind, c.Before, c.After, c.Delta()})
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		color.Red("Error encoding diff: %v", err)
+		return
+	}
+	fmt.Println(string(data))
+}
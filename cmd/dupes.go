@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/analyzer"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/trash"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var (
+	dupesMinSize  int64
+	dupesKeep     string
+	dupesPriority []string
+	dupesDelete   bool
+	dupesHardlink bool
+)
+
+var dupesCmd = &cobra.Command{
+	Use:   "dupes [path]",
+	Short: "Find and remove duplicate files",
+	Long: `Finds files with identical content under a path.
+
+Candidates are narrowed in stages: equal size, then a hash of the first
+8 KB, then of the last 8 KB, and finally a SHA-256 of the whole file, so
+only files that could still be duplicates are read in full.
+
+One copy of each group is kept, chosen by --keep:
+  newest    most recently modified (default)
+  oldest    least recently modified
+  priority  the first file under a --priority path, then newest
+
+Without --delete or --hardlink the groups are only reported. Each copy is
+re-verified before it is removed or replaced.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "C:\\"
+		if len(args) > 0 {
+			path = args[0]
+		}
+		applyAnalyzeSettings(cmd)
+		runDupes(path)
+	},
+}
+
+func init() {
+	dupesCmd.Flags().Int64Var(&dupesMinSize, "min-size", 1, "Ignore files smaller than this many MB")
+	dupesCmd.Flags().StringVar(&dupesKeep, "keep", analyzer.KeepNewest, "Which copy to keep: newest, oldest or priority")
+	dupesCmd.Flags().StringSliceVar(&dupesPriority, "priority", []string{}, "Paths whose copies are kept first, in order (with --keep priority)")
+	dupesCmd.Flags().BoolVar(&dupesDelete, "delete", false, "Delete the duplicate copies")
+	dupesCmd.Flags().BoolVar(&dupesHardlink, "hardlink", false, "Replace duplicate copies with hardlinks to the kept file")
+	dupesCmd.Flags().BoolVar(&useTrash, "trash", false, "Send deleted copies to the Recycle Bin (with --delete)")
+	dupesCmd.Flags().BoolVar(&showHidden, "hidden", false, "Include hidden files and folders")
+	dupesCmd.Flags().IntVar(&analyzeWorkers, "workers", 0, "Files to scan and hash concurrently (0 = automatic)")
+	addScanFilterFlags(dupesCmd)
+
+	analyzeCmd.AddCommand(dupesCmd)
+}
+
+func runDupes(path string) {
+	if dupesDelete && dupesHardlink {
+		color.Red("Error: use either --delete or --hardlink, not both")
+		return
+	}
+	if useTrash && !dupesDelete {
+		color.Red("Error: --trash only applies with --delete")
+		return
+	}
+	if dupesKeep == analyzer.KeepPriority && len(dupesPriority) == 0 {
+		color.Red("Error: --keep priority needs at least one --priority path")
+		return
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		color.Red("Invalid path: %v", err)
+		return
+	}
+	if !utils.PathExists(absPath) {
+		color.Red("Path does not exist: %s", absPath)
+		return
+	}
+
+	jsonOut := getOutputFormat() == config.FormatJSON
+	if !jsonOut {
+		color.White("Scanning %s for duplicate files of at least %s...\n",
+			color.CyanString(absPath), utils.FormatBytes(dupesMinSize*1024*1024))
+	}
+
+	a := analyzer.NewAnalyzer(debugMode, showHidden, fullScanDepth, dupesMinSize*1024*1024)
+	a.SetWorkers(analyzeWorkers)
+	if err := applyScanFilters(a); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	tree, err := a.AnalyzePath(absPath)
+	if err != nil {
+		color.Red("Error analyzing path: %v", err)
+		return
+	}
+
+	groups, stats := a.FindDuplicates(tree)
+
+	type plan struct {
+		group  analyzer.DuplicateGroup
+		keep   *analyzer.DiskNode
+		remove []*analyzer.DiskNode
+	}
+	plans := make([]plan, 0, len(groups))
+	var wasted int64
+	var copies int
+	for _, g := range groups {
+		keep, remove, err := g.ChooseKeeper(dupesKeep, dupesPriority)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		plans = append(plans, plan{g, keep, remove})
+		wasted += g.Wasted()
+		copies += len(remove)
+	}
+
+	if jsonOut {
+		type dupeGroup struct {
+			Size   int64    `json:"size"`
+			Hash   string   `json:"sha256"`
+			Keep   string   `json:"keep"`
+			Remove []string `json:"remove"`
+		}
+		out := struct {
+			Groups []dupeGroup `json:"groups"`
+			Wasted int64       `json:"wasted"`
+		}{Groups: []dupeGroup{}, Wasted: wasted}
+		for _, p := range plans {
+			g := dupeGroup{Size: p.group.Size, Hash: p.group.Hash, Keep: p.keep.Path}
+			for _, r := range p.remove {
+				g.Remove = append(g.Remove, r.Path)
+			}
+			out.Groups = append(out.Groups, g)
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			color.Red("Error encoding duplicates: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if debugMode {
+		color.White("Files: %d | same size: %d | head hashed: %d | tail hashed: %d | fully hashed: %d\n",
+			stats.Files, stats.SameSize, stats.HeadHashed, stats.TailHashed, stats.FullHashed)
+	}
+
+	if len(plans) == 0 {
+		color.Green("No duplicate files found.")
+		return
+	}
+
+	color.White("\n════════════════════════════════════════════════════════\n")
+	for i, p := range plans {
+		fmt.Printf("%s %s x %d  (%s reclaimable)\n",
+			color.CyanString("%3d.", i+1),
+			utils.FormatBytes(p.group.Size),
+			len(p.group.Files),
+			color.YellowString(utils.FormatBytes(p.group.Wasted())),
+		)
+		fmt.Printf("     %s %s\n", color.GreenString("keep  "), p.keep.Path)
+		for _, r := range p.remove {
+			fmt.Printf("     %s %s\n", color.RedString("remove"), r.Path)
+		}
+	}
+	color.White("════════════════════════════════════════════════════════\n")
+	fmt.Printf("%d groups, %s reclaimable\n", len(plans), color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(wasted)))
+
+	if !dupesDelete && !dupesHardlink {
+		color.White("\nRun again with --delete or --hardlink to reclaim the space.")
+		return
+	}
+
+	verb := "Delete"
+	if dupesHardlink {
+		verb = "Replace with hardlinks"
+	} else if useTrash {
+		verb = "Move to the Recycle Bin"
+	}
+
+	if dryRun {
+		color.Yellow("\nDry run: %s would be applied to the copies marked remove.", strings.ToLower(verb))
+		return
+	}
+
+	if !confirmAction(fmt.Sprintf("\n%s %d duplicate copies?", verb, copies)) {
+		color.Yellow("Cancelled.")
+		return
+	}
+
+	retries := loadSettings().Clean.Retries
+	var freed int64
+	var failed int
+	for _, p := range plans {
+		// Removing the copies is only safe while the kept copy still has
+		// the content they duplicate.
+		if err := analyzer.VerifyDuplicate(p.group, p.keep); err != nil {
+			color.Yellow("  skipped %d copies of %s: %v", len(p.remove), p.keep.Path, err)
+			failed += len(p.remove)
+			continue
+		}
+		for _, r := range p.remove {
+			if err := analyzer.VerifyDuplicate(p.group, r); err != nil {
+				color.Yellow("  skipped %s: %v", r.Path, err)
+				failed++
+				continue
+			}
+
+			switch {
+			case dupesHardlink:
+				err = analyzer.ReplaceWithHardlink(p.keep.Path, r.Path)
+			case useTrash:
+				err = trash.Move(r.Path)
+			default:
+				err = utils.SafeDelete(r.Path, retries)
+			}
+			if err != nil {
+				color.Red("  x %s: %v", r.Path, err)
+				failed++
+				continue
+			}
+			freed += p.group.Size
+		}
+	}
+
+	fmt.Println()
+	color.Green("Reclaimed %s", utils.FormatBytes(freed))
+	if failed > 0 {
+		color.Yellow("%d copies were skipped or failed", failed)
+	}
+}
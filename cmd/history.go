@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/cleanup"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/history"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var historyLimit int
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent cleanup runs",
+	Long:  "Shows cleanup runs recorded by 'wm clean', 'wm schedule run' and 'wm daemon'.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runHistory()
+	},
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of runs to show (0 for all)")
+}
+
+func runHistory() {
+	entries, err := history.Read(historyLimit)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if getOutputFormat() == config.FormatJSON {
+		if entries == nil {
+			entries = []history.Entry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			color.Red("Error encoding history: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(entries) == 0 {
+		color.Yellow("No cleanup runs recorded yet.")
+		return
+	}
+
+	for _, e := range entries {
+		status := color.GreenString("ok  ")
+		if !e.Success {
+			status = color.RedString("fail")
+		}
+
+		source := e.Trigger
+		if e.Job != "" {
+			source += ":" + e.Job
+		}
+		if e.DryRun {
+			source += " (dry run)"
+		}
+
+		fmt.Printf("%s %s  %-28s %10s %7d files  %s\n",
+			status,
+			e.Time.Local().Format("2006-01-02 15:04"),
+			source,
+			utils.FormatBytes(e.SpaceFreed),
+			e.FilesRemoved,
+			utils.FormatDuration(e.Duration),
+		)
+		if e.SpaceCompressed > 0 {
+			fmt.Printf("     %s reclaimed by compressing logs\n", utils.FormatBytes(e.SpaceCompressed))
+		}
+		if reasons := cleanup.DescribeSkipped(e.Skipped); reasons != "" {
+			color.Yellow("     not removed: %s", reasons)
+		}
+		if e.Error != "" {
+			color.Red("     %s", e.Error)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/eventlog"
+	"github.com/zs0c131y/burrow/internal/logfile"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var (
+	logsMinSize int64
+	logsSelect  bool
+	logsBundle  string
+	logsClear   bool
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "List, archive and clear Windows event logs",
+	Long: `Works with the Windows event log channels kept in
+%WINDIR%\System32\winevt\Logs.
+
+'wm logs list' shows each channel's log file size and record count.
+'wm logs archive' exports channels into a compressed bundle and, with
+--clear, clears them, backing up and clearing each channel in one step.
+
+The logs of 'wm daemon' and 'wm watch' are written to the logs folder in the
+Burrow config directory and rotated by size (see logs.max_size and logs.keep).`,
+}
+
+var logsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List event log channels with size and record count",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLogsList()
+	},
+}
+
+var logsArchiveCmd = &cobra.Command{
+	Use:   "archive [channel]...",
+	Short: "Archive event log channels to a zip bundle, optionally clearing them",
+	Long: `Exports the named channels, e.g. "System" or
+"Microsoft-Windows-PowerShell/Operational", into a zip bundle. --select picks
+channels from the list instead.
+
+With --clear each channel is backed up and cleared in one step, so events
+written meanwhile are not lost, and the backups are bundled. A channel that
+cannot be cleared is left as it is. If the bundle cannot be written the
+backups are kept beside it. The bundle defaults to the logs folder
+in the Burrow config directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLogsArchive(args)
+	},
+}
+
+func init() {
+	logsListCmd.Flags().Int64Var(&logsMinSize, "min-size", 0, "Only show channels whose log file is at least this many MB")
+	logsArchiveCmd.Flags().Int64Var(&logsMinSize, "min-size", 0, "Only offer channels of at least this many MB with --select")
+	logsArchiveCmd.Flags().BoolVar(&logsSelect, "select", false, "Choose the channels to archive from a list")
+	logsArchiveCmd.Flags().StringVar(&logsBundle, "bundle", "", "Path of the zip bundle to write")
+	logsArchiveCmd.Flags().BoolVar(&logsClear, "clear", false, "Clear the channels after archiving them")
+
+	logsCmd.AddCommand(logsListCmd)
+	logsCmd.AddCommand(logsArchiveCmd)
+}
+
+func newEventLogManager() (*eventlog.Manager, error) {
+	dir := eventlog.DefaultDir()
+	if dir == "" {
+		return nil, fmt.Errorf("WINDIR is not set; cannot locate the event logs")
+	}
+	return eventlog.NewManager(eventlog.ExecRunner{}, dir), nil
+}
+
+func runLogsList() {
+	manager, err := newEventLogManager()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	channels, err := manager.List(logsMinSize * 1024 * 1024)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if getOutputFormat() == config.FormatJSON {
+		if channels == nil {
+			channels = []eventlog.Channel{}
+		}
+		data, err := json.MarshalIndent(channels, "", "  ")
+		if err != nil {
+			color.Red("Error encoding event logs: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(channels) == 0 {
+		color.Yellow("No event log channels found.")
+		return
+	}
+	printChannels(channels)
+}
+
+func printChannels(channels []eventlog.Channel) {
+	var total int64
+	for i, ch := range channels {
+		records := "?"
+		if ch.Records >= 0 {
+			records = fmt.Sprintf("%d", ch.Records)
+		}
+		fmt.Printf(" %3d. %10s %10s  %s\n", i+1, utils.FormatBytes(ch.Size), records, ch.Name)
+		total += ch.Size
+	}
+	fmt.Printf("\nChannels: %d | Size: %s\n", len(channels), color.CyanString(utils.FormatBytes(total)))
+}
+
+func runLogsArchive(names []string) {
+	if len(names) == 0 && !logsSelect {
+		color.Red("Error: name the channels to archive or use --select")
+		return
+	}
+	if logsClear && !dryRun {
+		if err := utils.RequireAdmin(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	}
+
+	manager, err := newEventLogManager()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if logsSelect {
+		channels, err := manager.List(logsMinSize * 1024 * 1024)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		if len(channels) == 0 {
+			color.Yellow("No event log channels found.")
+			return
+		}
+		printChannels(channels)
+
+		fmt.Print("\nChannels to archive (e.g. 1-3,7 or all): ")
+		input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		picked, err := parseSelection(input, len(channels))
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		for _, i := range picked {
+			names = append(names, channels[i].Name)
+		}
+		if len(names) == 0 {
+			color.Yellow("Nothing selected.")
+			return
+		}
+	}
+
+	bundle := logsBundle
+	if bundle == "" {
+		dir, err := logfile.Dir()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		bundle = filepath.Join(dir, "eventlogs-"+time.Now().Format("20060102-150405")+".zip")
+	}
+
+	if dryRun {
+		color.Yellow("DRY RUN MODE - No logs will be exported or cleared\n")
+		for _, name := range names {
+			fmt.Printf("  * %s\n", name)
+		}
+		color.White("\nBundle: %s", bundle)
+		return
+	}
+
+	if logsClear && !confirmAction(fmt.Sprintf("Archive and clear %d channels?", len(names))) {
+		color.Yellow("Cancelled.")
+		return
+	}
+
+	var result *eventlog.ArchiveResult
+	if logsClear {
+		result, err = manager.ArchiveAndClear(names, bundle)
+	} else {
+		result, err = manager.Archive(names, bundle)
+	}
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	color.Green("Archived %d channels to %s", len(result.Channels), result.Path)
+	fmt.Printf("Exported: %s | Bundle: %s\n", utils.FormatBytes(result.Size), utils.FormatBytes(result.Compressed))
+	if logsClear {
+		fmt.Printf("Cleared: %s\n", color.GreenString("%d", len(result.Cleared)))
+		for _, e := range result.Errors {
+			color.Red("  x %s", e)
+		}
+	}
+}
+
+// openLogger returns a logger that writes to stdout and to name in Burrow's
+// log folder, rotated according to the logs settings. The returned function
+// closes the log file. If the file cannot be opened only stdout is used.
+func openLogger(name string) (*log.Logger, func()) {
+	s := loadSettings().Logs
+
+	dir, err := logfile.Dir()
+	if err == nil {
+		var w *logfile.Writer
+		w, err = logfile.Open(filepath.Join(dir, name), s.MaxSize*1024*1024, s.Keep)
+		if err == nil {
+			return log.New(io.MultiWriter(os.Stdout, w), "", log.LstdFlags), func() { w.Close() }
+		}
+	}
+
+	color.Yellow("Warning: %v (logging to the console only)", err)
+	return log.New(os.Stdout, "", log.LstdFlags), func() {}
+}
@@ -45,6 +45,7 @@ func runOptimize() {
 	startTime := time.Now()
 
 	manager := optimize.NewOptimizeManager(debugMode, dryRun)
+	manager.SetDisabledTasks(loadSettings().Optimize.DisabledTasks)
 
 	color.White("Analyzing system...\n")
 
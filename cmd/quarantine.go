@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/quarantine"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var purgeOlderThan int
+
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "List, restore or purge quarantined items",
+	Long: `Items quarantined from the disk explorer are moved into the Burrow config
+directory instead of being deleted. They stay there until restored or purged.`,
+}
+
+var quarantineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List quarantined items",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runQuarantineList()
+	},
+}
+
+var quarantineRestoreCmd = &cobra.Command{
+	Use:   "restore <id>...",
+	Short: "Move items back to their original location",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runQuarantineRestore(args)
+	},
+}
+
+var quarantinePurgeCmd = &cobra.Command{
+	Use:   "purge [id]...",
+	Short: "Permanently delete quarantined items",
+	Long: `Permanently deletes the given items, or with --older-than N every item
+quarantined more than N days ago.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runQuarantinePurge(cmd, args)
+	},
+}
+
+func init() {
+	quarantinePurgeCmd.Flags().IntVar(&purgeOlderThan, "older-than", 0, "Purge items quarantined more than this many days ago")
+
+	quarantineCmd.AddCommand(quarantineListCmd)
+	quarantineCmd.AddCommand(quarantineRestoreCmd)
+	quarantineCmd.AddCommand(quarantinePurgeCmd)
+}
+
+func runQuarantineList() {
+	items, err := quarantine.List()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if getOutputFormat() == config.FormatJSON {
+		if items == nil {
+			items = []quarantine.Item{}
+		}
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			color.Red("Error encoding quarantine: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	if len(items) == 0 {
+		color.Green("Quarantine is empty.")
+		return
+	}
+
+	var total int64
+	for _, item := range items {
+		total += item.Size
+		fmt.Printf("  %s  %s  %10s  %s\n",
+			color.CyanString(item.ID),
+			item.QuarantinedAt.Local().Format("2006-01-02 15:04"),
+			utils.FormatBytes(item.Size),
+			item.OriginalPath,
+		)
+	}
+	fmt.Printf("\n%d items, %s\n", len(items), utils.FormatBytes(total))
+}
+
+func runQuarantineRestore(ids []string) {
+	for _, id := range ids {
+		item, err := quarantine.Restore(id)
+		if err != nil {
+			color.Red("x %s: %v", id, err)
+			continue
+		}
+		color.Green("* Restored %s", item.OriginalPath)
+	}
+}
+
+func runQuarantinePurge(cmd *cobra.Command, ids []string) {
+	if len(ids) == 0 && !cmd.Flags().Changed("older-than") {
+		color.Red("Error: give item ids or --older-than")
+		return
+	}
+
+	if cmd.Flags().Changed("older-than") {
+		cutoff := time.Now().AddDate(0, 0, -purgeOlderThan)
+		if dryRun {
+			items, err := quarantine.List()
+			if err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			for _, item := range items {
+				if item.QuarantinedAt.Before(cutoff) {
+					color.Yellow("Would purge %s (%s)", item.OriginalPath, utils.FormatBytes(item.Size))
+				}
+			}
+			return
+		}
+
+		purged, err := quarantine.PurgeOlderThan(cutoff)
+		for _, item := range purged {
+			color.Green("* Purged %s", item.OriginalPath)
+		}
+		if err != nil {
+			color.Red("Error: %v", err)
+		}
+		if len(purged) == 0 && err == nil {
+			color.White("Nothing to purge.")
+		}
+	}
+
+	for _, id := range ids {
+		if dryRun {
+			color.Yellow("Would purge %s", id)
+			continue
+		}
+		item, err := quarantine.Purge(id)
+		if err != nil {
+			color.Red("x %s: %v", id, err)
+			continue
+		}
+		color.Green("* Purged %s", item.OriginalPath)
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/cleanup"
+	"github.com/zs0c131y/burrow/internal/history"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var (
+	reportSelect    bool
+	reportMinSize   int64
+	reportPermanent bool
+)
+
+var rmFromReportCmd = &cobra.Command{
+	Use:   "rm-from-report <report>",
+	Short: "Remove files listed in an analyzer report",
+	Long: `Removes the files listed in a report written by the analyzer:
+  - the --json output of 'wm analyze' (its largest files)
+  - the --json output of 'wm analyze --stale' or 'wm analyze --type'
+  - a JSON snapshot from 'wm analyze --export file.json'
+  - a candidate list from 'wm analyze --stale --export-candidates'
+
+Every file is checked against the size and modification time recorded in
+the report just before it is removed, and skipped if it changed since the
+scan. Whitelisted files are skipped as well.
+
+Files are moved into quarantine, where 'wm quarantine restore' can bring
+them back; --trash sends them to the Recycle Bin and --permanent deletes
+them instead. --select picks files from the numbered list, e.g. "1-3,7".`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRmFromReport(args[0])
+	},
+}
+
+func init() {
+	rmFromReportCmd.Flags().BoolVar(&reportSelect, "select", false, "Choose which of the listed files to remove")
+	rmFromReportCmd.Flags().Int64Var(&reportMinSize, "min-size", 0, "Only consider files of at least this many MB")
+	rmFromReportCmd.Flags().BoolVar(&reportPermanent, "permanent", false, "Delete the files instead of quarantining them")
+	rmFromReportCmd.Flags().BoolVar(&useTrash, "trash", false, "Send the files to the Recycle Bin instead of quarantining them")
+}
+
+func runRmFromReport(reportPath string) {
+	if reportPermanent && useTrash {
+		color.Red("Error: use either --permanent or --trash, not both")
+		return
+	}
+
+	list, err := cleanup.ReadReport(reportPath)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if reportMinSize > 0 {
+		kept := list.Items[:0]
+		for _, c := range list.Items {
+			if c.Size >= reportMinSize*1024*1024 {
+				kept = append(kept, c)
+			}
+		}
+		list.Items = kept
+	}
+
+	if len(list.Items) == 0 {
+		color.Green("The report lists no files to remove.")
+		return
+	}
+
+	if dryRun {
+		color.Yellow("DRY RUN MODE - No files will be removed\n")
+	}
+
+	color.White("Report: %s\n\n", color.CyanString(reportPath))
+	for i, c := range list.Items {
+		modified := "unknown"
+		if !c.ModTime.IsZero() {
+			modified = c.ModTime.Local().Format("2006-01-02")
+		}
+		fmt.Printf(" %3d. %10s  %s  %s\n", i+1, utils.FormatBytes(c.Size), modified, c.Path)
+	}
+	fmt.Println()
+
+	if reportSelect {
+		fmt.Print("Files to remove (e.g. 1-3,7 or all): ")
+		input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		picked, err := parseSelection(input, len(list.Items))
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		if len(picked) == 0 {
+			color.Yellow("Nothing selected.")
+			return
+		}
+		items := make([]cleanup.Candidate, 0, len(picked))
+		for _, i := range picked {
+			items = append(items, list.Items[i])
+		}
+		list.Items = items
+	}
+
+	action, disposal := "Quarantine", cleanup.DisposeQuarantine
+	switch {
+	case reportPermanent:
+		action, disposal = "Permanently delete", cleanup.DisposeDelete
+	case useTrash:
+		action, disposal = "Move to the Recycle Bin", cleanup.DisposeTrash
+	}
+	fmt.Printf("Files: %d | Size: %s\n\n",
+		len(list.Items),
+		color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(list.TotalSize())),
+	)
+
+	if !dryRun && !confirmAction(action+" these files?") {
+		color.Yellow("Removal cancelled.")
+		return
+	}
+
+	startTime := time.Now()
+
+	manager := cleanup.NewCleanupManager(debugMode, dryRun)
+	manager.SetRetries(loadSettings().Clean.Retries)
+	manager.SetDisposal(disposal)
+	summary := manager.CleanCandidates(list, "Report files")
+
+	if !dryRun {
+		entry := history.Entry{
+			Time:     startTime,
+			Command:  "rm-from-report",
+			Trigger:  history.TriggerManual,
+			Profile:  profileName,
+			Duration: time.Since(startTime),
+		}
+		fillHistoryEntry(&entry, summary)
+		if err := history.Append(entry); err != nil && debugMode {
+			color.Yellow("Warning: cannot record history: %v", err)
+		}
+	}
+
+	displayCleanupResults(summary, time.Since(startTime))
+	if summary.TotalProtected > 0 {
+		color.Yellow("Files that changed since the report was written, or are whitelisted, were left in place.")
+	}
+	if disposal == cleanup.DisposeQuarantine && summary.TotalFilesRemoved > 0 && !dryRun {
+		color.White("Use 'wm quarantine list' to review or restore them.")
+	}
+}
+
+// parseSelection turns a list of 1-based numbers and ranges such as
+// "1-3, 7" into 0-based indexes below n, in order and without repeats.
+// "all" selects everything.
+func parseSelection(input string, n int) ([]int, error) {
+	input = strings.TrimSpace(input)
+	if strings.EqualFold(input, "all") {
+		all := make([]int, n)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+
+	var picked []int
+	seen := make(map[int]bool)
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		from, to, isRange := strings.Cut(field, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", field)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil {
+				return nil, fmt.Errorf("invalid selection %q", field)
+			}
+		}
+		if first < 1 || last > n || first > last {
+			return nil, fmt.Errorf("selection %q is outside 1-%d", field, n)
+		}
+		for i := first - 1; i < last; i++ {
+			if !seen[i] {
+				seen[i] = true
+				picked = append(picked, i)
+			}
+		}
+	}
+	return picked, nil
+}
@@ -45,12 +45,23 @@ func Execute(version string) error {
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Enable debug mode with detailed logs")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Preview changes without making them")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Configuration profile to apply (see 'wm config')")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "", "Output format: text or json (default from config, else text)")
 
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(optimizeCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(whitelistCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(scheduleCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(quarantineCmd)
+	rootCmd.AddCommand(rmFromReportCmd)
+	rootCmd.AddCommand(logsCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(updateCmd)
 }
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/cleanup"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/internal/history"
+	"github.com/zs0c131y/burrow/internal/schedule"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var (
+	jobCron       string
+	jobCategories []string
+	jobJitter     time.Duration
+	jobCatchUp    bool
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage recurring cleanup jobs",
+	Long: `Define cleanup jobs that 'wm daemon' runs on a cron schedule.
+
+Schedules use the five-field cron format (minute hour day month weekday)
+or one of @hourly, @daily, @weekly, @monthly and @yearly. Examples:
+
+  wm schedule add temp-daily --cron "0 3 * * *" --categories temp
+  wm schedule add dev-weekly --cron "@weekly" --profile dev --jitter 30m --catch-up`,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List scheduled jobs",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleList()
+	},
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add or replace a job (runs with the --profile given here)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleAdd(args[0])
+	},
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a job",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleRemove(args[0])
+	},
+}
+
+var scheduleEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Enable a job",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleSetDisabled(args[0], false)
+	},
+}
+
+var scheduleDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Disable a job without removing it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleSetDisabled(args[0], true)
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "Run a job once now",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleRun(args[0])
+	},
+}
+
+func init() {
+	scheduleAddCmd.Flags().StringVar(&jobCron, "cron", "", "Cron expression, e.g. \"0 3 * * *\" or @daily (required)")
+	scheduleAddCmd.Flags().StringSliceVar(&jobCategories, "categories", []string{}, "Categories to clean (default from the profile's clean.categories)")
+	scheduleAddCmd.Flags().DurationVar(&jobJitter, "jitter", 0, "Random delay of up to this duration before each run, e.g. 15m")
+	scheduleAddCmd.Flags().BoolVar(&jobCatchUp, "catch-up", false, "Run once at daemon start if a run was missed")
+	_ = scheduleAddCmd.MarkFlagRequired("cron")
+
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleAddCmd)
+	scheduleCmd.AddCommand(scheduleRemoveCmd)
+	scheduleCmd.AddCommand(scheduleEnableCmd)
+	scheduleCmd.AddCommand(scheduleDisableCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+}
+
+func runScheduleList() {
+	jobs, err := schedule.LoadJobs()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	if len(jobs) == 0 {
+		color.Yellow("No scheduled jobs. Add one with 'wm schedule add'.")
+		return
+	}
+
+	state, err := schedule.LoadState()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, job := range jobs {
+		status := color.GreenString("enabled")
+		if job.Disabled {
+			status = color.YellowString("disabled")
+		}
+
+		fmt.Printf("%s  %-20s %s\n", status, color.CyanString(job.Name), job.Cron)
+
+		cats := "profile default"
+		if len(job.Categories) > 0 {
+			cats = strings.Join(job.Categories, ",")
+		}
+		fmt.Printf("    categories: %s", cats)
+		if job.Profile != "" {
+			fmt.Printf("  profile: %s", job.Profile)
+		}
+		if job.Jitter > 0 {
+			fmt.Printf("  jitter: %s", job.Jitter)
+		}
+		if job.CatchUp {
+			fmt.Print("  catch-up")
+		}
+		fmt.Println()
+
+		if last, ok := state.LastRun[job.Name]; ok {
+			fmt.Printf("    last run: %s\n", last.Local().Format("2006-01-02 15:04"))
+		}
+		if c, err := schedule.ParseCron(job.Cron); err == nil && !job.Disabled {
+			if next := c.Next(now); !next.IsZero() {
+				fmt.Printf("    next run: %s\n", next.Format("2006-01-02 15:04"))
+			}
+		}
+	}
+}
+
+func runScheduleAdd(name string) {
+	for _, c := range jobCategories {
+		if !cleanup.IsKnownCategory(c) {
+			color.Red("Error: unknown category %q", c)
+			return
+		}
+	}
+	if profileName != "" {
+		if _, err := config.Load(profileName); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	}
+
+	job := schedule.Job{
+		Name:       name,
+		Cron:       jobCron,
+		Categories: jobCategories,
+		Profile:    profileName,
+		Jitter:     jobJitter,
+		CatchUp:    jobCatchUp,
+		Created:    time.Now(),
+	}
+	if err := job.Validate(); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	jobs, err := schedule.LoadJobs()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	verb := "Added"
+	if i := schedule.FindJob(jobs, name); i >= 0 {
+		jobs[i] = job
+		verb = "Updated"
+	} else {
+		jobs = append(jobs, job)
+	}
+
+	if err := schedule.SaveJobs(jobs); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	color.Green("%s job %s (%s)", verb, name, job.Cron)
+}
+
+func runScheduleRemove(name string) {
+	jobs, err := schedule.LoadJobs()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	i := schedule.FindJob(jobs, name)
+	if i < 0 {
+		color.Red("Error: no job named %q", name)
+		return
+	}
+	jobs = append(jobs[:i], jobs[i+1:]...)
+
+	if err := schedule.SaveJobs(jobs); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	color.Green("Removed job %s", name)
+}
+
+func runScheduleSetDisabled(name string, disabled bool) {
+	jobs, err := schedule.LoadJobs()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	i := schedule.FindJob(jobs, name)
+	if i < 0 {
+		color.Red("Error: no job named %q", name)
+		return
+	}
+	jobs[i].Disabled = disabled
+
+	if err := schedule.SaveJobs(jobs); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	if disabled {
+		color.Yellow("Disabled job %s", name)
+	} else {
+		color.Green("Enabled job %s", name)
+	}
+}
+
+func runScheduleRun(name string) {
+	jobs, err := schedule.LoadJobs()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	i := schedule.FindJob(jobs, name)
+	if i < 0 {
+		color.Red("Error: no job named %q", name)
+		return
+	}
+	job := jobs[i]
+
+	if !dryRun {
+		if err := utils.RequireAdmin(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	}
+
+	lockPath, err := schedule.DefaultLockPath()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	lock, err := schedule.AcquireLock(lockPath)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	defer lock.Release()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	color.White("Running job %s...\n", job.Name)
+	entry := runCleanupJob(ctx, job.Categories, job.Profile, "")
+	entry.Job = job.Name
+	entry.Trigger = history.TriggerImmediate
+
+	if err := history.Append(entry); err != nil {
+		color.Yellow("Warning: cannot record history: %v", err)
+	}
+
+	if !entry.Success {
+		color.Red("Job %s failed: %s", job.Name, entry.Error)
+		return
+	}
+	color.Green("Job %s finished: freed %s, %d files removed",
+		job.Name, utils.FormatBytes(entry.SpaceFreed), entry.FilesRemoved)
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/zs0c131y/burrow/internal/analyzer"
+	"github.com/zs0c131y/burrow/internal/cleanup"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// staleHistogramRows is how many top-level folders get their own histogram row.
+const staleHistogramRows = 10
+
+// runStaleReport scans absPath in full and reports files older than
+// staleDays, grouped by folder, with an age histogram per top-level folder.
+func runStaleReport(absPath string) {
+	jsonOut := getOutputFormat() == config.FormatJSON
+	if !jsonOut {
+		printAnalyzerBanner()
+		color.White("Scanning %s for files not modified in %d days...\n\n", color.CyanString(absPath), staleDays)
+	}
+
+	a, tree, err := fullScan(absPath)
+	if err != nil {
+		color.Red("Error analyzing path: %v", err)
+		return
+	}
+
+	now := time.Now()
+	stale := a.GetOldestFiles(tree, staleDays)
+	dirs := analyzer.GroupByDir(stale)
+
+	var staleSize int64
+	for _, f := range stale {
+		staleSize += f.Size
+	}
+
+	if exportStale != "" {
+		list := &cleanup.CandidateList{
+			Source:    fmt.Sprintf("wm analyze %s --stale %d", absPath, staleDays),
+			Generated: now,
+		}
+		for _, f := range stale {
+			list.Items = append(list.Items, cleanup.Candidate{Path: f.Path, Size: f.Size, ModTime: f.ModTime})
+		}
+		if err := cleanup.WriteCandidates(exportStale, list); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	}
+
+	if jsonOut {
+		printStaleJSON(tree, dirs, staleSize, now)
+		return
+	}
+
+	displayAgeHistogram(tree, now)
+
+	color.White("\nFiles not modified in %d days:\n", staleDays)
+	color.White("════════════════════════════════════════════════════════\n")
+	if len(dirs) == 0 {
+		color.Green("No stale files found.")
+	}
+	for i, d := range dirs {
+		if i == 20 {
+			color.White("  ... and %d more folders", len(dirs)-i)
+			break
+		}
+		fmt.Printf("  %10s  %5d files  oldest %s  %s\n",
+			color.YellowString(utils.FormatBytes(d.Size)),
+			len(d.Files),
+			d.Oldest.Local().Format("2006-01-02"),
+			utils.TruncateString(d.Path, 50),
+		)
+	}
+	color.White("════════════════════════════════════════════════════════\n")
+	fmt.Printf("Stale: %s in %d files\n",
+		color.New(color.FgYellow, color.Bold).Sprint(utils.FormatBytes(staleSize)), len(stale))
+
+	if exportStale != "" {
+		color.Green("\nCandidate list written to %s", exportStale)
+		color.White("Review it, then run: wm clean --candidates %s", exportStale)
+	}
+}
+
+// displayAgeHistogram prints bytes by age for the largest top-level folders
+// of tree and for the tree as a whole.
+func displayAgeHistogram(tree *analyzer.DiskNode, now time.Time) {
+	color.White("Age of data (by last modification):\n")
+	color.White("════════════════════════════════════════════════════════\n")
+
+	fmt.Printf("  %-24s", "")
+	for _, label := range analyzer.AgeBuckets {
+		fmt.Printf(" %10s", label)
+	}
+	fmt.Println()
+
+	row := func(name string, h analyzer.AgeHistogram) {
+		fmt.Printf("  %-24s", utils.TruncateString(name, 24))
+		for i, b := range h {
+			cell := fmt.Sprintf("%10s", utils.FormatBytes(b))
+			if i == len(h)-1 && b > 0 {
+				cell = color.YellowString(cell)
+			}
+			fmt.Printf(" %s", cell)
+		}
+		fmt.Println()
+	}
+
+	shown := 0
+	for _, child := range tree.Children {
+		if !child.IsDirectory {
+			continue
+		}
+		if shown == staleHistogramRows {
+			break
+		}
+		row(child.Name, analyzer.Histogram(child, now))
+		shown++
+	}
+	row("Total", analyzer.Histogram(tree, now))
+	color.White("════════════════════════════════════════════════════════\n")
+}
+
+func printStaleJSON(tree *analyzer.DiskNode, dirs []analyzer.StaleDir, staleSize int64, now time.Time) {
+	type histogramRow struct {
+		Path    string           `json:"path"`
+		Buckets map[string]int64 `json:"buckets"`
+	}
+	type staleDir struct {
+		Path   string          `json:"path"`
+		Size   int64           `json:"size"`
+		Oldest time.Time       `json:"oldest"`
+		Files  []analysisEntry `json:"files"`
+	}
+
+	newRow := func(n *analyzer.DiskNode) histogramRow {
+		h := analyzer.Histogram(n, now)
+		r := histogramRow{Path: n.Path, Buckets: make(map[string]int64, len(h))}
+		for i, b := range h {
+			r.Buckets[analyzer.AgeBuckets[i]] = b
+		}
+		return r
+	}
+
+	report := struct {
+		Path        string         `json:"path"`
+		StaleDays   int            `json:"stale_days"`
+		StaleSize   int64          `json:"stale_size"`
+		Histogram   histogramRow   `json:"histogram"`
+		Subtrees    []histogramRow `json:"subtrees"`
+		Directories []staleDir     `json:"directories"`
+	}{
+		Path:        tree.Path,
+		StaleDays:   staleDays,
+		StaleSize:   staleSize,
+		Histogram:   newRow(tree),
+		Subtrees:    []histogramRow{},
+		Directories: []staleDir{},
+	}
+
+	for _, child := range tree.Children {
+		if child.IsDirectory {
+			report.Subtrees = append(report.Subtrees, newRow(child))
+		}
+	}
+	for _, d := range dirs {
+		sd := staleDir{Path: d.Path, Size: d.Size, Oldest: d.Oldest}
+		for _, f := range d.Files {
+			sd.Files = append(sd.Files, newAnalysisEntry(f, false))
+		}
+		report.Directories = append(report.Directories, sd)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		color.Red("Error encoding stale report: %v", err)
+		return
+	}
+	fmt.Println(string(data))
+}
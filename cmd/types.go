@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/zs0c131y/burrow/internal/analyzer"
+	"github.com/zs0c131y/burrow/internal/config"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// typeReportExtensions is how many extensions are listed in the breakdown.
+const typeReportExtensions = 15
+
+// runTypeReport scans absPath in full and shows space by file type, or lists
+// the files of fileType when it is set.
+func runTypeReport(absPath string) {
+	kind := strings.ToLower(fileType)
+	if kind != "" && !strings.HasPrefix(kind, ".") && !analyzer.IsTypeGroup(kind) {
+		color.Red("Unknown type %q: use one of %s, other, or an extension such as .iso",
+			fileType, strings.Join(analyzer.TypeGroups, ", "))
+		return
+	}
+
+	jsonOut := getOutputFormat() == config.FormatJSON
+	if !jsonOut {
+		printAnalyzerBanner()
+		color.White("Analyzing: %s\n", color.CyanString(absPath))
+		color.White("Please wait, scanning every file...\n\n")
+	}
+
+	_, tree, err := fullScan(absPath)
+	if err != nil {
+		color.Red("Error analyzing path: %v", err)
+		return
+	}
+
+	if kind != "" {
+		files := analyzer.FilesOfType(tree, kind, minSize*1024*1024)
+		if jsonOut {
+			entries := make([]analysisEntry, 0, len(files))
+			for _, f := range files {
+				entries = append(entries, newAnalysisEntry(f, false))
+			}
+			printTypeJSON(entries)
+			return
+		}
+		displayFilesOfType(files, kind)
+		return
+	}
+
+	byExt, byGroup := analyzer.Breakdown(tree)
+	if jsonOut {
+		type typeEntry struct {
+			Name  string `json:"name"`
+			Size  int64  `json:"size"`
+			Count int    `json:"count"`
+		}
+		convert := func(stats []analyzer.TypeStats) []typeEntry {
+			out := make([]typeEntry, 0, len(stats))
+			for _, s := range stats {
+				out = append(out, typeEntry(s))
+			}
+			return out
+		}
+		printTypeJSON(struct {
+			Path       string      `json:"path"`
+			Groups     []typeEntry `json:"groups"`
+			Extensions []typeEntry `json:"extensions"`
+		}{tree.Path, convert(byGroup), convert(byExt)})
+		return
+	}
+
+	displayTypeStats("Space by type", byGroup, tree.Size, len(byGroup))
+	fmt.Println()
+	displayTypeStats("Top extensions", byExt, tree.Size, typeReportExtensions)
+}
+
+func printTypeJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		color.Red("Error encoding type report: %v", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+func displayTypeStats(title string, stats []analyzer.TypeStats, total int64, limit int) {
+	color.White("%s:\n", title)
+	color.White("════════════════════════════════════════════════════════\n")
+
+	for i, s := range stats {
+		if i == limit {
+			break
+		}
+		percentage := 0.0
+		if total > 0 {
+			percentage = float64(s.Size) / float64(total) * 100
+		}
+		name := s.Name
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Printf("  %-12s %10s %6.1f%% %s %8d files\n",
+			utils.TruncateString(name, 12),
+			color.CyanString(utils.FormatBytes(s.Size)),
+			percentage,
+			createUsageBar(percentage, 20),
+			s.Count,
+		)
+	}
+
+	color.White("════════════════════════════════════════════════════════\n")
+}
+
+func displayFilesOfType(files []*analyzer.DiskNode, kind string) {
+	if len(files) == 0 {
+		color.Green("No %s files found.", kind)
+		return
+	}
+
+	var total int64
+	color.White("Files of type %s:\n", color.CyanString(kind))
+	color.White("════════════════════════════════════════════════════════\n")
+	for _, f := range files {
+		total += f.Size
+		fmt.Printf("  %10s  %s  %s\n",
+			color.YellowString(utils.FormatBytes(f.Size)),
+			f.ModTime.Local().Format("2006-01-02"),
+			f.Path,
+		)
+	}
+	color.White("════════════════════════════════════════════════════════\n")
+	fmt.Printf("%d files, %s\n", len(files), color.New(color.FgGreen, color.Bold).Sprint(utils.FormatBytes(total)))
+}
@@ -26,6 +26,10 @@ var uninstallCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	uninstallCmd.Flags().BoolVar(&useTrash, "trash", false, "Send leftover files to the Recycle Bin instead of deleting them")
+}
+
 func runUninstall() {
 	if err := utils.RequireAdmin(); err != nil {
 		color.Red("Error: %v", err)
@@ -39,6 +43,7 @@ func runUninstall() {
 	color.White("Discovering installed applications...\n")
 
 	manager := uninstall.NewUninstallManager(debugMode, dryRun)
+	manager.SetTrash(useTrash)
 
 	apps, err := manager.DiscoverApplications()
 	if err != nil {
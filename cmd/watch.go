@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/history"
+	"github.com/zs0c131y/burrow/internal/schedule"
+	"github.com/zs0c131y/burrow/internal/watch"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+var (
+	watchMinFreePercent int
+	watchMinFreeGB      int
+	watchCooldown       time.Duration
+	watchInterval       time.Duration
+	watchMounts         []string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Clean up automatically when a drive runs low on space",
+	Long: `Polls free space on each drive and runs a cleanup when a drive drops
+below the configured threshold.
+
+A drive is low when its free space is below --min-free-percent of its
+capacity or below --min-free-gb, whichever is larger. After a cleanup the
+drive must recover past the threshold plus watch.hysteresis_percent before
+it can trigger again, and never sooner than --cooldown after the last run.
+
+The cleanup only cleans targets on the drive that ran low. It uses the
+--profile profile, or the one named by watch.profile in config.toml when no
+profile is given. If another cleanup is already running, the run is skipped
+and recorded as failed. Defaults for every flag come from
+the [watch] section. Activity is logged to watch.log in the logs folder of
+the config directory.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		applyWatchSettings(cmd)
+		runWatch()
+	},
+}
+
+func init() {
+	watchCmd.Flags().IntVar(&watchMinFreePercent, "min-free-percent", 0, "Trigger when free space falls below this percentage")
+	watchCmd.Flags().IntVar(&watchMinFreeGB, "min-free-gb", 0, "Trigger when free space falls below this many GB")
+	watchCmd.Flags().DurationVar(&watchCooldown, "cooldown", 0, "Minimum time between cleanups of the same drive, e.g. 2h")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 0, "How often to check free space, e.g. 30s")
+	watchCmd.Flags().StringSliceVar(&watchMounts, "mounts", []string{}, "Drives to watch, e.g. C:,D: (default all)")
+}
+
+// applyWatchSettings fills watch flags the user did not set from the config.
+func applyWatchSettings(cmd *cobra.Command) {
+	s := loadSettings().Watch
+	flags := cmd.Flags()
+	if !flags.Changed("min-free-percent") {
+		watchMinFreePercent = s.MinFreePercent
+	}
+	if !flags.Changed("min-free-gb") {
+		watchMinFreeGB = s.MinFreeGB
+	}
+	if !flags.Changed("cooldown") {
+		watchCooldown = time.Duration(s.Cooldown) * time.Minute
+	}
+	if !flags.Changed("interval") {
+		watchInterval = time.Duration(s.Interval) * time.Second
+	}
+	if !flags.Changed("mounts") {
+		watchMounts = s.Mounts
+	}
+}
+
+func runWatch() {
+	policy := watch.Policy{
+		MinFreePercent: float64(watchMinFreePercent),
+		MinFreeBytes:   uint64(watchMinFreeGB) << 30,
+		Hysteresis:     float64(loadSettings().Watch.HysteresisPercent),
+		Cooldown:       watchCooldown,
+	}
+	if !policy.Enabled() {
+		color.Red("Error: no threshold set; use --min-free-percent or --min-free-gb")
+		return
+	}
+	if watchInterval < 5*time.Second {
+		color.Red("Error: --interval must be at least 5s")
+		return
+	}
+
+	if !dryRun {
+		if err := utils.RequireAdmin(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	}
+
+	cleanProfile := watch.CleanProfile(profileName, loadSettings().Watch.Profile)
+
+	dir, err := utils.GetConfigDir()
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	lock, err := schedule.AcquireLock(filepath.Join(dir, "watch.lock"))
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	defer lock.Release()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger, closeLog := openLogger("watch.log")
+	defer closeLog()
+
+	monitor := watch.NewMonitor(policy, watchMounts)
+	monitor.Logf = logger.Printf
+
+	logger.Printf("watching free space every %s (threshold %d%% / %d GB, cooldown %s)",
+		watchInterval, watchMinFreePercent, watchMinFreeGB, watchCooldown)
+	if dryRun {
+		logger.Printf("dry run: no files will be deleted")
+	}
+
+	monitor.Run(ctx, watchInterval, func(ctx context.Context, v watch.Volume) {
+		logger.Printf("%s is low on space: %s free (%.1f%%), threshold %s; running cleanup",
+			v.Mount, utils.FormatBytes(int64(v.Free)), v.FreePercent(), utils.FormatBytes(int64(policy.Threshold(v))))
+
+		entry := runCleanupJob(ctx, nil, cleanProfile, v.Mount)
+		entry.Trigger = history.TriggerPressure
+		entry.Job = v.Mount
+
+		if err := history.Append(entry); err != nil {
+			logger.Printf("cannot record history: %v", err)
+		}
+		if entry.Success {
+			logger.Printf("cleanup for %s freed %s", v.Mount, utils.FormatBytes(entry.SpaceFreed))
+		} else {
+			logger.Printf("cleanup for %s failed: %s", v.Mount, entry.Error)
+		}
+	})
+
+	logger.Printf("watch stopped")
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/zs0c131y/burrow/internal/cleanup"
+)
+
+var (
+	whitelistComment string
+	whitelistExpires string
+	whitelistForce   bool
+	whitelistReplace bool
+)
+
+var whitelistCmd = &cobra.Command{
+	Use:   "whitelist",
+	Short: "Manage paths and patterns protected from cleanup",
+	Long: `Manage the cleanup whitelist without the interactive menu.
+
+Entries may be folders, files or glob patterns such as
+C:\Users\Me\AppData\Local\Temp\keep* or **\*.pst. A protected
+entry shields everything beneath it from cleanup.`,
+}
+
+var whitelistListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List whitelist entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runWhitelistList()
+	},
+}
+
+var whitelistAddCmd = &cobra.Command{
+	Use:   "add <path|pattern>...",
+	Short: "Protect paths or glob patterns",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWhitelistAdd(args)
+	},
+}
+
+var whitelistRemoveCmd = &cobra.Command{
+	Use:     "remove <path|pattern>...",
+	Aliases: []string{"rm"},
+	Short:   "Remove whitelist entries",
+	Args:    cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWhitelistRemove(args)
+	},
+}
+
+var whitelistImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Merge entries from a whitelist file",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runWhitelistImport(args[0])
+	},
+}
+
+var whitelistExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write the whitelist to a file or stdout",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dest := ""
+		if len(args) > 0 {
+			dest = args[0]
+		}
+		runWhitelistExport(dest)
+	},
+}
+
+func init() {
+	whitelistAddCmd.Flags().StringVar(&whitelistComment, "comment", "", "Note describing why the entry is protected")
+	whitelistAddCmd.Flags().StringVar(&whitelistExpires, "expires", "", "Last day (YYYY-MM-DD) the entry is active")
+	whitelistAddCmd.Flags().BoolVar(&whitelistForce, "force", false, "Add paths even if they do not exist")
+	whitelistImportCmd.Flags().BoolVar(&whitelistReplace, "replace", false, "Replace the whitelist instead of merging")
+	whitelistImportCmd.Flags().BoolVar(&whitelistForce, "force", false, "Import paths even if they do not exist")
+
+	whitelistCmd.AddCommand(whitelistListCmd)
+	whitelistCmd.AddCommand(whitelistAddCmd)
+	whitelistCmd.AddCommand(whitelistRemoveCmd)
+	whitelistCmd.AddCommand(whitelistImportCmd)
+	whitelistCmd.AddCommand(whitelistExportCmd)
+}
+
+func runWhitelistList() {
+	wl, err := cleanup.LoadWhitelist()
+	if err != nil {
+		color.Red("Error loading whitelist: %v", err)
+		return
+	}
+
+	if len(wl.Entries) == 0 {
+		color.Yellow("No paths are currently protected.")
+		return
+	}
+
+	now := time.Now()
+	for i, e := range wl.Entries {
+		line := fmt.Sprintf("  %2d. %s", i+1, e.Pattern)
+		if e.Expires != nil {
+			line += fmt.Sprintf("  (expires %s)", e.Expires.Format("2006-01-02"))
+		}
+		if e.Comment != "" {
+			line += "  # " + e.Comment
+		}
+
+		if e.Expired(now) {
+			color.Yellow("%s  [expired]", line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+}
+
+func runWhitelistAdd(patterns []string) {
+	var expires *time.Time
+	if whitelistExpires != "" {
+		t, err := cleanup.ParseWhitelistExpiry(whitelistExpires)
+		if err != nil {
+			color.Red("Invalid expiry date %q: use YYYY-MM-DD", whitelistExpires)
+			return
+		}
+		expires = &t
+	}
+
+	wl, err := cleanup.LoadWhitelist()
+	if err != nil {
+		color.Red("Error loading whitelist: %v", err)
+		return
+	}
+
+	now := time.Now()
+	changed := false
+	for _, p := range patterns {
+		p, ok := checkWhitelistPattern(p)
+		if !ok {
+			continue
+		}
+
+		entry := cleanup.WhitelistEntry{
+			Pattern: p,
+			Comment: whitelistComment,
+			Added:   &now,
+			Expires: expires,
+		}
+		if wl.Add(entry) {
+			color.Green("  * Added: %s", p)
+		} else {
+			color.Cyan("  * Updated: %s", p)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := wl.Save(); err != nil {
+		color.Red("Error saving whitelist: %v", err)
+	}
+}
+
+// checkWhitelistPattern makes p absolute and validates it. A pattern that
+// fails validation is reported and rejected unless --force is given.
+func checkWhitelistPattern(p string) (string, bool) {
+	p = cleanup.AbsWhitelistPattern(p)
+	if err := cleanup.ValidateWhitelistPattern(p); err != nil && !whitelistForce {
+		color.Red("  x %v", err)
+		return p, false
+	}
+	return p, true
+}
+
+func runWhitelistRemove(patterns []string) {
+	wl, err := cleanup.LoadWhitelist()
+	if err != nil {
+		color.Red("Error loading whitelist: %v", err)
+		return
+	}
+
+	changed := false
+	for _, p := range patterns {
+		if wl.Remove(p) || wl.Remove(cleanup.AbsWhitelistPattern(p)) {
+			color.Green("  * Removed: %s", p)
+			changed = true
+		} else {
+			color.Yellow("  ! Not in whitelist: %s", p)
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	if err := wl.Save(); err != nil {
+		color.Red("Error saving whitelist: %v", err)
+	}
+}
+
+func runWhitelistImport(src string) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		color.Red("Cannot read %s: %v", src, err)
+		return
+	}
+
+	imported, err := cleanup.ParseWhitelist(data)
+	if err != nil {
+		color.Red("Cannot import %s: %v", src, err)
+		return
+	}
+
+	wl := &cleanup.Whitelist{}
+	if !whitelistReplace {
+		wl, err = cleanup.LoadWhitelist()
+		if err != nil {
+			color.Red("Error loading whitelist: %v", err)
+			return
+		}
+	}
+
+	added, accepted := 0, 0
+	for _, e := range imported.Entries {
+		var ok bool
+		if e.Pattern, ok = checkWhitelistPattern(e.Pattern); !ok {
+			continue
+		}
+		accepted++
+		if wl.Add(e) {
+			added++
+		}
+	}
+
+	if err := wl.Save(); err != nil {
+		color.Red("Error saving whitelist: %v", err)
+		return
+	}
+
+	color.Green("Imported %d entries (%d new) from %s", accepted, added, src)
+	if skipped := len(imported.Entries) - accepted; skipped > 0 {
+		color.Yellow("Skipped %d entries; use --force to import paths that do not exist", skipped)
+	}
+}
+
+func runWhitelistExport(dest string) {
+	wl, err := cleanup.LoadWhitelist()
+	if err != nil {
+		color.Red("Error loading whitelist: %v", err)
+		return
+	}
+
+	data, err := wl.Marshal()
+	if err != nil {
+		color.Red("Error exporting whitelist: %v", err)
+		return
+	}
+
+	if dest == "" {
+		fmt.Println(string(data))
+		return
+	}
+
+	if err := os.WriteFile(dest, data, 0o644); err != nil {
+		color.Red("Cannot write %s: %v", dest, err)
+		return
+	}
+	color.Green("Exported %d entries to %s", len(wl.Entries), dest)
+}
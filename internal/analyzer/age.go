@@ -0,0 +1,97 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// AgeBuckets names the buckets of an AgeHistogram, youngest first.
+var AgeBuckets = []string{"< 30d", "30d-180d", "180d-1y", "> 1y"}
+
+// AgeHistogram holds the bytes of files in each of AgeBuckets.
+type AgeHistogram [4]int64
+
+// Total returns the bytes counted across all buckets.
+func (h AgeHistogram) Total() int64 {
+	var total int64
+	for _, b := range h {
+		total += b
+	}
+	return total
+}
+
+// ageBucket returns the AgeBuckets index for a file last modified at modTime.
+func ageBucket(modTime, now time.Time) int {
+	age := now.Sub(modTime)
+	switch {
+	case age < 30*24*time.Hour:
+		return 0
+	case age < 180*24*time.Hour:
+		return 1
+	case age < 365*24*time.Hour:
+		return 2
+	}
+	return 3
+}
+
+// Histogram buckets the bytes of every file under node by modification time.
+// Directories summarized beyond the analyzer depth carry no per-file times
+// and are counted by the directory's own modification time.
+func Histogram(node *DiskNode, now time.Time) AgeHistogram {
+	var h AgeHistogram
+
+	var walk func(n *DiskNode)
+	walk = func(n *DiskNode) {
+		if n.IsDirectory && len(n.Children) > 0 {
+			for _, child := range n.Children {
+				walk(child)
+			}
+			return
+		}
+		if n.IsDirectory && n.ItemCount == 0 {
+			return
+		}
+		h[ageBucket(n.ModTime, now)] += n.Size
+	}
+
+	walk(node)
+	return h
+}
+
+// StaleDir groups the stale files found directly in one directory.
+type StaleDir struct {
+	Path   string
+	Size   int64
+	Oldest time.Time
+	Files  []*DiskNode
+}
+
+// GroupByDir groups files by their parent directory, largest group first.
+func GroupByDir(files []*DiskNode) []StaleDir {
+	index := make(map[string]int)
+	var dirs []StaleDir
+
+	for _, f := range files {
+		dir := filepath.Dir(f.Path)
+		i, ok := index[dir]
+		if !ok {
+			i = len(dirs)
+			index[dir] = i
+			dirs = append(dirs, StaleDir{Path: dir, Oldest: f.ModTime})
+		}
+
+		d := &dirs[i]
+		d.Size += f.Size
+		d.Files = append(d.Files, f)
+		if f.ModTime.Before(d.Oldest) {
+			d.Oldest = f.ModTime
+		}
+	}
+
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return dirs[i].Size > dirs[j].Size
+	})
+
+	return dirs
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAgeBucket(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		age  time.Duration
+		want int
+	}{
+		{0, 0},
+		{29 * day, 0},
+		{30 * day, 1},
+		{179 * day, 1},
+		{180 * day, 2},
+		{364 * day, 2},
+		{365 * day, 3},
+		{2000 * day, 3},
+	}
+
+	for _, tt := range tests {
+		if got := ageBucket(now.Add(-tt.age), now); got != tt.want {
+			t.Errorf("ageBucket(%v old) = %d, want %d", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestHistogramAndGroupByDir(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	oldA := &DiskNode{Name: "a.log", Path: "/r/logs/a.log", Size: 100, ItemCount: 1, ModTime: ago(400)}
+	oldB := &DiskNode{Name: "b.log", Path: "/r/logs/b.log", Size: 50, ItemCount: 1, ModTime: ago(200)}
+	oldC := &DiskNode{Name: "c.bin", Path: "/r/c.bin", Size: 500, ItemCount: 1, ModTime: ago(90)}
+	fresh := &DiskNode{Name: "d.txt", Path: "/r/d.txt", Size: 7, ItemCount: 1, ModTime: ago(1)}
+	summarized := &DiskNode{Name: "deep", Path: "/r/deep", Size: 30, ItemCount: 3, IsDirectory: true, ModTime: ago(40)}
+	empty := &DiskNode{Name: "empty", Path: "/r/empty", IsDirectory: true, ModTime: ago(900)}
+
+	logs := &DiskNode{Name: "logs", Path: "/r/logs", IsDirectory: true, Children: []*DiskNode{oldA, oldB}}
+	root := &DiskNode{Name: "r", Path: "/r", IsDirectory: true,
+		Children: []*DiskNode{oldC, logs, fresh, summarized, empty}}
+
+	h := Histogram(root, now)
+	want := AgeHistogram{7, 530, 50, 100}
+	if h != want {
+		t.Errorf("Histogram = %v, want %v", h, want)
+	}
+	if h.Total() != 687 {
+		t.Errorf("Total = %d, want 687", h.Total())
+	}
+
+	dirs := GroupByDir([]*DiskNode{oldA, oldC, oldB})
+	if len(dirs) != 2 {
+		t.Fatalf("GroupByDir returned %d groups, want 2", len(dirs))
+	}
+	if dirs[0].Path != filepath.FromSlash("/r") || dirs[0].Size != 500 {
+		t.Errorf("first group = %s (%d), want /r (500)", dirs[0].Path, dirs[0].Size)
+	}
+	if dirs[1].Size != 150 || len(dirs[1].Files) != 2 || !dirs[1].Oldest.Equal(ago(400)) {
+		t.Errorf("logs group = %+v", dirs[1])
+	}
+}
@@ -1,24 +1,58 @@
 package analyzer
 
 import (
-	"crypto/sha256"
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
-	"strings"
+	"sync"
 	"time"
 
 	"github.com/zs0c131y/burrow/pkg/utils"
 )
 
+// getAttributes reads the attributes of a directory entry; tests replace it
+// to simulate Windows attributes.
+var getAttributes = utils.GetAttributes
+
+// statDir and readDir stat and list the directories a scan visits; tests
+// replace them to count the I/O a scan does.
+var (
+	statDir = os.Stat
+	readDir = os.ReadDir
+)
+
 // Analyzer performs disk space analysis.
 type Analyzer struct {
 	debug      bool
 	showHidden bool
 	maxDepth   int
 	minSize    int64
+
+	followLinks bool
+	links       *utils.LinkTracker
+
+	// sizeMode selects the size that drives sorting and the minimum size
+	// filter; hardlinks records files with several links so each is
+	// counted once.
+	sizeMode  string
+	hardlinks *utils.LinkTracker
+
+	// exclude drops matching entries from the scan; with oneFileSystem,
+	// directories on another filesystem than the root (device) are dropped
+	// as well.
+	exclude       *utils.ExcludeList
+	oneFileSystem bool
+	device        uint64
+
+	cache *ScanCache
+
+	// sem holds one token per extra goroutine the walker may run; the
+	// calling goroutine is the first worker.
+	sem     chan struct{}
+	workers int
 }
 
 // DiskNode represents a file or directory in the analysis tree.
@@ -31,6 +65,25 @@ type DiskNode struct {
 	Children    []*DiskNode
 	LargeFiles  int
 	ModTime     time.Time
+	IsLink      bool
+	LinkTarget  string
+
+	// Allocated is the space the node takes on disk, as opposed to the
+	// apparent Size. HardLink marks a file whose data is already counted
+	// through another of its hard links; its sizes are zero.
+	Allocated int64
+	HardLink  bool
+
+	// Attrs holds the entry's file attributes. Offline files are cloud
+	// placeholders whose data is not stored locally; they count as items
+	// but have zero sizes.
+	Attrs utils.Attributes
+
+	// Delta is the change in Size since the previous cached scan and IsNew
+	// marks nodes that did not exist then. Both are only meaningful when
+	// ScanCache.HasDeltas reports true.
+	Delta int64
+	IsNew bool
 }
 
 // NewAnalyzer creates a new Analyzer.
@@ -38,37 +91,189 @@ func NewAnalyzer(debug, showHidden bool, maxDepth int, minSize int64) *Analyzer
 	if maxDepth < 1 {
 		maxDepth = 1
 	}
-	return &Analyzer{
+	a := &Analyzer{
 		debug:      debug,
 		showHidden: showHidden,
 		maxDepth:   maxDepth,
 		minSize:    minSize,
+		sizeMode:   SizeApparent,
+	}
+	a.SetWorkers(0)
+	return a
+}
+
+// DefaultWorkers returns the number of directories scanned concurrently when
+// no explicit worker count is set. Directory reads are I/O bound, so this is
+// a multiple of the CPU count.
+func DefaultWorkers() int {
+	n := runtime.GOMAXPROCS(0) * 4
+	if n > 64 {
+		n = 64
 	}
+	return n
+}
+
+// SetWorkers sets how many directories are scanned concurrently. Values
+// below 1 select DefaultWorkers; 1 scans sequentially.
+func (a *Analyzer) SetWorkers(n int) {
+	if n < 1 {
+		n = DefaultWorkers()
+	}
+	a.workers = n
+	a.sem = nil
+	if n > 1 {
+		a.sem = make(chan struct{}, n-1)
+	}
+}
+
+// SetFollowLinks controls whether symbolic links and junctions are followed.
+// By default links are reported as leaf nodes without being traversed; when
+// following, each target directory is entered at most once so loops and
+// repeated targets are not double-counted.
+func (a *Analyzer) SetFollowLinks(follow bool) {
+	a.followLinks = follow
+}
+
+// SetExclude makes the analyzer skip entries matching l, along with
+// everything beneath them. Pass nil to scan everything.
+func (a *Analyzer) SetExclude(l *utils.ExcludeList) {
+	a.exclude = l
+}
+
+// SetOneFileSystem controls whether the scan stays on the filesystem or
+// volume of the root, skipping mounted drives and network shares below it.
+func (a *Analyzer) SetOneFileSystem(on bool) {
+	a.oneFileSystem = on
+}
+
+// Size modes for SetSizeMode.
+const (
+	SizeApparent  = "apparent"
+	SizeAllocated = "allocated"
+)
+
+// SetSizeMode selects whether the apparent or the allocated size drives
+// sorting, the minimum size filter and GetLargestFiles. Both sizes are
+// always recorded.
+func (a *Analyzer) SetSizeMode(mode string) {
+	if mode != SizeAllocated {
+		mode = SizeApparent
+	}
+	a.sizeMode = mode
+}
+
+// SizeOf returns the size of n in the analyzer's size mode.
+func (a *Analyzer) SizeOf(n *DiskNode) int64 {
+	if a.sizeMode == SizeAllocated {
+		return n.Allocated
+	}
+	return n.Size
+}
+
+// SetCache makes the analyzer reuse directory listings from c and record the
+// new scan in it. Pass nil to disable caching.
+func (a *Analyzer) SetCache(c *ScanCache) {
+	a.cache = c
 }
 
 // AnalyzePath analyzes the given path and returns a tree of DiskNodes.
+// The root path itself is always resolved if it is a link.
 func (a *Analyzer) AnalyzePath(path string) (*DiskNode, error) {
-	return a.analyzeNode(path, 0)
+	node, err := a.scan(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.cache != nil {
+		a.cache.record(node, a.optionsKey())
+	}
+
+	return node, nil
 }
 
-func (a *Analyzer) analyzeNode(path string, depth int) (*DiskNode, error) {
+// Expand analyzes the directory at path as a new root, to the analyzer's
+// depth. It is used to drill into folders that were summarized beyond the
+// original depth. Expanded scans are not recorded in the cache.
+func (a *Analyzer) Expand(path string) (*DiskNode, error) {
+	return a.scan(path)
+}
+
+func (a *Analyzer) scan(path string) (*DiskNode, error) {
 	info, err := os.Stat(path)
 	if err != nil {
 		return nil, fmt.Errorf("cannot stat %s: %w", path, err)
 	}
 
+	a.hardlinks = utils.NewLinkTracker()
+	a.device = 0
+	if a.oneFileSystem {
+		if dev, ok := utils.DeviceID(path, info); ok {
+			a.device = dev
+		}
+	}
+	a.links = nil
+	if a.followLinks {
+		a.links = utils.NewLinkTracker()
+		a.links.Visit(path)
+	}
+
+	root := dirEntry{
+		name:    filepath.Base(path),
+		isDir:   info.IsDir(),
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		attrs:   getAttributes(filepath.Base(path), info),
+	}
+	root.setUsage(path, info)
+
+	return a.analyzeNode(path, root, 0)
+}
+
+func (a *Analyzer) analyzeNode(path string, e dirEntry, depth int) (*DiskNode, error) {
 	node := &DiskNode{
-		Name:        filepath.Base(path),
+		Name:        e.name,
 		Path:        path,
-		IsDirectory: info.IsDir(),
-		ModTime:     info.ModTime(),
+		IsDirectory: e.isDir,
+		ModTime:     e.modTime,
+		Attrs:       e.attrs,
+	}
+
+	if depth > 0 && e.isLink {
+		node.IsLink = true
+		node.LinkTarget = e.linkTarget
+
+		if !a.followLinks {
+			node.IsDirectory = false
+			return node, nil
+		}
+
+		target, ok := a.resolveLink(path)
+		if !ok {
+			return node, nil
+		}
+		node.IsDirectory = target.isDir
+		e = target
+	} else if depth > 0 && e.isDir && a.followLinks && !a.links.Visit(path) {
+		return node, nil
+	}
+
+	if depth > 0 && e.isDir && a.otherDevice(path) {
+		return nil, errOtherDevice
 	}
 
-	if !info.IsDir() {
-		node.Size = info.Size()
+	if !e.isDir {
 		node.ItemCount = 1
+		if e.attrs.Has(utils.AttrOffline) {
+			return node, nil
+		}
+		if !a.hardlinks.VisitFile(e.usage) {
+			node.HardLink = true
+			return node, nil
+		}
 
-		if node.Size > 100*1024*1024 {
+		node.Size = e.size
+		node.Allocated = e.alloc
+		if node.Size > largeFileSize {
 			node.LargeFiles = 1
 		}
 
@@ -76,50 +281,303 @@ func (a *Analyzer) analyzeNode(path string, depth int) (*DiskNode, error) {
 	}
 
 	if depth >= a.maxDepth {
-		size, count, err := utils.GetDirSize(path)
+		stats, err := a.sumDir(path)
 		if err != nil {
 			return nil, fmt.Errorf("cannot calculate size of %s: %w", path, err)
 		}
-		node.Size = size
-		node.ItemCount = count
+		node.Size = stats.size
+		node.Allocated = stats.alloc
+		node.ItemCount = stats.files
+		node.LargeFiles = stats.largeFiles
 		return node, nil
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := a.listDir(path)
 	if err != nil {
-		size, count, _ := utils.GetDirSize(path)
-		node.Size = size
-		node.ItemCount = count
 		return node, nil
 	}
 
-	for _, entry := range entries {
-		if !a.showHidden && isHidden(entry.Name()) {
+	visible := make([]dirEntry, 0, len(entries))
+	for _, child := range entries {
+		if !a.showHidden && child.attrs.Has(utils.AttrHidden) {
+			continue
+		}
+		if a.exclude.Match(filepath.Join(path, child.name)) {
 			continue
 		}
+		visible = append(visible, child)
+	}
 
-		childPath := filepath.Join(path, entry.Name())
+	children := make([]*DiskNode, len(visible))
+	a.forEach(visible, func(i int) {
+		childNode, err := a.analyzeNode(filepath.Join(path, visible[i].name), visible[i], depth+1)
+		if err == nil {
+			children[i] = childNode
+		}
+	})
 
-		childNode, err := a.analyzeNode(childPath, depth+1)
-		if err != nil {
+	for _, childNode := range children {
+		if childNode == nil {
 			continue
 		}
 
-		if childNode.Size >= a.minSize {
+		if a.SizeOf(childNode) >= a.minSize {
 			node.Children = append(node.Children, childNode)
 			node.Size += childNode.Size
+			node.Allocated += childNode.Allocated
 			node.ItemCount += childNode.ItemCount
 			node.LargeFiles += childNode.LargeFiles
 		}
 	}
 
 	sort.Slice(node.Children, func(i, j int) bool {
-		return node.Children[i].Size > node.Children[j].Size
+		return a.SizeOf(node.Children[i]) > a.SizeOf(node.Children[j])
 	})
 
 	return node, nil
 }
 
+// largeFileSize is the size above which a file counts towards LargeFiles.
+const largeFileSize = 100 * 1024 * 1024
+
+// dirEntry is one directory entry as seen by the analyzer. Links are
+// recorded as links; their targets are resolved only when following links.
+type dirEntry struct {
+	name       string
+	isDir      bool
+	isLink     bool
+	linkTarget string
+	size       int64
+	alloc      int64
+	modTime    time.Time
+	attrs      utils.Attributes
+
+	// usage identifies files with several hard links; it is only filled in
+	// for those.
+	usage utils.FileUsage
+}
+
+// setUsage fills in the allocated size of a regular file from info, and its
+// identity when it has several hard links. Without usage information the
+// apparent size stands in for the allocated size. Offline files are not
+// opened, so reading their usage cannot trigger a download.
+func (e *dirEntry) setUsage(path string, info os.FileInfo) {
+	e.alloc = e.size
+	if e.isDir || e.isLink || e.attrs.Has(utils.AttrOffline) {
+		return
+	}
+
+	u, ok := utils.GetFileUsage(path, info)
+	if !ok {
+		return
+	}
+	e.alloc = u.Allocated
+	if u.Links > 1 {
+		e.usage = u
+	}
+}
+
+// listDir returns the entries of the directory at path, reusing the cached
+// listing when the directory's modification time is unchanged. The time is
+// read from the directory itself, not from its parent's listing, which may
+// have come from the cache too. A reused listing costs that one stat: its
+// files are trusted as cached.
+func (a *Analyzer) listDir(path string) ([]dirEntry, error) {
+	var modTime time.Time
+	if a.cache != nil {
+		info, err := statDir(path)
+		if err != nil {
+			return nil, err
+		}
+		modTime = info.ModTime()
+		if entries, ok := a.cache.lookup(path, modTime); ok {
+			return entries, nil
+		}
+	}
+
+	dirEntries, err := readDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]dirEntry, 0, len(dirEntries))
+	for _, de := range dirEntries {
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+
+		childPath := filepath.Join(path, de.Name())
+		e := dirEntry{
+			name:    de.Name(),
+			isDir:   info.IsDir(),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+			attrs:   getAttributes(de.Name(), info),
+		}
+		if utils.IsLink(childPath, info) {
+			e.isLink = true
+			e.isDir = false
+			e.size = 0
+			e.linkTarget, _ = os.Readlink(childPath)
+		}
+		e.setUsage(childPath, info)
+		entries = append(entries, e)
+	}
+
+	if a.cache != nil {
+		a.cache.store(path, modTime, entries)
+	}
+
+	return entries, nil
+}
+
+// resolveLink returns the target of the link at path. It reports false if the
+// target cannot be read or is a directory that has already been visited.
+func (a *Analyzer) resolveLink(path string) (dirEntry, bool) {
+	target, err := os.Stat(path)
+	if err != nil {
+		return dirEntry{}, false
+	}
+	if target.IsDir() && !a.links.Visit(path) {
+		return dirEntry{}, false
+	}
+	return dirEntry{
+		name:    filepath.Base(path),
+		isDir:   target.IsDir(),
+		size:    target.Size(),
+		alloc:   target.Size(),
+		modTime: target.ModTime(),
+		attrs:   getAttributes(filepath.Base(path), target),
+	}, true
+}
+
+// dirStats aggregates a subtree that is sized but not expanded into nodes.
+type dirStats struct {
+	size       int64
+	alloc      int64
+	files      int
+	largeFiles int
+}
+
+// sumDir totals the subtree at path. Hidden entries are included, links are
+// only followed when following links is enabled, offline files add no size,
+// and excluded entries, other filesystems and unreadable subdirectories are
+// skipped.
+func (a *Analyzer) sumDir(path string) (dirStats, error) {
+	var stats dirStats
+
+	entries, err := a.listDir(path)
+	if err != nil {
+		return stats, err
+	}
+
+	results := make([]dirStats, len(entries))
+	a.forEach(entries, func(i int) {
+		e := entries[i]
+		childPath := filepath.Join(path, e.name)
+
+		if a.exclude.Match(childPath) {
+			return
+		}
+
+		if e.isLink {
+			if !a.followLinks {
+				return
+			}
+			target, ok := a.resolveLink(childPath)
+			if !ok {
+				return
+			}
+			e = target
+		} else if e.isDir && a.followLinks && !a.links.Visit(childPath) {
+			return
+		}
+
+		if e.isDir {
+			if !a.otherDevice(childPath) {
+				results[i], _ = a.sumDir(childPath)
+			}
+			return
+		}
+
+		results[i] = dirStats{files: 1}
+		if e.attrs.Has(utils.AttrOffline) || !a.hardlinks.VisitFile(e.usage) {
+			return
+		}
+		results[i].size = e.size
+		results[i].alloc = e.alloc
+		if e.size > largeFileSize {
+			results[i].largeFiles = 1
+		}
+	})
+
+	for _, r := range results {
+		stats.size += r.size
+		stats.alloc += r.alloc
+		stats.files += r.files
+		stats.largeFiles += r.largeFiles
+	}
+
+	return stats, nil
+}
+
+// errOtherDevice is returned by analyzeNode for directories skipped because
+// they lie on another filesystem.
+var errOtherDevice = errors.New("on another filesystem")
+
+// otherDevice reports whether the directory at path lies on another
+// filesystem than the root when the scan is limited to one filesystem.
+func (a *Analyzer) otherDevice(path string) bool {
+	if !a.oneFileSystem || a.device == 0 {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	dev, ok := utils.DeviceID(path, info)
+	return ok && dev != a.device
+}
+
+// forEach calls fn for the index of every entry. Directories and links are
+// handed to another goroutine while a worker slot is free; everything else,
+// and all work once the pool is busy, runs on the calling goroutine, so
+// nested calls cannot deadlock. Results must be written to per-index slots.
+func (a *Analyzer) forEach(entries []dirEntry, fn func(i int)) {
+	var wg sync.WaitGroup
+
+	for i := range entries {
+		if !entries[i].isDir && !entries[i].isLink {
+			fn(i)
+			continue
+		}
+
+		select {
+		case a.sem <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-a.sem
+					wg.Done()
+				}()
+				fn(i)
+			}(i)
+		default:
+			fn(i)
+		}
+	}
+
+	wg.Wait()
+}
+
+// optionsKey identifies the settings that affect node sizes, so deltas are
+// only reported against a scan made with the same settings.
+func (a *Analyzer) optionsKey() string {
+	return fmt.Sprintf("hidden=%v min=%d links=%v size=%s exclude=%q xdev=%v",
+		a.showHidden, a.minSize, a.followLinks, a.sizeMode, a.exclude.Patterns(), a.oneFileSystem)
+}
+
 // GetLargestFiles returns the N largest files from the tree.
 func (a *Analyzer) GetLargestFiles(root *DiskNode, n int) []*DiskNode {
 	if n <= 0 {
@@ -130,7 +588,7 @@ func (a *Analyzer) GetLargestFiles(root *DiskNode, n int) []*DiskNode {
 	a.collectFiles(root, &files)
 
 	sort.Slice(files, func(i, j int) bool {
-		return files[i].Size > files[j].Size
+		return a.SizeOf(files[i]) > a.SizeOf(files[j])
 	})
 
 	if len(files) > n {
@@ -180,85 +638,3 @@ func (a *Analyzer) GetOldestFiles(root *DiskNode, daysOld int) []*DiskNode {
 
 	return oldFiles
 }
-
-// DuplicateGroup represents a set of files that are potential duplicates.
-type DuplicateGroup struct {
-	Size  int64
-	Hash  string
-	Files []*DiskNode
-}
-
-// GetDuplicates finds duplicate files by first matching on size, then verifying
-// with a partial content hash (first 8KB) for efficiency.
-func (a *Analyzer) GetDuplicates(root *DiskNode) []DuplicateGroup {
-	sizeMap := make(map[int64][]*DiskNode)
-
-	var collectBySize func(*DiskNode)
-	collectBySize = func(node *DiskNode) {
-		if !node.IsDirectory && node.Size > 0 {
-			sizeMap[node.Size] = append(sizeMap[node.Size], node)
-		}
-		for _, child := range node.Children {
-			collectBySize(child)
-		}
-	}
-
-	collectBySize(root)
-
-	var groups []DuplicateGroup
-
-	for size, nodes := range sizeMap {
-		if len(nodes) < 2 {
-			continue
-		}
-
-		hashGroups := make(map[string][]*DiskNode)
-		for _, node := range nodes {
-			hash, err := partialHash(node.Path)
-			if err != nil {
-				continue
-			}
-			hashGroups[hash] = append(hashGroups[hash], node)
-		}
-
-		for hash, hNodes := range hashGroups {
-			if len(hNodes) >= 2 {
-				groups = append(groups, DuplicateGroup{
-					Size:  size,
-					Hash:  hash,
-					Files: hNodes,
-				})
-			}
-		}
-	}
-
-	sort.Slice(groups, func(i, j int) bool {
-		return groups[i].Size*int64(len(groups[i].Files)) > groups[j].Size*int64(len(groups[j].Files))
-	})
-
-	return groups
-}
-
-// partialHash computes a SHA-256 hash of the first 8KB of a file for quick comparison.
-func partialHash(path string) (string, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
-	buf := make([]byte, 8192)
-	n, err := f.Read(buf)
-	if err != nil && err != io.EOF {
-		return "", err
-	}
-
-	h := sha256.Sum256(buf[:n])
-	return fmt.Sprintf("%x", h), nil
-}
-
-// isHidden checks if a file/directory name indicates it's hidden.
-// On Windows, files starting with '.' or having the hidden attribute are hidden.
-func isHidden(name string) bool {
-	return strings.HasPrefix(name, ".")
-}
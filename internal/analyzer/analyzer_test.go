@@ -3,8 +3,13 @@ package analyzer
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
 	"testing"
 	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
 )
 
 func createTestTree(t *testing.T) string {
@@ -327,3 +332,312 @@ func TestHiddenFiles(t *testing.T) {
 		t.Errorf("With hidden: ItemCount = %d, want 2", tree2.ItemCount)
 	}
 }
+
+func createLinkTree(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink tests require Linux")
+	}
+
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, d := range []string{root, outside} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "b.txt"), make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "external")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(root, "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	return root
+}
+
+func TestAnalyzePathReportsLinks(t *testing.T) {
+	root := createLinkTree(t)
+
+	a := NewAnalyzer(false, true, 5, 0)
+	tree, err := a.AnalyzePath(root)
+	if err != nil {
+		t.Fatalf("AnalyzePath error: %v", err)
+	}
+
+	if tree.Size != 100 {
+		t.Errorf("Size = %d, want 100 (links must not be followed)", tree.Size)
+	}
+
+	links := 0
+	for _, child := range tree.Children {
+		if child.IsLink {
+			links++
+			if child.LinkTarget == "" {
+				t.Errorf("link %s has no target", child.Name)
+			}
+			if len(child.Children) != 0 {
+				t.Errorf("link %s should not be expanded", child.Name)
+			}
+		}
+	}
+	if links != 2 {
+		t.Errorf("found %d link nodes, want 2", links)
+	}
+}
+
+func TestAnalyzePathFollowLinks(t *testing.T) {
+	root := createLinkTree(t)
+
+	for _, depth := range []int{1, 5} {
+		a := NewAnalyzer(false, true, depth, 0)
+		a.SetFollowLinks(true)
+		tree, err := a.AnalyzePath(root)
+		if err != nil {
+			t.Fatalf("AnalyzePath error: %v", err)
+		}
+
+		if tree.Size != 1100 {
+			t.Errorf("depth %d: Size = %d, want 1100", depth, tree.Size)
+		}
+		if tree.ItemCount != 2 {
+			t.Errorf("depth %d: ItemCount = %d, want 2", depth, tree.ItemCount)
+		}
+	}
+}
+
+func TestAnalyzePathHardLinksCountedOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	original := filepath.Join(tmpDir, "data.bin")
+	if err := os.WriteFile(original, make([]byte, 5000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(original, filepath.Join(tmpDir, "sub", "link.bin")); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	for _, depth := range []int{1, 5} {
+		a := NewAnalyzer(false, true, depth, 0)
+		tree, err := a.AnalyzePath(tmpDir)
+		if err != nil {
+			t.Fatalf("AnalyzePath error: %v", err)
+		}
+		if tree.Size != 5000 {
+			t.Errorf("depth %d: Size = %d, want 5000 (hard link counted once)", depth, tree.Size)
+		}
+		if tree.ItemCount != 2 {
+			t.Errorf("depth %d: ItemCount = %d, want 2", depth, tree.ItemCount)
+		}
+	}
+}
+
+func TestAnalyzePathAllocatedSize(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("extending a file with Truncate does not make it sparse on Windows")
+	}
+
+	tmpDir := t.TempDir()
+
+	sparse := filepath.Join(tmpDir, "sparse.img")
+	f, err := os.Create(sparse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(64 << 20); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	dense := filepath.Join(tmpDir, "dense.bin")
+	if err := os.WriteFile(dense, make([]byte, 1<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewAnalyzer(false, true, 3, 0)
+	tree, err := a.AnalyzePath(tmpDir)
+	if err != nil {
+		t.Fatalf("AnalyzePath error: %v", err)
+	}
+	if tree.Children[0].Name != "sparse.img" {
+		t.Errorf("apparent mode: largest = %s, want sparse.img", tree.Children[0].Name)
+	}
+	if tree.Allocated >= tree.Size {
+		t.Errorf("Allocated = %d, want less than apparent %d for a sparse file", tree.Allocated, tree.Size)
+	}
+
+	a.SetSizeMode(SizeAllocated)
+	tree, err = a.AnalyzePath(tmpDir)
+	if err != nil {
+		t.Fatalf("AnalyzePath error: %v", err)
+	}
+	if tree.Children[0].Name != "dense.bin" {
+		t.Errorf("allocated mode: largest = %s, want dense.bin", tree.Children[0].Name)
+	}
+}
+
+func TestAnalyzePathExclude(t *testing.T) {
+	tmpDir := createTestTree(t)
+
+	tests := []struct {
+		name      string
+		depth     int
+		patterns  []string
+		wantSize  int64
+		wantItems int
+	}{
+		{"by name", 5, []string{"dir2"}, 5300, 3},
+		{"by glob", 5, []string{"*.txt"}, 0, 0},
+		{"relative path", 5, []string{filepath.Join("dir1", "subdir")}, 25100, 4},
+		{"absolute path", 5, []string{filepath.Join(tmpDir, "dir2", "large.txt")}, 15300, 4},
+		{"beyond depth", 1, []string{"subdir", "another.txt"}, 15100, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := utils.NewExcludeList(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			a := NewAnalyzer(false, false, tt.depth, 0)
+			a.SetExclude(list)
+			tree, err := a.AnalyzePath(tmpDir)
+			if err != nil {
+				t.Fatalf("AnalyzePath error: %v", err)
+			}
+			if tree.Size != tt.wantSize || tree.ItemCount != tt.wantItems {
+				t.Errorf("size = %d, items = %d; want %d, %d", tree.Size, tree.ItemCount, tt.wantSize, tt.wantItems)
+			}
+		})
+	}
+}
+
+func TestAnalyzePathOneFileSystem(t *testing.T) {
+	tmpDir := t.TempDir()
+	other, err := os.MkdirTemp("/dev/shm", "burrow-")
+	if err != nil {
+		t.Skip("no second filesystem available")
+	}
+	defer os.RemoveAll(other)
+
+	rootInfo, _ := os.Stat(tmpDir)
+	otherInfo, _ := os.Stat(other)
+	rootDev, ok1 := utils.DeviceID(tmpDir, rootInfo)
+	otherDev, ok2 := utils.DeviceID(other, otherInfo)
+	if !ok1 || !ok2 || rootDev == otherDev {
+		t.Skip("no second filesystem available")
+	}
+
+	data := make([]byte, 100)
+	os.WriteFile(filepath.Join(tmpDir, "local.bin"), data, 0o644)
+	os.WriteFile(filepath.Join(other, "copy.bin"), data, 0o644)
+	if err := os.Symlink(other, filepath.Join(tmpDir, "mnt")); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	tests := []struct {
+		name       string
+		depth      int
+		oneFS      bool
+		wantSize   int64
+		wantGroups int
+	}{
+		{"crossing", 5, false, 200, 1},
+		{"one filesystem", 5, true, 100, 0},
+		{"one filesystem beyond depth", 1, true, 100, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnalyzer(false, true, tt.depth, 0)
+			a.SetFollowLinks(true)
+			a.SetOneFileSystem(tt.oneFS)
+			tree, err := a.AnalyzePath(tmpDir)
+			if err != nil {
+				t.Fatalf("AnalyzePath error: %v", err)
+			}
+			if tree.Size != tt.wantSize {
+				t.Errorf("size = %d, want %d", tree.Size, tt.wantSize)
+			}
+			if groups, _ := a.FindDuplicates(tree); len(groups) != tt.wantGroups {
+				t.Errorf("duplicate groups = %d, want %d", len(groups), tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestAnalyzePathAttributes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]int{
+		"visible.txt":             100,
+		".dotfile":                50,
+		"desktop.ini":             20,
+		"cloud.docx":              5000,
+		filepath.Join("sub", "a"): 30,
+	}
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(root, name), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Windows marks desktop.ini hidden and cloud.docx offline; the dot
+	// prefix alone no longer makes a file hidden.
+	getAttributes = func(name string, info os.FileInfo) utils.Attributes {
+		switch name {
+		case "desktop.ini":
+			return utils.AttrHidden | utils.AttrSystem
+		case "cloud.docx":
+			return utils.AttrOffline
+		}
+		return 0
+	}
+	defer func() { getAttributes = utils.GetAttributes }()
+
+	tests := []struct {
+		showHidden bool
+		wantSize   int64
+		wantNames  []string
+	}{
+		{false, 180, []string{".dotfile", "cloud.docx", "sub", "visible.txt"}},
+		{true, 200, []string{".dotfile", "cloud.docx", "desktop.ini", "sub", "visible.txt"}},
+	}
+	for _, tt := range tests {
+		tree, err := NewAnalyzer(false, tt.showHidden, 5, 0).AnalyzePath(root)
+		if err != nil {
+			t.Fatalf("AnalyzePath error: %v", err)
+		}
+
+		var names []string
+		for _, c := range tree.Children {
+			names = append(names, c.Name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tt.wantNames) {
+			t.Errorf("showHidden=%v: children = %v, want %v", tt.showHidden, names, tt.wantNames)
+		}
+		if tree.Size != tt.wantSize {
+			t.Errorf("showHidden=%v: Size = %d, want %d", tt.showHidden, tree.Size, tt.wantSize)
+		}
+
+		cloud := findChild(tree, "cloud.docx")
+		if cloud == nil || cloud.Size != 0 || cloud.ItemCount != 1 || !cloud.Attrs.Has(utils.AttrOffline) {
+			t.Errorf("showHidden=%v: cloud placeholder = %+v, want zero size, one item, offline", tt.showHidden, cloud)
+		}
+	}
+}
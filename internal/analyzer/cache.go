@@ -0,0 +1,260 @@
+package analyzer
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// cacheVersion is bumped whenever the cache layout changes; caches with a
+// different version are discarded.
+const cacheVersion = 4
+
+// ScanCache persists directory listings and node sizes between analyzer runs.
+//
+// A directory whose modification time is unchanged is not read again; its
+// cached listing is reused, files included. Every directory is still stat'd
+// on each scan, even below a reused listing, so additions, removals and
+// renames anywhere in the tree are picked up. Files written in place and hard
+// links made from elsewhere leave their directory alone and are only seen
+// after a Reset.
+type ScanCache struct {
+	file string
+	prev cacheData
+
+	mu   sync.Mutex // guards next.Dirs during a concurrent scan
+	next cacheData
+}
+
+// cacheData is the gob-encoded, gzip-compressed cache file.
+type cacheData struct {
+	Version   int
+	Root      string
+	Options   string
+	ScannedAt time.Time
+	Dirs      map[string]cachedDir
+	Sizes     map[string]int64
+}
+
+type cachedDir struct {
+	ModTime int64
+	Entries []cachedEntry
+}
+
+type cachedEntry struct {
+	Name    string
+	Flags   uint8
+	Size    int64
+	Alloc   int64
+	Links   int32
+	Attrs   uint8
+	ModTime int64
+	Target  string
+	// Device and Index identify a file with several hard links.
+	Device uint64
+	Index  uint64
+}
+
+const (
+	entryDir uint8 = 1 << iota
+	entryLink
+	entryID // Device and Index are set
+)
+
+// OpenCache loads the scan cache for root. A missing, unreadable or outdated
+// cache file yields an empty cache rather than an error.
+func OpenCache(root string) (*ScanCache, error) {
+	dir, err := utils.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	dir = filepath.Join(dir, "cache")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create cache directory: %w", err)
+	}
+
+	key := strings.ToLower(filepath.Clean(root))
+	sum := sha256.Sum256([]byte(key))
+
+	c := &ScanCache{file: filepath.Join(dir, fmt.Sprintf("analyze-%x.gob.gz", sum[:8]))}
+	if data, err := readCache(c.file); err == nil && data.Version == cacheVersion && strings.EqualFold(data.Root, root) {
+		c.prev = data
+	}
+	c.next = cacheData{
+		Version: cacheVersion,
+		Root:    root,
+		Dirs:    make(map[string]cachedDir),
+		Sizes:   make(map[string]int64),
+	}
+	return c, nil
+}
+
+func readCache(path string) (cacheData, error) {
+	var data cacheData
+
+	f, err := os.Open(path)
+	if err != nil {
+		return data, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return data, err
+	}
+	defer zr.Close()
+
+	err = gob.NewDecoder(zr).Decode(&data)
+	return data, err
+}
+
+// Reset discards the cached directory listings so the next scan reads every
+// directory again. Sizes from the previous scan are kept for deltas.
+func (c *ScanCache) Reset() {
+	c.prev.Dirs = nil
+}
+
+// PreviousScan returns when the cached scan was made, or the zero time.
+func (c *ScanCache) PreviousScan() time.Time {
+	return c.prev.ScannedAt
+}
+
+// PreviousSizes returns the node sizes recorded by the previous scan, keyed by
+// path. They are only comparable with the current scan when HasDeltas
+// reports true.
+func (c *ScanCache) PreviousSizes() map[string]int64 {
+	return c.prev.Sizes
+}
+
+// HasDeltas reports whether the last recorded scan could be compared with a
+// previous scan made with the same settings.
+func (c *ScanCache) HasDeltas() bool {
+	return !c.prev.ScannedAt.IsZero() && c.prev.Options == c.next.Options && len(c.prev.Sizes) > 0
+}
+
+// Save writes the scan recorded by the analyzer to disk.
+func (c *ScanCache) Save() error {
+	tmp := c.file + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("cannot write scan cache: %w", err)
+	}
+
+	zw := gzip.NewWriter(f)
+	err = gob.NewEncoder(zw).Encode(&c.next)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("cannot write scan cache: %w", err)
+	}
+
+	if err := os.Rename(tmp, c.file); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("cannot write scan cache: %w", err)
+	}
+	return nil
+}
+
+// lookup returns the cached listing of path if its modification time matches.
+// A listing that is used is carried over into the new cache.
+func (c *ScanCache) lookup(path string, modTime time.Time) ([]dirEntry, bool) {
+	cd, ok := c.prev.Dirs[path]
+	if !ok || cd.ModTime != modTime.UnixNano() {
+		return nil, false
+	}
+
+	entries := make([]dirEntry, len(cd.Entries))
+	for i, ce := range cd.Entries {
+		entries[i] = dirEntry{
+			name:       ce.Name,
+			isDir:      ce.Flags&entryDir != 0,
+			isLink:     ce.Flags&entryLink != 0,
+			linkTarget: ce.Target,
+			size:       ce.Size,
+			alloc:      ce.Alloc,
+			modTime:    time.Unix(0, ce.ModTime),
+			attrs:      utils.Attributes(ce.Attrs),
+		}
+		entries[i].usage = utils.FileUsage{Allocated: ce.Alloc, Links: int(ce.Links)}
+		if ce.Flags&entryID != 0 {
+			entries[i].usage = entries[i].usage.WithIdentity(ce.Device, ce.Index)
+		}
+	}
+
+	c.mu.Lock()
+	c.next.Dirs[path] = cd
+	c.mu.Unlock()
+	return entries, true
+}
+
+// store records a freshly read listing of path.
+func (c *ScanCache) store(path string, modTime time.Time, entries []dirEntry) {
+	cd := cachedDir{
+		ModTime: modTime.UnixNano(),
+		Entries: make([]cachedEntry, len(entries)),
+	}
+	for i, e := range entries {
+		var flags uint8
+		if e.isDir {
+			flags |= entryDir
+		}
+		if e.isLink {
+			flags |= entryLink
+		}
+		cd.Entries[i] = cachedEntry{
+			Name:    e.name,
+			Flags:   flags,
+			Size:    e.size,
+			Alloc:   e.alloc,
+			Links:   int32(e.usage.Links),
+			Attrs:   uint8(e.attrs),
+			ModTime: e.modTime.UnixNano(),
+			Target:  e.linkTarget,
+		}
+		if dev, index, ok := e.usage.Identity(); ok {
+			cd.Entries[i].Flags |= entryID
+			cd.Entries[i].Device, cd.Entries[i].Index = dev, index
+		}
+	}
+
+	c.mu.Lock()
+	c.next.Dirs[path] = cd
+	c.mu.Unlock()
+}
+
+// record stores the sizes of the finished tree and fills in the deltas
+// against the previous scan when it used the same options.
+func (c *ScanCache) record(root *DiskNode, options string) {
+	c.next.Options = options
+	c.next.ScannedAt = time.Now()
+
+	compare := c.HasDeltas()
+
+	var walk func(*DiskNode)
+	walk = func(n *DiskNode) {
+		c.next.Sizes[n.Path] = n.Size
+		if compare {
+			prev, ok := c.prev.Sizes[n.Path]
+			n.Delta = n.Size - prev
+			n.IsNew = !ok
+		}
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+	walk(root)
+}
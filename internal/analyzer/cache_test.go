@@ -0,0 +1,269 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+func scanWithCache(t *testing.T, a *Analyzer, root string, reset bool) (*DiskNode, *ScanCache) {
+	t.Helper()
+	c, err := OpenCache(root)
+	if err != nil {
+		t.Fatalf("OpenCache error: %v", err)
+	}
+	if reset {
+		c.Reset()
+	}
+	a.SetCache(c)
+
+	tree, err := a.AnalyzePath(root)
+	if err != nil {
+		t.Fatalf("AnalyzePath error: %v", err)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	return tree, c
+}
+
+func findChild(n *DiskNode, name string) *DiskNode {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestScanCacheDeltas(t *testing.T) {
+	os.Setenv("APPDATA", t.TempDir())
+	defer os.Unsetenv("APPDATA")
+
+	root := createTestTree(t)
+	a := NewAnalyzer(false, true, 1, 0)
+
+	first, c := scanWithCache(t, a, root, false)
+	if c.HasDeltas() {
+		t.Error("first scan should have no deltas")
+	}
+
+	// dir2 is beyond the display depth, so its new file is picked up through
+	// the changed directory mtime of dir2 itself.
+	if err := os.WriteFile(filepath.Join(root, "dir2", "new.bin"), make([]byte, 3000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "fresh.txt"), make([]byte, 50), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	second, c := scanWithCache(t, a, root, false)
+	if !c.HasDeltas() {
+		t.Fatal("second scan should report deltas")
+	}
+	if second.Size != first.Size+3050 {
+		t.Errorf("Size = %d, want %d", second.Size, first.Size+3050)
+	}
+	if second.Delta != 3050 {
+		t.Errorf("root Delta = %d, want 3050", second.Delta)
+	}
+
+	dir2 := findChild(second, "dir2")
+	if dir2 == nil || dir2.Delta != 3000 || dir2.IsNew {
+		t.Errorf("dir2 = %+v, want Delta 3000 and not new", dir2)
+	}
+	if fresh := findChild(second, "fresh.txt"); fresh == nil || !fresh.IsNew {
+		t.Errorf("fresh.txt = %+v, want IsNew", fresh)
+	}
+	if dir1 := findChild(second, "dir1"); dir1 == nil || dir1.Delta != 0 {
+		t.Errorf("dir1 = %+v, want unchanged", dir1)
+	}
+
+	// Changing the options makes the sizes incomparable.
+	b := NewAnalyzer(false, false, 1, 0)
+	if _, c := scanWithCache(t, b, root, false); c.HasDeltas() {
+		t.Error("scan with different options should not report deltas")
+	}
+}
+
+func TestScanCacheReusesUnchangedDirectories(t *testing.T) {
+	os.Setenv("APPDATA", t.TempDir())
+	defer os.Unsetenv("APPDATA")
+
+	root := createTestTree(t)
+	a := NewAnalyzer(false, true, 5, 0)
+
+	first, _ := scanWithCache(t, a, root, false)
+
+	// Growing a file in place and adding one behind a restored directory
+	// mtime leave the directory looking unchanged, so its listing, files
+	// included, is reused until a rescan.
+	dir1 := filepath.Join(root, "dir1")
+	dirInfo, err := os.Stat(dir1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir1, "medium.txt"), make([]byte, 8000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir1, "hidden-by-cache.txt"), make([]byte, 700), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(dir1, dirInfo.ModTime(), dirInfo.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, _ := scanWithCache(t, a, root, false)
+	if cached.Size != first.Size || cached.Delta != 0 {
+		t.Errorf("cached scan Size = %d, Delta = %d; want reused %d, 0", cached.Size, cached.Delta, first.Size)
+	}
+
+	rescanned, _ := scanWithCache(t, a, root, true)
+	if rescanned.Size != first.Size+3700 || rescanned.Delta != 3700 {
+		t.Errorf("rescan Size = %d, Delta = %d; want %d, 3700", rescanned.Size, rescanned.Delta, first.Size+3700)
+	}
+}
+
+func TestScanCacheWarmScanDoesLessIO(t *testing.T) {
+	os.Setenv("APPDATA", t.TempDir())
+	defer os.Unsetenv("APPDATA")
+
+	var stats, lists, infos atomic.Int64
+	statDir = func(name string) (os.FileInfo, error) {
+		stats.Add(1)
+		return os.Stat(name)
+	}
+	readDir = func(name string) ([]os.DirEntry, error) {
+		lists.Add(1)
+		entries, err := os.ReadDir(name)
+		for i := range entries {
+			entries[i] = countingEntry{entries[i], &infos}
+		}
+		return entries, err
+	}
+	defer func() { statDir, readDir = os.Stat, os.ReadDir }()
+
+	root := createTestTree(t)
+	a := NewAnalyzer(false, true, 5, 0)
+
+	cold, _ := scanWithCache(t, a, root, true)
+	coldIO := stats.Load() + lists.Load() + infos.Load()
+	dirs := lists.Load()
+
+	stats.Store(0)
+	lists.Store(0)
+	infos.Store(0)
+	warm, _ := scanWithCache(t, a, root, false)
+	warmIO := stats.Load() + lists.Load() + infos.Load()
+
+	if warm.Size != cold.Size {
+		t.Errorf("warm scan Size = %d, want %d", warm.Size, cold.Size)
+	}
+	// A warm scan stats each directory once and reads nothing else.
+	if lists.Load() != 0 || infos.Load() != 0 || stats.Load() != dirs {
+		t.Errorf("warm scan did %d stats, %d listings, %d file stats; want %d, 0, 0", stats.Load(), lists.Load(), infos.Load(), dirs)
+	}
+	if warmIO >= coldIO {
+		t.Errorf("warm scan did %d calls, cold scan %d", warmIO, coldIO)
+	}
+}
+
+// countingEntry counts the Info calls made on a directory entry, each a stat
+// on most platforms.
+type countingEntry struct {
+	os.DirEntry
+	n *atomic.Int64
+}
+
+func (e countingEntry) Info() (os.FileInfo, error) {
+	e.n.Add(1)
+	return e.DirEntry.Info()
+}
+
+func TestScanCacheSeesNestedChanges(t *testing.T) {
+	os.Setenv("APPDATA", t.TempDir())
+	defer os.Unsetenv("APPDATA")
+
+	root := t.TempDir()
+	deep := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(deep, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(deep, "x"), make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, depth := range []int{1, 3} {
+		a := NewAnalyzer(false, true, depth, 0)
+		scanWithCache(t, a, root, true)
+
+		// Only a/b changes; root and a keep their modification times.
+		extra := filepath.Join(deep, "y")
+		if err := os.WriteFile(extra, make([]byte, 5000), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		tree, _ := scanWithCache(t, a, root, false)
+		if tree.Size != 5100 {
+			t.Errorf("depth %d: Size = %d after a nested change, want 5100", depth, tree.Size)
+		}
+		os.Remove(extra)
+	}
+}
+
+func TestScanCacheHardLinks(t *testing.T) {
+	os.Setenv("APPDATA", t.TempDir())
+	defer os.Unsetenv("APPDATA")
+
+	root := t.TempDir()
+	for _, d := range []string{"a", "b"} {
+		if err := os.Mkdir(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orig := filepath.Join(root, "a", "data.bin")
+	if err := os.WriteFile(orig, make([]byte, 4000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Link(orig, filepath.Join(root, "b", "link.bin")); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+	if u, ok := utils.GetFileUsage(orig, mustLstat(t, orig)); !ok || u.Links < 2 {
+		t.Skip("link counts not available on this platform")
+	}
+
+	// The identities of linked files are cached, so a warm scan that reuses
+	// both listings still counts the file once.
+	a := NewAnalyzer(false, true, 3, 0)
+	scanWithCache(t, a, root, true)
+	tree, _ := scanWithCache(t, a, root, false)
+	if tree.Size != 4000 {
+		t.Errorf("cached scan Size = %d with a hard link, want 4000", tree.Size)
+	}
+
+	// A link made from c leaves a, and its cached listing, untouched; a
+	// rescan reads the new link count.
+	if err := os.Mkdir(filepath.Join(root, "c"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(orig, filepath.Join(root, "c", "link.bin")); err != nil {
+		t.Fatal(err)
+	}
+	tree, _ = scanWithCache(t, a, root, true)
+	if tree.Size != 4000 {
+		t.Errorf("rescan Size = %d with a new hard link, want 4000", tree.Size)
+	}
+}
+
+func mustLstat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change kinds reported by Compare.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeGrown   = "grown"
+	ChangeShrunk  = "shrunk"
+)
+
+// Change is a path whose size differs between two scans.
+type Change struct {
+	Path   string
+	Kind   string
+	Before int64
+	After  int64
+}
+
+// Delta returns the change in size.
+func (c Change) Delta() int64 {
+	return c.After - c.Before
+}
+
+// Diff is the result of comparing two scans of the same root.
+type Diff struct {
+	Root    string
+	From    time.Time
+	To      time.Time
+	Before  int64
+	After   int64
+	Changes []Change
+}
+
+// GrowthPerDay returns the change in the root's size per day between the two
+// scans. It reports false when the scan times are missing or out of order.
+func (d *Diff) GrowthPerDay() (float64, bool) {
+	elapsed := d.To.Sub(d.From)
+	if d.From.IsZero() || d.To.IsZero() || elapsed <= 0 {
+		return 0, false
+	}
+	return float64(d.After-d.Before) / elapsed.Hours() * 24, true
+}
+
+// Sizes flattens a tree into a map from path to size.
+func Sizes(root *DiskNode) map[string]int64 {
+	sizes := make(map[string]int64)
+
+	var walk func(*DiskNode)
+	walk = func(n *DiskNode) {
+		sizes[n.Path] = n.Size
+		for _, child := range n.Children {
+			walk(child)
+		}
+	}
+
+	walk(root)
+	return sizes
+}
+
+// Compare reports the paths under root that were added, removed, grew or
+// shrank between two scans, ranked by the absolute change in size. Only paths
+// down to the depth reached by both scans are compared, so scans made with
+// different depths do not report folders as added or removed. Paths inside an
+// added or removed folder are left out; the folder covers them.
+func Compare(root string, before, after map[string]int64, from, to time.Time) *Diff {
+	d := &Diff{
+		Root:   root,
+		From:   from,
+		To:     to,
+		Before: before[root],
+		After:  after[root],
+	}
+
+	maxDepth := min(deepest(root, before), deepest(root, after))
+	inScope := func(path string) bool {
+		depth, ok := relDepth(root, path)
+		return ok && depth <= maxDepth
+	}
+
+	for path, a := range after {
+		if !inScope(path) {
+			continue
+		}
+		b, ok := before[path]
+		switch {
+		case !ok:
+			d.Changes = append(d.Changes, Change{Path: path, Kind: ChangeAdded, After: a})
+		case a > b:
+			d.Changes = append(d.Changes, Change{Path: path, Kind: ChangeGrown, Before: b, After: a})
+		case a < b:
+			d.Changes = append(d.Changes, Change{Path: path, Kind: ChangeShrunk, Before: b, After: a})
+		}
+	}
+	for path, b := range before {
+		if _, ok := after[path]; !ok && inScope(path) {
+			d.Changes = append(d.Changes, Change{Path: path, Kind: ChangeRemoved, Before: b})
+		}
+	}
+
+	d.Changes = collapseChanges(d.Changes)
+
+	sort.Slice(d.Changes, func(i, j int) bool {
+		ai, aj := abs(d.Changes[i].Delta()), abs(d.Changes[j].Delta())
+		if ai != aj {
+			return ai > aj
+		}
+		return d.Changes[i].Path < d.Changes[j].Path
+	})
+
+	return d
+}
+
+// collapseChanges drops additions and removals that lie inside a folder that
+// was itself added or removed.
+func collapseChanges(changes []Change) []Change {
+	whole := make(map[string]bool)
+	for _, c := range changes {
+		if c.Kind == ChangeAdded || c.Kind == ChangeRemoved {
+			whole[c.Path] = true
+		}
+	}
+
+	kept := changes[:0]
+	for _, c := range changes {
+		if (c.Kind == ChangeAdded || c.Kind == ChangeRemoved) && hasWholeAncestor(c.Path, whole) {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+func hasWholeAncestor(path string, whole map[string]bool) bool {
+	for dir := filepath.Dir(path); dir != path; path, dir = dir, filepath.Dir(dir) {
+		if whole[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// deepest returns the greatest depth below root of any path in sizes.
+func deepest(root string, sizes map[string]int64) int {
+	deepest := 0
+	for path := range sizes {
+		if depth, ok := relDepth(root, path); ok && depth > deepest {
+			deepest = depth
+		}
+	}
+	return deepest
+}
+
+// relDepth returns how many levels path lies below root.
+func relDepth(root, path string) (int, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return 0, false
+	}
+	if rel == "." {
+		return 0, true
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1, true
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
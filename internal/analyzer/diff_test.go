@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	p := filepath.FromSlash
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(48 * time.Hour)
+
+	before := map[string]int64{
+		p("/r"):            1000,
+		p("/r/logs"):       100,
+		p("/r/old"):        300,
+		p("/r/old/a"):      300,
+		p("/r/media"):      600,
+		p("/r/media/x"):    600,
+		p("/r/media/x/y"):  600, // deeper than the second scan reached
+		p("/other/ignore"): 5,
+	}
+	after := map[string]int64{
+		p("/r"):         3000,
+		p("/r/logs"):    2100,
+		p("/r/media"):   500,
+		p("/r/media/x"): 500,
+		p("/r/new"):     400,
+		p("/r/new/b"):   400,
+	}
+
+	d := Compare(p("/r"), before, after, from, to)
+
+	want := []Change{
+		{Path: p("/r"), Kind: ChangeGrown, Before: 1000, After: 3000},
+		{Path: p("/r/logs"), Kind: ChangeGrown, Before: 100, After: 2100},
+		{Path: p("/r/new"), Kind: ChangeAdded, After: 400},
+		{Path: p("/r/old"), Kind: ChangeRemoved, Before: 300},
+		{Path: p("/r/media"), Kind: ChangeShrunk, Before: 600, After: 500},
+		{Path: p("/r/media/x"), Kind: ChangeShrunk, Before: 600, After: 500},
+	}
+	if len(d.Changes) != len(want) {
+		t.Fatalf("Changes = %+v, want %+v", d.Changes, want)
+	}
+	for i := range want {
+		if d.Changes[i] != want[i] {
+			t.Errorf("Changes[%d] = %+v, want %+v", i, d.Changes[i], want[i])
+		}
+	}
+
+	rate, ok := d.GrowthPerDay()
+	if !ok || math.Abs(rate-1000) > 0.001 {
+		t.Errorf("GrowthPerDay = %v, %v; want 1000", rate, ok)
+	}
+
+	if _, ok := Compare(p("/r"), before, after, to, from).GrowthPerDay(); ok {
+		t.Error("GrowthPerDay should fail when the scans are out of order")
+	}
+}
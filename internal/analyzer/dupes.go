@@ -0,0 +1,357 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// hashBlock is the size of the head and tail samples hashed before a file
+// is read in full.
+const hashBlock = 8192
+
+// DuplicateGroup is a set of files with identical content.
+type DuplicateGroup struct {
+	Size  int64
+	Hash  string // SHA-256 of the full content
+	Files []*DiskNode
+}
+
+// Wasted returns the bytes that removing all but one copy would free.
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+// DupeStats counts the files that reached each stage of duplicate detection.
+type DupeStats struct {
+	Files      int // files considered
+	SameSize   int // files sharing their size with another file
+	HeadHashed int
+	TailHashed int
+	FullHashed int
+}
+
+// GetDuplicates returns groups of files in the tree with identical content,
+// largest waste first.
+func (a *Analyzer) GetDuplicates(root *DiskNode) []DuplicateGroup {
+	groups, _ := a.FindDuplicates(root)
+	return groups
+}
+
+// FindDuplicates finds files with identical content in stages, each run
+// only on the files still sharing a group: equal size, then a hash of the
+// first 8 KB, then of the last 8 KB, then a streaming hash of the whole
+// file. Files of at most 8 KB are settled by the first hash. Each stage
+// hashes on goroutines of its own, as many as the analyzer's worker count.
+// Hardlinks to the same file are reported once, and unreadable files are
+// dropped.
+func (a *Analyzer) FindDuplicates(root *DiskNode) ([]DuplicateGroup, DupeStats) {
+	var stats DupeStats
+
+	bySize := make(map[int64][]*DiskNode)
+	var collect func(*DiskNode)
+	collect = func(n *DiskNode) {
+		if !n.IsDirectory && !n.IsLink && n.Size > 0 {
+			bySize[n.Size] = append(bySize[n.Size], n)
+			stats.Files++
+		}
+		for _, c := range n.Children {
+			collect(c)
+		}
+	}
+	collect(root)
+
+	var candidates [][]*DiskNode
+	for _, nodes := range bySize {
+		if nodes = distinctFiles(nodes); len(nodes) > 1 {
+			candidates = append(candidates, nodes)
+			stats.SameSize += len(nodes)
+		}
+	}
+
+	workers := a.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	head := refine(candidates, workers, &stats.HeadHashed, func(n *DiskNode) (string, error) {
+		return hashRange(n.Path, 0, hashBlock)
+	})
+
+	var groups []DuplicateGroup
+	var large [][]*DiskNode
+	for _, g := range head {
+		if g.files[0].Size <= hashBlock {
+			// The head hash covered the whole file.
+			groups = append(groups, DuplicateGroup{Size: g.files[0].Size, Hash: g.hash, Files: g.files})
+		} else {
+			large = append(large, g.files)
+		}
+	}
+
+	tail := refine(large, workers, &stats.TailHashed, func(n *DiskNode) (string, error) {
+		return hashRange(n.Path, n.Size-hashBlock, hashBlock)
+	})
+
+	var needFull [][]*DiskNode
+	for _, g := range tail {
+		needFull = append(needFull, g.files)
+	}
+
+	full := refine(needFull, workers, &stats.FullHashed, func(n *DiskNode) (string, error) {
+		return hashRange(n.Path, 0, -1)
+	})
+	for _, g := range full {
+		groups = append(groups, DuplicateGroup{Size: g.files[0].Size, Hash: g.hash, Files: g.files})
+	}
+
+	for _, g := range groups {
+		sort.Slice(g.Files, func(x, y int) bool { return g.Files[x].Path < g.Files[y].Path })
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Wasted() != groups[j].Wasted() {
+			return groups[i].Wasted() > groups[j].Wasted()
+		}
+		return groups[i].Files[0].Path < groups[j].Files[0].Path
+	})
+
+	return groups, stats
+}
+
+// hashGroup is a set of files that produced the same hash in a stage.
+type hashGroup struct {
+	hash  string
+	files []*DiskNode
+}
+
+// refine splits every group by the hash computed for each member, in
+// parallel, and keeps the sub-groups that still have two or more members.
+func refine(groups [][]*DiskNode, workers int, counter *int, key func(*DiskNode) (string, error)) []hashGroup {
+	type job struct {
+		group, index int
+	}
+
+	keys := make([][]string, len(groups))
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+
+	for g := range groups {
+		keys[g] = make([]string, len(groups[g]))
+		*counter += len(groups[g])
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				k, err := key(groups[j.group][j.index])
+				if err == nil {
+					keys[j.group][j.index] = k
+				}
+			}
+		}()
+	}
+
+	for g := range groups {
+		for i := range groups[g] {
+			jobs <- job{g, i}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var out []hashGroup
+	for g, nodes := range groups {
+		split := make(map[string][]*DiskNode)
+		var order []string
+		for i, n := range nodes {
+			k := keys[g][i]
+			if k == "" {
+				continue
+			}
+			if _, ok := split[k]; !ok {
+				order = append(order, k)
+			}
+			split[k] = append(split[k], n)
+		}
+		for _, k := range order {
+			if len(split[k]) > 1 {
+				out = append(out, hashGroup{hash: k, files: split[k]})
+			}
+		}
+	}
+	return out
+}
+
+// distinctFiles drops nodes that are hardlinks to a file already in nodes.
+func distinctFiles(nodes []*DiskNode) []*DiskNode {
+	var out []*DiskNode
+	var infos []os.FileInfo
+
+	for _, n := range nodes {
+		info, err := os.Stat(n.Path)
+		if err != nil {
+			continue
+		}
+		same := false
+		for _, seen := range infos {
+			if os.SameFile(seen, info) {
+				same = true
+				break
+			}
+		}
+		if !same {
+			out = append(out, n)
+			infos = append(infos, info)
+		}
+	}
+	return out
+}
+
+// hashRange returns the SHA-256 of length bytes of the file at path starting
+// at offset, or of everything from offset when length is negative.
+func hashRange(path string, offset, length int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+
+	var r io.Reader = f
+	if length >= 0 {
+		r = io.LimitReader(f, length)
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Keep strategies for choosing which copy of a duplicate survives.
+const (
+	KeepNewest   = "newest"
+	KeepOldest   = "oldest"
+	KeepPriority = "priority"
+)
+
+// ChooseKeeper splits a group into the file to keep and the copies to
+// remove. With KeepPriority the file under the earliest matching prefix in
+// priority is kept, falling back to the newest file; otherwise the newest or
+// oldest modification time wins. Ties go to the first path in sort order.
+func (g DuplicateGroup) ChooseKeeper(strategy string, priority []string) (keep *DiskNode, remove []*DiskNode, err error) {
+	if len(g.Files) == 0 {
+		return nil, nil, fmt.Errorf("empty duplicate group")
+	}
+
+	rank := func(n *DiskNode) int {
+		for i, prefix := range priority {
+			if pathHasPrefix(n.Path, prefix) {
+				return i
+			}
+		}
+		return len(priority)
+	}
+
+	var better func(a, b *DiskNode) bool
+	switch strategy {
+	case KeepNewest, "":
+		better = func(a, b *DiskNode) bool { return a.ModTime.After(b.ModTime) }
+	case KeepOldest:
+		better = func(a, b *DiskNode) bool { return a.ModTime.Before(b.ModTime) }
+	case KeepPriority:
+		better = func(a, b *DiskNode) bool {
+			ra, rb := rank(a), rank(b)
+			if ra != rb {
+				return ra < rb
+			}
+			return a.ModTime.After(b.ModTime)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown keep strategy %q (use %s, %s or %s)", strategy, KeepNewest, KeepOldest, KeepPriority)
+	}
+
+	keep = g.Files[0]
+	for _, f := range g.Files[1:] {
+		if better(f, keep) {
+			keep = f
+		}
+	}
+	for _, f := range g.Files {
+		if f != keep {
+			remove = append(remove, f)
+		}
+	}
+	return keep, remove, nil
+}
+
+// pathHasPrefix reports whether path is prefix or lies beneath it, ignoring case.
+func pathHasPrefix(path, prefix string) bool {
+	path = strings.ToLower(filepath.Clean(path))
+	prefix = strings.ToLower(filepath.Clean(prefix))
+	if path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(prefix, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// VerifyDuplicate checks that dup still has the size and content hash of its
+// group before it is acted on, in case it changed since the scan. It applies
+// to the copy that is kept as much as to those that are removed.
+func VerifyDuplicate(g DuplicateGroup, dup *DiskNode) error {
+	info, err := os.Stat(dup.Path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != g.Size {
+		return fmt.Errorf("%s changed size since the scan", dup.Path)
+	}
+	h, err := hashRange(dup.Path, 0, -1)
+	if err != nil {
+		return err
+	}
+	if h != g.Hash {
+		return fmt.Errorf("%s changed content since the scan", dup.Path)
+	}
+	return nil
+}
+
+// ReplaceWithHardlink replaces dup with a hardlink to keep. Both must be on
+// the same volume. The link is created beside dup under a name not yet in
+// use and renamed over it, so dup is never missing if linking fails and no
+// other file is touched.
+func ReplaceWithHardlink(keep, dup string) error {
+	var tmp string
+	for i := 0; ; i++ {
+		tmp = fmt.Sprintf("%s.burrow-link-%d", dup, i)
+		err := os.Link(keep, tmp)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("cannot link %s: %w", dup, err)
+		}
+	}
+	if err := os.Rename(tmp, dup); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("cannot replace %s: %w", dup, err)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeBytes(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pattern(n int, seed byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i%251) ^ seed
+	}
+	return b
+}
+
+func TestFindDuplicatesStages(t *testing.T) {
+	root := t.TempDir()
+	const size = 64 * 1024
+
+	base := pattern(size, 0)
+	writeBytes(t, filepath.Join(root, "a", "orig.bin"), base)
+	writeBytes(t, filepath.Join(root, "b", "copy.bin"), base)
+
+	// Same head as base, different tail.
+	tailDiff := pattern(size, 0)
+	tailDiff[size-1] ^= 0xff
+	writeBytes(t, filepath.Join(root, "tail.bin"), tailDiff)
+
+	// Same head and tail as base, different middle.
+	midDiff := pattern(size, 0)
+	midDiff[size/2] ^= 0xff
+	writeBytes(t, filepath.Join(root, "middle.bin"), midDiff)
+
+	// Small duplicates are settled by the head hash.
+	writeBytes(t, filepath.Join(root, "s1.txt"), []byte("same small"))
+	writeBytes(t, filepath.Join(root, "s2.txt"), []byte("same small"))
+
+	// A hardlink is the same file, not a duplicate.
+	if err := os.Link(filepath.Join(root, "s1.txt"), filepath.Join(root, "s1-link.txt")); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+
+	a := NewAnalyzer(false, true, 10, 0)
+	a.SetWorkers(4)
+	tree, err := a.AnalyzePath(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, stats := a.FindDuplicates(tree)
+	if len(groups) != 2 {
+		t.Fatalf("found %d groups, want 2: %+v", len(groups), groups)
+	}
+
+	big := groups[0]
+	if len(big.Files) != 2 || big.Size != size {
+		t.Errorf("largest group = %d files of %d bytes", len(big.Files), big.Size)
+	}
+	sum := sha256.Sum256(base)
+	if big.Hash != hex.EncodeToString(sum[:]) {
+		t.Error("group hash should be the SHA-256 of the full content")
+	}
+	if big.Wasted() != size {
+		t.Errorf("Wasted = %d, want %d", big.Wasted(), size)
+	}
+
+	if len(groups[1].Files) != 2 {
+		t.Errorf("small group has %d files, want 2 (hardlink counted once)", len(groups[1].Files))
+	}
+
+	if stats.HeadHashed != 6 || stats.TailHashed != 4 || stats.FullHashed != 3 {
+		t.Errorf("stats = %+v, want 6 head, 4 tail, 3 full hashes", stats)
+	}
+}
+
+func TestChooseKeeper(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sep := string(filepath.Separator)
+	g := DuplicateGroup{Files: []*DiskNode{
+		{Path: sep + filepath.Join("downloads", "x.iso"), ModTime: t0.Add(2 * time.Hour)},
+		{Path: sep + filepath.Join("archive", "x.iso"), ModTime: t0},
+		{Path: sep + filepath.Join("desktop", "x.iso"), ModTime: t0.Add(time.Hour)},
+	}}
+
+	tests := []struct {
+		strategy string
+		priority []string
+		want     string
+	}{
+		{KeepNewest, nil, "downloads"},
+		{KeepOldest, nil, "archive"},
+		{KeepPriority, []string{sep + "DESKTOP", sep + "archive"}, "desktop"},
+		{KeepPriority, []string{sep + "nowhere"}, "downloads"},
+	}
+
+	for _, tc := range tests {
+		keep, remove, err := g.ChooseKeeper(tc.strategy, tc.priority)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.strategy, err)
+		}
+		if filepath.Base(filepath.Dir(keep.Path)) != tc.want {
+			t.Errorf("%s %v kept %s, want %s", tc.strategy, tc.priority, keep.Path, tc.want)
+		}
+		if len(remove) != 2 {
+			t.Errorf("%s: %d files to remove, want 2", tc.strategy, len(remove))
+		}
+	}
+
+	if _, _, err := g.ChooseKeeper("largest", nil); err == nil {
+		t.Error("unknown strategy should be rejected")
+	}
+}
+
+func TestReplaceWithHardlink(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.bin")
+	dup := filepath.Join(dir, "dup.bin")
+	data := pattern(20000, 7)
+	writeBytes(t, keep, data)
+	writeBytes(t, dup, data)
+
+	sum := sha256.Sum256(data)
+	g := DuplicateGroup{Size: int64(len(data)), Hash: hex.EncodeToString(sum[:])}
+	if err := VerifyDuplicate(g, &DiskNode{Path: dup}); err != nil {
+		t.Fatalf("VerifyDuplicate error: %v", err)
+	}
+
+	if err := ReplaceWithHardlink(keep, dup); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+
+	ki, _ := os.Stat(keep)
+	di, _ := os.Stat(dup)
+	if !os.SameFile(ki, di) {
+		t.Error("dup should be a hardlink to keep")
+	}
+	if _, err := os.Stat(dup + ".burrow-link-0"); !os.IsNotExist(err) {
+		t.Error("temporary link left behind")
+	}
+
+	writeBytes(t, filepath.Join(dir, "changed.bin"), pattern(20000, 9))
+	if err := VerifyDuplicate(g, &DiskNode{Path: filepath.Join(dir, "changed.bin")}); err == nil {
+		t.Error("VerifyDuplicate should reject a file whose content changed")
+	}
+}
+
+func TestReplaceWithHardlinkKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.bin")
+	dup := filepath.Join(dir, "dup.bin")
+	data := pattern(20000, 5)
+	writeBytes(t, keep, data)
+	writeBytes(t, dup, data)
+
+	// Files that happen to use the temporary names must survive.
+	taken := []string{dup + ".burrow-link-0", dup + ".burrow-link-1"}
+	for _, p := range taken {
+		writeBytes(t, p, []byte("someone else's file"))
+	}
+
+	if err := ReplaceWithHardlink(keep, dup); err != nil {
+		t.Skipf("hardlinks not supported: %v", err)
+	}
+
+	ki, _ := os.Stat(keep)
+	di, _ := os.Stat(dup)
+	if !os.SameFile(ki, di) {
+		t.Error("dup should be a hardlink to keep")
+	}
+	for _, p := range taken {
+		if got, err := os.ReadFile(p); err != nil || string(got) != "someone else's file" {
+			t.Errorf("%s was changed or removed", filepath.Base(p))
+		}
+	}
+	if _, err := os.Stat(dup + ".burrow-link-2"); !os.IsNotExist(err) {
+		t.Error("temporary link left behind")
+	}
+}
+
+func TestVerifyDuplicateRejectsChangedKeeper(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.bin")
+	data := pattern(20000, 3)
+	writeBytes(t, keep, data)
+
+	sum := sha256.Sum256(data)
+	g := DuplicateGroup{Size: int64(len(data)), Hash: hex.EncodeToString(sum[:])}
+	keeper := &DiskNode{Path: keep}
+
+	// Edited in place after the scan: same size, different content.
+	writeBytes(t, keep, pattern(20000, 4))
+	if err := VerifyDuplicate(g, keeper); err == nil {
+		t.Error("VerifyDuplicate should reject a keeper edited since the scan")
+	}
+
+	os.Remove(keep)
+	if err := VerifyDuplicate(g, keeper); err == nil {
+		t.Error("VerifyDuplicate should reject a keeper deleted since the scan")
+	}
+}
@@ -0,0 +1,157 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Type groups reported by Breakdown. Files whose extension belongs to none of
+// them are grouped under TypeOther.
+const (
+	TypeVideo      = "video"
+	TypeImages     = "images"
+	TypeArchives   = "archives"
+	TypeInstallers = "installers"
+	TypeVMDisks    = "vm-disks"
+	TypeISO        = "iso"
+	TypeLogs       = "logs"
+	TypeSource     = "source"
+	TypeOther      = "other"
+)
+
+// TypeGroups lists the named type groups, excluding TypeOther.
+var TypeGroups = []string{
+	TypeVideo, TypeImages, TypeArchives, TypeInstallers,
+	TypeVMDisks, TypeISO, TypeLogs, TypeSource,
+}
+
+var groupExtensions = map[string][]string{
+	TypeVideo:      {".mp4", ".mkv", ".avi", ".mov", ".wmv", ".webm", ".m4v", ".flv", ".mpg", ".mpeg"},
+	TypeImages:     {".jpg", ".jpeg", ".png", ".gif", ".bmp", ".tif", ".tiff", ".webp", ".heic", ".raw", ".cr2", ".nef", ".psd", ".svg"},
+	TypeArchives:   {".zip", ".7z", ".rar", ".tar", ".gz", ".tgz", ".bz2", ".xz", ".zst", ".cab"},
+	TypeInstallers: {".exe", ".msi", ".msix", ".msixbundle", ".appx", ".appxbundle", ".msp"},
+	TypeVMDisks:    {".vhd", ".vhdx", ".vmdk", ".vdi", ".qcow2", ".avhdx"},
+	TypeISO:        {".iso", ".img"},
+	TypeLogs:       {".log", ".etl", ".evtx", ".dmp", ".trace"},
+	TypeSource: {".go", ".c", ".h", ".cpp", ".hpp", ".cc", ".cs", ".java", ".js", ".ts", ".tsx", ".jsx",
+		".py", ".rs", ".rb", ".php", ".swift", ".kt", ".ps1", ".sh", ".sql"},
+}
+
+// extensionGroup maps a lower-case extension to its type group. Each
+// extension belongs to one group only; .ts, for instance, is TypeScript
+// source far more often than an MPEG transport stream.
+var extensionGroup = func() map[string]string {
+	m := make(map[string]string)
+	for _, group := range TypeGroups {
+		for _, ext := range groupExtensions[group] {
+			m[ext] = group
+		}
+	}
+	return m
+}()
+
+// IsTypeGroup reports whether name is one of TypeGroups or TypeOther.
+func IsTypeGroup(name string) bool {
+	if name == TypeOther {
+		return true
+	}
+	_, ok := groupExtensions[name]
+	return ok
+}
+
+// Extension returns the lower-case extension of name including the dot, or
+// "" if it has none.
+func Extension(name string) string {
+	return strings.ToLower(filepath.Ext(name))
+}
+
+// TypeGroup returns the type group of a file name.
+func TypeGroup(name string) string {
+	if group, ok := extensionGroup[Extension(name)]; ok {
+		return group
+	}
+	return TypeOther
+}
+
+// TypeStats totals the files of one extension or type group.
+type TypeStats struct {
+	Name  string
+	Size  int64
+	Count int
+}
+
+// Breakdown totals the files under root by extension and by type group, each
+// ranked by size. Directories summarized beyond the analyzer depth cannot be
+// broken down and are left out.
+func Breakdown(root *DiskNode) (byExt, byGroup []TypeStats) {
+	exts := make(map[string]*TypeStats)
+	groups := make(map[string]*TypeStats)
+
+	add := func(m map[string]*TypeStats, key string, size int64) {
+		s, ok := m[key]
+		if !ok {
+			s = &TypeStats{Name: key}
+			m[key] = s
+		}
+		s.Size += size
+		s.Count++
+	}
+
+	walkFiles(root, func(n *DiskNode) {
+		add(exts, Extension(n.Name), n.Size)
+		add(groups, TypeGroup(n.Name), n.Size)
+	})
+
+	return rankTypes(exts), rankTypes(groups)
+}
+
+func rankTypes(m map[string]*TypeStats) []TypeStats {
+	stats := make([]TypeStats, 0, len(m))
+	for _, s := range m {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Size != stats[j].Size {
+			return stats[i].Size > stats[j].Size
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// FilesOfType returns the files under root of at least minSize bytes that
+// belong to kind, largest first. kind is a type group or an extension such as
+// ".iso".
+func FilesOfType(root *DiskNode, kind string, minSize int64) []*DiskNode {
+	kind = strings.ToLower(kind)
+	match := func(name string) bool { return TypeGroup(name) == kind }
+	if strings.HasPrefix(kind, ".") {
+		match = func(name string) bool { return Extension(name) == kind }
+	}
+
+	var files []*DiskNode
+	walkFiles(root, func(n *DiskNode) {
+		if n.Size >= minSize && match(n.Name) {
+			files = append(files, n)
+		}
+	})
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Size > files[j].Size
+	})
+	return files
+}
+
+// walkFiles calls fn for every file node under node. Links are skipped.
+func walkFiles(node *DiskNode, fn func(*DiskNode)) {
+	if node.IsDirectory {
+		for _, child := range node.Children {
+			walkFiles(child, fn)
+		}
+		return
+	}
+	if !node.IsLink {
+		fn(node)
+	}
+}
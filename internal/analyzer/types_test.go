@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"testing"
+)
+
+func TestTypeGroup(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"holiday.MKV", TypeVideo},
+		{"scan.jpeg", TypeImages},
+		{"backup.tar.gz", TypeArchives},
+		{"setup.msi", TypeInstallers},
+		{"ubuntu.vhdx", TypeVMDisks},
+		{"win11.iso", TypeISO},
+		{"CBS.log", TypeLogs},
+		{"main.go", TypeSource},
+		{"index.ts", TypeSource},
+		{"notes.txt", TypeOther},
+		{"Makefile", TypeOther},
+	}
+
+	for _, tt := range tests {
+		if got := TypeGroup(tt.name); got != tt.want {
+			t.Errorf("TypeGroup(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtensionsBelongToOneGroup(t *testing.T) {
+	seen := make(map[string]string)
+	for _, group := range TypeGroups {
+		for _, ext := range groupExtensions[group] {
+			if other, ok := seen[ext]; ok {
+				t.Errorf("%s is in both %s and %s", ext, other, group)
+			}
+			seen[ext] = group
+		}
+	}
+}
+
+func TestBreakdownAndFilesOfType(t *testing.T) {
+	file := func(name string, size int64) *DiskNode {
+		return &DiskNode{Name: name, Path: "/r/" + name, Size: size, ItemCount: 1}
+	}
+	movie := file("movie.mp4", 900)
+	clip := file("clip.MP4", 100)
+	disk := file("vm.vhdx", 700)
+	link := &DiskNode{Name: "link.mp4", Path: "/r/link.mp4", IsLink: true}
+	root := &DiskNode{Name: "r", IsDirectory: true, Children: []*DiskNode{
+		movie, disk,
+		{Name: "sub", IsDirectory: true, Children: []*DiskNode{clip, file("a.txt", 5), link}},
+	}}
+
+	byExt, byGroup := Breakdown(root)
+
+	wantGroups := []TypeStats{
+		{Name: TypeVideo, Size: 1000, Count: 2},
+		{Name: TypeVMDisks, Size: 700, Count: 1},
+		{Name: TypeOther, Size: 5, Count: 1},
+	}
+	if len(byGroup) != len(wantGroups) {
+		t.Fatalf("byGroup = %+v, want %+v", byGroup, wantGroups)
+	}
+	for i := range wantGroups {
+		if byGroup[i] != wantGroups[i] {
+			t.Errorf("byGroup[%d] = %+v, want %+v", i, byGroup[i], wantGroups[i])
+		}
+	}
+
+	if byExt[0] != (TypeStats{Name: ".mp4", Size: 1000, Count: 2}) {
+		t.Errorf("byExt[0] = %+v", byExt[0])
+	}
+
+	videos := FilesOfType(root, "video", 500)
+	if len(videos) != 1 || videos[0] != movie {
+		t.Errorf("FilesOfType(video, 500) = %v", videos)
+	}
+	if got := FilesOfType(root, ".VHDX", 0); len(got) != 1 || got[0] != disk {
+		t.Errorf("FilesOfType(.VHDX) = %v", got)
+	}
+}
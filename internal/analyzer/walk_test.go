@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// generateTree creates dirs*dirs leaf directories under root with filesPer
+// small files each, returning the number of files created.
+func generateTree(tb testing.TB, root string, dirs, filesPer int) int {
+	tb.Helper()
+	data := make([]byte, 10)
+	count := 0
+
+	for i := 0; i < dirs; i++ {
+		for j := 0; j < dirs; j++ {
+			leaf := filepath.Join(root, fmt.Sprintf("d%03d", i), fmt.Sprintf("s%03d", j))
+			if err := os.MkdirAll(leaf, 0o755); err != nil {
+				tb.Fatal(err)
+			}
+			for k := 0; k < filesPer; k++ {
+				if err := os.WriteFile(filepath.Join(leaf, fmt.Sprintf("f%04d", k)), data, 0o644); err != nil {
+					tb.Fatal(err)
+				}
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func sameTree(t *testing.T, a, b *DiskNode) {
+	t.Helper()
+	if a.Path != b.Path || a.Size != b.Size || a.ItemCount != b.ItemCount || a.LargeFiles != b.LargeFiles {
+		t.Fatalf("node mismatch:\n  %+v\n  %+v", a, b)
+	}
+	if len(a.Children) != len(b.Children) {
+		t.Fatalf("%s: %d children vs %d", a.Path, len(a.Children), len(b.Children))
+	}
+	for i := range a.Children {
+		sameTree(t, a.Children[i], b.Children[i])
+	}
+}
+
+func TestParallelWalkMatchesSequential(t *testing.T) {
+	root := t.TempDir()
+	files := generateTree(t, root, 6, 20)
+
+	for _, depth := range []int{1, 2, 5} {
+		seq := NewAnalyzer(false, true, depth, 0)
+		seq.SetWorkers(1)
+		want, err := seq.AnalyzePath(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		par := NewAnalyzer(false, true, depth, 0)
+		par.SetWorkers(8)
+		got, err := par.AnalyzePath(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want.ItemCount != files || want.Size != int64(files*10) {
+			t.Errorf("depth %d: ItemCount = %d, Size = %d, want %d files of 10 bytes",
+				depth, want.ItemCount, want.Size, files)
+		}
+		sameTree(t, want, got)
+	}
+}
+
+// BenchmarkAnalyzePath scans a generated tree of about one million files
+// (100*100 directories of 100 files each). Generating the tree takes a while
+// and needs about a million free inodes, so run it explicitly:
+//
+//	go test -run XXX -bench AnalyzePath -benchtime 3x ./internal/analyzer
+func BenchmarkAnalyzePath(b *testing.B) {
+	root := b.TempDir()
+	files := generateTree(b, root, 100, 100)
+	b.Logf("generated %d files", files)
+
+	for _, workers := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				a := NewAnalyzer(false, true, 3, 0)
+				a.SetWorkers(workers)
+				tree, err := a.AnalyzePath(root)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if tree.ItemCount != files {
+					b.Fatalf("ItemCount = %d, want %d", tree.ItemCount, files)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package cleanup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/zs0c131y/burrow/internal/quarantine"
+	"github.com/zs0c131y/burrow/internal/trash"
+	"github.com/zs0c131y/burrow/pkg/models"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// candidateListVersion is the schema version written by WriteCandidates.
+const candidateListVersion = 1
+
+// Candidate is a file proposed for deletion, as it was when it was found.
+type Candidate struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// CandidateList is a set of files proposed for deletion by another command,
+// such as the stale-file report of 'wm analyze'.
+type CandidateList struct {
+	Version   int         `json:"version"`
+	Source    string      `json:"source"`
+	Generated time.Time   `json:"generated"`
+	Items     []Candidate `json:"items"`
+}
+
+// TotalSize returns the combined size of all candidates.
+func (l *CandidateList) TotalSize() int64 {
+	var total int64
+	for _, c := range l.Items {
+		total += c.Size
+	}
+	return total
+}
+
+// ErrCandidateChanged is returned by Candidate.Check for a file that no longer
+// matches the size or modification time it was listed with.
+var ErrCandidateChanged = errors.New("file changed since it was listed")
+
+// Check reports whether the file is still present and unchanged.
+func (c Candidate) Check() error {
+	info, err := os.Lstat(c.Path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is now a directory", c.Path)
+	}
+	if info.Size() != c.Size || !info.ModTime().Equal(c.ModTime) {
+		return ErrCandidateChanged
+	}
+	return nil
+}
+
+// WriteCandidates saves list to path as JSON.
+func WriteCandidates(path string, list *CandidateList) error {
+	list.Version = candidateListVersion
+	if list.Items == nil {
+		list.Items = []Candidate{}
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode candidate list: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write candidate list: %w", err)
+	}
+	return nil
+}
+
+// ReadCandidates loads a candidate list written by WriteCandidates.
+func ReadCandidates(path string) (*CandidateList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read candidate list: %w", err)
+	}
+
+	var list CandidateList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse candidate list: %w", err)
+	}
+	if list.Version > candidateListVersion {
+		return nil, fmt.Errorf("candidate list version %d is newer than supported (%d)", list.Version, candidateListVersion)
+	}
+	return &list, nil
+}
+
+// CleanCandidates deletes the files in list as a single cleanup target, or
+// quarantines or trashes them according to the manager's disposal.
+// Whitelisted files and files that changed since they were listed are
+// skipped and counted as protected.
+func (cm *CleanupManager) CleanCandidates(list *CandidateList, name string) *CleanupSummary {
+	target := &models.CleanupTarget{
+		Name:        name,
+		Description: "Deletion candidates from " + list.Source,
+		Size:        list.TotalSize(),
+		ItemCount:   len(list.Items),
+	}
+	result := &CleanupResult{Target: target, Success: true}
+
+	var failed int
+	for _, c := range list.Items {
+		if cm.isProtected(c.Path) {
+			result.FilesProtected++
+			cm.record(result, cm.disposal, c.Path, c.Size, errProtected)
+			continue
+		}
+		if err := c.Check(); err != nil {
+			if !os.IsNotExist(err) {
+				result.FilesProtected++
+			}
+			cm.record(result, cm.disposal, c.Path, c.Size, err)
+			continue
+		}
+
+		if cm.dryRun {
+			result.SpaceFreed += c.Size
+			result.FilesRemoved++
+			cm.record(result, cm.disposal, c.Path, c.Size, nil)
+			continue
+		}
+
+		err := cm.dispose(c.Path)
+		cm.record(result, cm.disposal, c.Path, c.Size, err)
+		if err != nil {
+			failed++
+			continue
+		}
+		result.SpaceFreed += c.Size
+		result.FilesRemoved++
+	}
+
+	if failed > 0 && result.FilesRemoved == 0 {
+		result.Success = false
+		result.Error = fmt.Errorf("%d files skipped (%s)", failed, DescribeSkipped(result.Skipped))
+	}
+
+	summary := &CleanupSummary{
+		TotalTargets:      1,
+		TotalSpaceFreed:   result.SpaceFreed,
+		TotalFilesRemoved: result.FilesRemoved,
+		TotalProtected:    result.FilesProtected,
+		Results:           []*CleanupResult{result},
+	}
+	summary.addSkipped(result)
+	if result.Success {
+		summary.SuccessfulCleans = 1
+	} else {
+		summary.FailedCleans = 1
+	}
+	cm.findHolders(summary)
+	return summary
+}
+
+// dispose removes one verified candidate according to the manager's disposal.
+func (cm *CleanupManager) dispose(path string) error {
+	switch cm.disposal {
+	case DisposeQuarantine:
+		_, err := quarantine.Move(path)
+		return err
+	case DisposeTrash:
+		return trash.Move(path)
+	}
+	return utils.SafeDelete(path, cm.retries)
+}
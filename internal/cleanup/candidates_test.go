@@ -0,0 +1,90 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+func TestCleanCandidates(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	write := func(name string, size int) Candidate {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		old := time.Now().AddDate(-1, 0, 0)
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Candidate{Path: path, Size: info.Size(), ModTime: info.ModTime()}
+	}
+
+	stale := write("stale.bin", 100)
+	changed := write("changed.bin", 200)
+	kept := write("report.keep", 300)
+	gone := write("gone.bin", 400)
+
+	listPath := filepath.Join(tmpDir, "candidates.json")
+	err := WriteCandidates(listPath, &CandidateList{
+		Source: "test",
+		Items:  []Candidate{stale, changed, kept, gone},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := ReadCandidates(listPath)
+	if err != nil {
+		t.Fatalf("ReadCandidates error: %v", err)
+	}
+	if len(list.Items) != 4 || list.TotalSize() != 1000 {
+		t.Fatalf("read back %d items of %d bytes", len(list.Items), list.TotalSize())
+	}
+
+	if err := os.WriteFile(changed.Path, make([]byte, 250), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(gone.Path); err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &CleanupManager{
+		retries:   1,
+		whitelist: map[string]bool{"*.keep": true},
+	}
+	summary := cm.CleanCandidates(list, "Stale files")
+
+	if summary.TotalFilesRemoved != 1 || summary.TotalSpaceFreed != 100 {
+		t.Errorf("removed %d files / %d bytes, want 1 / 100", summary.TotalFilesRemoved, summary.TotalSpaceFreed)
+	}
+	if summary.TotalProtected != 2 {
+		t.Errorf("TotalProtected = %d, want 2 (whitelisted and changed)", summary.TotalProtected)
+	}
+	if utils.PathExists(stale.Path) {
+		t.Error("unchanged candidate was not deleted")
+	}
+	if !utils.PathExists(changed.Path) || !utils.PathExists(kept.Path) {
+		t.Error("changed or whitelisted candidate was deleted")
+	}
+}
+
+func TestReadCandidatesRejectsNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "candidates.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "items": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadCandidates(path); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected version error, got %v", err)
+	}
+}
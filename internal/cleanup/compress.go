@@ -0,0 +1,447 @@
+package cleanup
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/zs0c131y/burrow/pkg/models"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// Archive formats for compressed log files.
+const (
+	CompressGzip = "gzip"
+	CompressZip  = "zip"
+)
+
+// CompressOptions controls how log targets are compressed instead of deleted.
+type CompressOptions struct {
+	Format string        // CompressGzip or CompressZip
+	MinAge time.Duration // only files last modified longer ago are compressed
+	Dir    string        // archive folder; empty keeps archives next to the originals
+}
+
+// SetLogCompression makes log targets compress their old files instead of
+// deleting them. Each original is removed only after its archive has been
+// read back and matched against it.
+func (cm *CleanupManager) SetLogCompression(opts CompressOptions) {
+	if opts.Format != CompressZip {
+		opts.Format = CompressGzip
+	}
+	cm.compress = &opts
+}
+
+// logAction returns the action for log targets.
+func (cm *CleanupManager) logAction() models.CleanupAction {
+	if cm.compress != nil {
+		return models.ActionCompress
+	}
+	return models.ActionDelete
+}
+
+// archiveExt returns the extension of archives in the configured format.
+func (cm *CleanupManager) archiveExt() string {
+	if cm.compress.Format == CompressZip {
+		return ".zip"
+	}
+	return ".gz"
+}
+
+// compressible returns the files under root that are due to be compressed,
+// and the files the whitelist protected. Links, archives and files modified
+// within the minimum age are left alone.
+func (cm *CleanupManager) compressible(root string) (files, protected []string, err error) {
+	cutoff := time.Now().Add(-cm.compress.MinAge)
+	archiveDir := filepath.Clean(cm.compress.Dir)
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		if cm.isProtected(path) {
+			// Everything beneath a protected folder is protected too;
+			// walk it only to count its files.
+			if !d.IsDir() {
+				protected = append(protected, path)
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || utils.IsLink(path, info) {
+			return nil
+		}
+		if d.IsDir() {
+			if cm.compress.Dir != "" && strings.EqualFold(filepath.Clean(path), archiveDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if isArchive(path) || info.ModTime().After(cutoff) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, protected, err
+}
+
+func isArchive(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".zip", ".7z", ".cab", ".partial":
+		return true
+	}
+	return false
+}
+
+// sizeCompressTarget fills in the size and count of the files a compress
+// target would compress.
+func (cm *CleanupManager) sizeCompressTarget(target *models.CleanupTarget) error {
+	files, protected, err := cm.compressible(target.Path)
+	if err != nil {
+		return err
+	}
+	target.Size = 0
+	target.BlockedSize, target.BlockedItems = 0, 0
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		target.Size += info.Size()
+		if cm.blocked(path) {
+			target.BlockedSize += info.Size()
+			target.BlockedItems++
+		}
+	}
+	target.ItemCount = len(files)
+	target.ProtectedItems = len(protected)
+	return nil
+}
+
+// compressTarget replaces the due files of target with archives.
+func (cm *CleanupManager) compressTarget(target *models.CleanupTarget) *CleanupResult {
+	result := &CleanupResult{Target: target, Success: true}
+
+	files, protected, err := cm.compressible(target.Path)
+	if err != nil {
+		result.Success = false
+		result.Error = err
+		return result
+	}
+	result.FilesProtected = len(protected)
+	for _, path := range protected {
+		cm.record(result, string(models.ActionCompress), path, 0, errProtected)
+	}
+
+	if cm.dryRun {
+		for _, path := range files {
+			size, archived, err := estimateArchive(path, cm.compress.Format)
+			if err == nil && archived >= size {
+				err = errNotSmaller
+			}
+			cm.record(result, string(models.ActionCompress), path, size, err)
+			if err != nil {
+				continue
+			}
+			result.SpaceCompressed += size - archived
+			result.FilesCompressed++
+		}
+		return result
+	}
+
+	var failed int
+	for _, path := range files {
+		if cm.stopped() {
+			break
+		}
+		size, saved, err := cm.compressLog(target.Path, path)
+		cm.record(result, string(models.ActionCompress), path, size, err)
+		if errors.Is(err, errNotSmaller) {
+			continue
+		}
+		if err != nil {
+			failed++
+			if cm.debug {
+				color.Yellow("\n  Warning: cannot compress %s: %v", path, err)
+			}
+			continue
+		}
+		result.SpaceCompressed += saved
+		result.FilesCompressed++
+	}
+
+	if failed > 0 && result.FilesCompressed == 0 {
+		result.Success = false
+		result.Error = fmt.Errorf("%d files skipped (%s)", failed, DescribeSkipped(result.Skipped))
+	}
+	return result
+}
+
+// errChangedWhileCompressing is returned for a log that was written to while
+// its archive was being made.
+var errChangedWhileCompressing = errors.New("file changed while it was being compressed")
+
+// errNotSmaller is returned for a log whose archive would take no less space
+// than the log itself.
+var errNotSmaller = errors.New("archive is not smaller than the file")
+
+// compressLog archives the file at path, found under root, and removes the
+// original. It returns the size of the file and the bytes reclaimed. A file
+// whose estimated archive is not smaller is left alone without writing one,
+// so logs that do not compress cost little on every later run. The archive
+// is discarded and the original kept if it turns out not smaller after all,
+// or if the file changed while it was being compressed or cannot be removed.
+func (cm *CleanupManager) compressLog(root, path string) (size, saved int64, err error) {
+	before, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	size = before.Size()
+
+	if _, estimate, err := estimateArchive(path, cm.compress.Format); err == nil && estimate >= size {
+		return size, 0, errNotSmaller
+	}
+
+	dst, err := cm.archivePath(root, path, before.ModTime())
+	if err != nil {
+		return size, 0, err
+	}
+	archived, err := compressFile(path, dst, cm.compress.Format)
+	if err != nil {
+		return size, 0, err
+	}
+	if archived >= size {
+		os.Remove(dst)
+		return size, 0, errNotSmaller
+	}
+
+	after, err := os.Stat(path)
+	if err != nil || after.Size() != before.Size() || !after.ModTime().Equal(before.ModTime()) {
+		os.Remove(dst)
+		return size, 0, errChangedWhileCompressing
+	}
+	if err := utils.SafeDelete(path, cm.retries); err != nil {
+		os.Remove(dst)
+		return size, 0, err
+	}
+	return size, size - archived, nil
+}
+
+// archivePath returns a free name for the archive of path: next to it, or
+// under the archive folder in a subfolder named after root. An existing
+// archive from an earlier run is never overwritten; the file's modification
+// time is added to the name instead.
+func (cm *CleanupManager) archivePath(root, path string, modTime time.Time) (string, error) {
+	base := path
+	if cm.compress.Dir != "" {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(cm.compress.Dir, filepath.Base(root), rel)
+		if err := os.MkdirAll(filepath.Dir(base), 0o755); err != nil {
+			return "", fmt.Errorf("cannot create archive folder: %w", err)
+		}
+	}
+
+	ext := cm.archiveExt()
+	candidates := []string{
+		base + ext,
+		base + "." + modTime.Format("20060102-150405") + ext,
+	}
+	for _, name := range candidates {
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name, nil
+		}
+	}
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s.%s.%d%s", base, modTime.Format("20060102-150405"), i, ext)
+		if _, err := os.Lstat(name); os.IsNotExist(err) {
+			return name, nil
+		}
+	}
+}
+
+// compressFile writes src to a new archive at dst in format, reads it back
+// to check it holds exactly the bytes of src, and returns its size. dst is
+// only created once the archive has been verified, and both the archive and
+// its folder are flushed to disk before compressFile returns, so the original
+// is never removed while its archive could still be lost in a crash.
+func compressFile(src, dst, format string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	tmp := dst + ".partial"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create archive: %w", err)
+	}
+
+	sum := sha256.New()
+	err = writeArchive(out, io.TeeReader(in, sum), filepath.Base(src), info.ModTime(), format)
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err == nil {
+		err = verifyArchive(tmp, format, sum.Sum(nil))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("cannot compress: %w", err)
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("cannot compress: %w", err)
+	}
+	_ = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	if err := utils.SyncDir(filepath.Dir(dst)); err != nil {
+		os.Remove(dst)
+		return 0, fmt.Errorf("cannot compress: %w", err)
+	}
+
+	archived, err := os.Stat(dst)
+	if err != nil {
+		return 0, err
+	}
+	return archived.Size(), nil
+}
+
+// compressSample is how much of a file estimateArchive compresses.
+const compressSample = 1 << 20
+
+// estimateArchive returns the size of the file at path and an estimate of
+// the size of its archive in format. The first compressSample bytes are
+// compressed and discarded; for larger files the result is scaled up.
+func estimateArchive(path, format string) (size, archived int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	size = info.Size()
+
+	sample := &io.LimitedReader{R: f, N: compressSample}
+	var out countingWriter
+	if err := writeArchive(&out, sample, filepath.Base(path), info.ModTime(), format); err != nil {
+		return size, 0, err
+	}
+	if read := compressSample - sample.N; read > 0 && read < size {
+		return size, out.n * size / read, nil
+	}
+	return size, out.n, nil
+}
+
+// countingWriter discards what is written to it and counts the bytes.
+type countingWriter struct{ n int64 }
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+func writeArchive(w io.Writer, r io.Reader, name string, modTime time.Time, format string) error {
+	if format == CompressZip {
+		zw := zip.NewWriter(w)
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime})
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(fw, r); err != nil {
+			return err
+		}
+		return zw.Close()
+	}
+
+	gw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	gw.Name = name
+	gw.ModTime = modTime
+	if _, err := io.Copy(gw, r); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// verifyArchive decompresses the archive at path and compares the SHA-256 of
+// its content with want. The gzip and zip readers also check their CRC-32.
+func verifyArchive(path, format string, want []byte) error {
+	sum := sha256.New()
+
+	if format == CompressZip {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		if len(zr.File) != 1 {
+			return fmt.Errorf("archive holds %d entries, want 1", len(zr.File))
+		}
+		rc, err := zr.File[0].Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if _, err := io.Copy(sum, rc); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(sum, gr); err != nil {
+			return err
+		}
+		if err := gr.Close(); err != nil {
+			return err
+		}
+	}
+
+	if !bytes.Equal(sum.Sum(nil), want) {
+		return fmt.Errorf("archive does not match the original")
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package cleanup
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/models"
+)
+
+func writeLog(t *testing.T, path, content string, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readArchive(t *testing.T, path, format string) string {
+	t.Helper()
+	var r io.Reader
+	if format == CompressZip {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		rc, err := zr.File[0].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		r = rc
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gr
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCompressTarget(t *testing.T) {
+	day := 24 * time.Hour
+	oldLog := strings.Repeat("2026-01-01 Info CBS Loaded servicing stack\n", 200)
+
+	tests := []struct {
+		format  string
+		archive string // archive folder, relative to the test dir; "" compresses in place
+		want    string // archive of old.log, relative to the test dir
+	}{
+		{CompressGzip, "", "Logs/CBS/old.log.gz"},
+		{CompressZip, "", "Logs/CBS/old.log.zip"},
+		{CompressGzip, "archive", "archive/Logs/CBS/old.log.gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+"/"+tt.archive, func(t *testing.T) {
+			dir := t.TempDir()
+			root := filepath.Join(dir, "Logs")
+			writeLog(t, filepath.Join(root, "CBS", "old.log"), oldLog, 40*day)
+			writeLog(t, filepath.Join(root, "CBS", "new.log"), "recent\n", day)
+			writeLog(t, filepath.Join(root, "CBS", "older.log.gz"), "already compressed", 90*day)
+			writeLog(t, filepath.Join(root, "keep", "audit.log"), "kept for compliance", 90*day)
+			writeLog(t, filepath.Join(root, "keep", "2025", "audit.log"), "kept for compliance", 90*day)
+
+			cm := &CleanupManager{retries: 1, whitelist: map[string]bool{filepath.Join(root, "keep"): true}}
+			opts := CompressOptions{Format: tt.format, MinAge: 30 * day}
+			if tt.archive != "" {
+				opts.Dir = filepath.Join(dir, tt.archive)
+			}
+			cm.SetLogCompression(opts)
+
+			target := &models.CleanupTarget{Name: "Windows Logs", Path: root, Category: models.CategoryLogs, Action: cm.logAction()}
+			if err := cm.sizeCompressTarget(target); err != nil {
+				t.Fatalf("sizeCompressTarget error: %v", err)
+			}
+			if target.Size != int64(len(oldLog)) || target.ItemCount != 1 || target.ProtectedItems != 2 {
+				t.Errorf("target = %d bytes, %d items, %d protected", target.Size, target.ItemCount, target.ProtectedItems)
+			}
+
+			result := cm.cleanTarget(target)
+			if !result.Success || result.FilesCompressed != 1 || result.FilesRemoved != 0 || result.SpaceFreed != 0 {
+				t.Fatalf("cleanTarget = %+v", result)
+			}
+
+			archive := filepath.Join(dir, filepath.FromSlash(tt.want))
+			info, err := os.Stat(archive)
+			if err != nil {
+				t.Fatalf("archive missing: %v", err)
+			}
+			if result.SpaceCompressed != int64(len(oldLog))-info.Size() || result.SpaceCompressed <= 0 {
+				t.Errorf("SpaceCompressed = %d, archive is %d of %d bytes", result.SpaceCompressed, info.Size(), len(oldLog))
+			}
+			if got := readArchive(t, archive, tt.format); got != oldLog {
+				t.Errorf("archive content differs from the original")
+			}
+
+			if _, err := os.Stat(filepath.Join(root, "CBS", "old.log")); !os.IsNotExist(err) {
+				t.Error("original not removed after compression")
+			}
+			for _, kept := range []string{"CBS/new.log", "CBS/older.log.gz", "keep/audit.log"} {
+				if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(kept))); err != nil {
+					t.Errorf("%s was touched: %v", kept, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressKeepsEarlierArchive(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "setupact.log")
+	second := strings.Repeat("second run\n", 50)
+	writeLog(t, path, second, 48*time.Hour)
+	writeLog(t, path+".gz", "from an earlier run", 72*time.Hour)
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{}}
+	cm.SetLogCompression(CompressOptions{})
+	result := cm.compressTarget(&models.CleanupTarget{Path: root, Action: models.ActionCompress})
+	if result.FilesCompressed != 1 {
+		t.Fatalf("compressTarget = %+v", result)
+	}
+
+	if data, _ := os.ReadFile(path + ".gz"); string(data) != "from an earlier run" {
+		t.Error("earlier archive was overwritten")
+	}
+	matches, _ := filepath.Glob(filepath.Join(root, "setupact.log.*-*.gz"))
+	if len(matches) != 1 || readArchive(t, matches[0], CompressGzip) != second {
+		t.Errorf("new archive not found beside the earlier one: %v", matches)
+	}
+}
+
+func TestCompressKeepsIncompressibleFile(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "tiny.log")
+	writeLog(t, path, "ok\n", 48*time.Hour)
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{}}
+	cm.SetLogCompression(CompressOptions{})
+	result := cm.compressTarget(&models.CleanupTarget{Path: root, Action: models.ActionCompress})
+	if !result.Success || result.FilesCompressed != 0 || result.SpaceCompressed != 0 || result.Skipped[ReasonNoSaving] != 1 {
+		t.Fatalf("compressTarget = %+v", result)
+	}
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != "ok\n" {
+		t.Errorf("original not kept: %q, %v", data, err)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 1 {
+		t.Errorf("archive left behind: %d entries", len(entries))
+	}
+}
+
+func TestCompressDryRun(t *testing.T) {
+	root := t.TempDir()
+	content := strings.Repeat("2025-01-01 Info CBS Session initialized\n", 250)
+	writeLog(t, filepath.Join(root, "a.log"), content, 10*24*time.Hour)
+	writeLog(t, filepath.Join(root, "tiny.log"), "ok\n", 10*24*time.Hour)
+
+	cm := &CleanupManager{dryRun: true, retries: 1, whitelist: map[string]bool{}}
+	cm.SetLogCompression(CompressOptions{Format: CompressZip})
+	result := cm.cleanTarget(&models.CleanupTarget{Path: root, Action: models.ActionCompress})
+	if result.FilesCompressed != 1 || result.Skipped[ReasonNoSaving] != 1 {
+		t.Errorf("dry run = %+v, want 1 file compressed and 1 incompressible", result)
+	}
+	if result.SpaceCompressed <= 0 || result.SpaceCompressed >= int64(len(content)) {
+		t.Errorf("dry run estimated %d bytes reclaimed from %d", result.SpaceCompressed, len(content))
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 2 {
+		t.Errorf("dry run changed the folder: %d entries", len(entries))
+	}
+}
+
+func TestEstimateArchive(t *testing.T) {
+	root := t.TempDir()
+
+	text := filepath.Join(root, "text.log")
+	writeLog(t, text, strings.Repeat("2025-01-01 Info CBS Session initialized\n", 80000), 0)
+
+	noise := make([]byte, 3*compressSample)
+	rand.New(rand.NewSource(1)).Read(noise)
+	random := filepath.Join(root, "random.log")
+	writeLog(t, random, string(noise), 0)
+
+	for _, format := range []string{CompressGzip, CompressZip} {
+		size, archived, err := estimateArchive(text, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if archived <= 0 || archived > size/10 {
+			t.Errorf("%s: text estimate = %d of %d bytes, want well under a tenth", format, archived, size)
+		}
+
+		size, archived, err = estimateArchive(random, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if archived < size {
+			t.Errorf("%s: random estimate = %d of %d bytes, want no saving", format, archived, size)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cleanup
+
+import (
+	"github.com/zs0c131y/burrow/internal/locks"
+)
+
+// LockedFile is a file a cleanup could not remove because another process
+// had it open.
+type LockedFile struct {
+	Path    string
+	Size    int64
+	Holders []locks.Process
+}
+
+// LockedFiles returns the locked files of every result in the summary.
+func (s *CleanupSummary) LockedFiles() []LockedFile {
+	var files []LockedFile
+	for _, r := range s.Results {
+		files = append(files, r.Locked...)
+	}
+	return files
+}
+
+// findHolders looks up the processes holding the locked files of summary
+// and adds them to the results and to the manifest. Lookups that fail leave
+// the holders empty.
+func (cm *CleanupManager) findHolders(summary *CleanupSummary) {
+	var paths []string
+	for _, f := range summary.LockedFiles() {
+		paths = append(paths, f.Path)
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	holders, err := locks.Holders(paths)
+	if err != nil || len(holders) == 0 {
+		return
+	}
+
+	for _, r := range summary.Results {
+		for i := range r.Locked {
+			r.Locked[i].Holders = holders[r.Locked[i].Path]
+		}
+	}
+
+	if cm.manifest == nil {
+		return
+	}
+	for i := range cm.manifest.Entries {
+		e := &cm.manifest.Entries[i]
+		if e.ErrorClass != ReasonLocked {
+			continue
+		}
+		for _, p := range holders[e.Path] {
+			e.Holders = append(e.Holders, p.String())
+		}
+	}
+}
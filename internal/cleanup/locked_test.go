@@ -0,0 +1,85 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/zs0c131y/burrow/internal/recyclebin"
+	"github.com/zs0c131y/burrow/pkg/models"
+)
+
+func TestFindHolders(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("holder lookup through /proc requires Linux")
+	}
+
+	path := filepath.Join(t.TempDir(), "in-use.tmp")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	cm := &CleanupManager{whitelist: map[string]bool{}}
+	cm.KeepManifest()
+	result := &CleanupResult{Target: &models.CleanupTarget{Name: "Temp"}}
+	cm.record(result, DisposeDelete, path, 10, &os.PathError{Op: "remove", Path: path, Err: syscall.EBUSY})
+	summary := &CleanupSummary{Results: []*CleanupResult{result}}
+
+	cm.findHolders(summary)
+
+	locked := summary.LockedFiles()
+	if len(locked) != 1 || locked[0].Path != path {
+		t.Fatalf("LockedFiles = %+v", locked)
+	}
+	pid := os.Getpid()
+	var found bool
+	for _, p := range locked[0].Holders {
+		found = found || p.PID == pid
+	}
+	if !found {
+		t.Errorf("holders %v do not include this process (pid %d)", locked[0].Holders, pid)
+	}
+
+	entry := cm.Manifest().Entries[0]
+	if entry.ErrorClass != ReasonLocked || !strings.Contains(strings.Join(entry.Holders, ","), "pid "+strconv.Itoa(pid)) {
+		t.Errorf("manifest entry = %+v", entry)
+	}
+}
+
+func TestOnlyDeletedFilesAreLocked(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "in-use.tmp")
+	binned := filepath.Join(dir, "$RA00001")
+	for _, path := range []string{file, binned} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	busy := &os.PathError{Op: "remove", Path: file, Err: syscall.EBUSY}
+
+	// Only a plain file deletion may be retried at the next restart. A binned
+	// item's original path may hold a new file by now, and a log that could
+	// not be compressed has no archive to fall back on.
+	cm := &CleanupManager{whitelist: map[string]bool{}}
+	result := &CleanupResult{Target: &models.CleanupTarget{Name: "Logs"}}
+	cm.record(result, DisposeDelete, file, 1, busy)
+	cm.record(result, DisposeDelete, dir, 0, busy)
+	cm.record(result, DisposeDelete, filepath.Join(dir, "gone.tmp"), 1, busy)
+	cm.record(result, DisposeTrash, file, 1, busy)
+	cm.record(result, string(models.ActionCompress), file, 1, busy)
+	cm.recordBinItem(result, recyclebin.Entry{Info: recyclebin.Info{OriginalPath: file, Size: 1}, DataPath: binned}, busy)
+
+	summary := &CleanupSummary{Results: []*CleanupResult{result}}
+	if locked := summary.LockedFiles(); len(locked) != 1 || locked[0].Path != file {
+		t.Errorf("LockedFiles = %+v, want only the deleted file", locked)
+	}
+	if result.Skipped[ReasonLocked] != 6 {
+		t.Errorf("Skipped = %v", result.Skipped)
+	}
+}
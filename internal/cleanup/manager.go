@@ -1,25 +1,48 @@
 package cleanup
 
 import (
-	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/zs0c131y/burrow/pkg/models"
 	"github.com/zs0c131y/burrow/pkg/utils"
 )
 
+// Categories lists the category names accepted by DiscoverTargets.
+var Categories = []string{"temp", "cache", "browser", "updates", "logs"}
+
+// IsKnownCategory reports whether name is one of Categories.
+func IsKnownCategory(name string) bool {
+	return contains(Categories, name)
+}
+
 // CleanupManager handles system cleanup operations.
 type CleanupManager struct {
 	debug     bool
 	dryRun    bool
+	retries   int
+	disposal  string
 	whitelist map[string]bool
-	mutex     sync.Mutex
+
+	// recycleAge is the minimum age of Recycle Bin items to empty.
+	recycleAge time.Duration
+	// compress, when set, makes log targets compress files instead of
+	// deleting them.
+	compress *CompressOptions
+	// manifest, when set, records what happened to every file.
+	manifest *Manifest
+	// ctx, when set, stops a cleanup between files once it is done.
+	ctx context.Context
+	// probe checks whether a file can be deleted now while targets are
+	// sized; tests replace it.
+	probe func(path string) error
+
+	mutex sync.Mutex
 }
 
 // CleanupSummary captures the results of a cleanup run.
@@ -29,16 +52,72 @@ type CleanupSummary struct {
 	FailedCleans      int
 	TotalSpaceFreed   int64
 	TotalFilesRemoved int
+	TotalProtected    int
 	Results           []*CleanupResult
+
+	// Space reclaimed, and files replaced, by compressing log files. Not
+	// included in TotalSpaceFreed or TotalFilesRemoved.
+	TotalSpaceCompressed int64
+	TotalFilesCompressed int
+
+	// TotalSkipped counts the files left behind by error class.
+	TotalSkipped map[string]int
+
+	// EstimatedSpace is what the cleaned targets were sized at beforehand
+	// and GuaranteedSpace the part of it that was not found in use.
+	// Compressed targets are left out of both.
+	EstimatedSpace  int64
+	GuaranteedSpace int64
+}
+
+// EstimateAccuracy returns the space freed as a percentage of the estimate.
+// It reports false when nothing was estimated.
+func (s *CleanupSummary) EstimateAccuracy() (float64, bool) {
+	if s.EstimatedSpace <= 0 {
+		return 0, false
+	}
+	return float64(s.TotalSpaceFreed) / float64(s.EstimatedSpace) * 100, true
+}
+
+// addSkipped adds the skipped counts of result to the summary.
+func (s *CleanupSummary) addSkipped(result *CleanupResult) {
+	for class, n := range result.Skipped {
+		if s.TotalSkipped == nil {
+			s.TotalSkipped = make(map[string]int)
+		}
+		s.TotalSkipped[class] += n
+	}
+}
+
+// DescribeSkipped lists the counts of files left behind by error class,
+// e.g. "3 locked, 1 permission denied". Whitelisted files are left out.
+func DescribeSkipped(counts map[string]int) string {
+	var parts []string
+	for _, class := range Reasons {
+		if n := counts[class]; n > 0 && class != ReasonProtected {
+			parts = append(parts, fmt.Sprintf("%d %s", n, strings.ReplaceAll(class, "_", " ")))
+		}
+	}
+	return strings.Join(parts, ", ")
 }
 
 // CleanupResult captures the result of cleaning a single target.
 type CleanupResult struct {
-	Target       *models.CleanupTarget
-	Success      bool
-	SpaceFreed   int64
-	FilesRemoved int
-	Error        error
+	Target         *models.CleanupTarget
+	Success        bool
+	SpaceFreed     int64
+	FilesRemoved   int
+	FilesProtected int
+	Error          error
+
+	SpaceCompressed int64
+	FilesCompressed int
+
+	// Skipped counts the files left behind by error class, e.g. ReasonLocked.
+	Skipped map[string]int
+	// Locked lists the files other processes kept open, with the processes
+	// when they could be identified.
+	Locked []LockedFile
 }
 
 // NewCleanupManager creates a new CleanupManager.
@@ -46,8 +125,48 @@ func NewCleanupManager(debug, dryRun bool) *CleanupManager {
 	return &CleanupManager{
 		debug:     debug,
 		dryRun:    dryRun,
+		retries:   3,
+		disposal:  DisposeDelete,
 		whitelist: loadWhitelist(),
+		probe:     utils.ProbeDelete,
+	}
+}
+
+// SetRetries sets how many times a locked file is retried before it is skipped.
+func (cm *CleanupManager) SetRetries(n int) {
+	if n < 1 {
+		n = 1
 	}
+	cm.retries = n
+}
+
+// SetContext makes ExecuteCleanup stop once ctx is done. The file being
+// removed at that moment is finished; the remaining files and targets are
+// left alone.
+func (cm *CleanupManager) SetContext(ctx context.Context) {
+	cm.ctx = ctx
+}
+
+// stopped reports whether the context set with SetContext is done.
+func (cm *CleanupManager) stopped() bool {
+	return cm.ctx != nil && cm.ctx.Err() != nil
+}
+
+// Ways CleanCandidates disposes of files.
+const (
+	DisposeDelete     = "delete"
+	DisposeQuarantine = "quarantine"
+	DisposeTrash      = "trash"
+)
+
+// SetDisposal selects whether CleanCandidates deletes files, moves them into
+// quarantine or sends them to the Recycle Bin; the last two can be restored.
+// Unknown modes delete.
+func (cm *CleanupManager) SetDisposal(mode string) {
+	if mode != DisposeQuarantine && mode != DisposeTrash {
+		mode = DisposeDelete
+	}
+	cm.disposal = mode
 }
 
 // DiscoverTargets finds cleanup targets based on the given category filter.
@@ -77,16 +196,25 @@ func (cm *CleanupManager) DiscoverTargets(categories []string) ([]*models.Cleanu
 	}
 
 	targets = append(targets, cm.getOtherTargets(sysPaths)...)
+	targets = dropNested(targets)
 
 	for _, target := range targets {
+		if target.Category == models.CategoryRecycleBin {
+			if err := cm.sizeRecycleBin(target); err != nil && cm.debug {
+				color.Yellow("  Warning: error scanning %s: %v", target.Name, err)
+			}
+			continue
+		}
+		if target.Action == models.ActionCompress {
+			if err := cm.sizeCompressTarget(target); err != nil && cm.debug {
+				color.Yellow("  Warning: error scanning %s: %v", target.Name, err)
+			}
+			continue
+		}
 		if utils.PathExists(target.Path) {
-			size, count, err := utils.GetDirSize(target.Path)
-			if err != nil && cm.debug {
+			if err := cm.sizeTarget(target); err != nil && cm.debug {
 				color.Yellow("  Warning: error scanning %s: %v", target.Name, err)
 			}
-			target.Size = size
-			target.ItemCount = count
-			target.Protected = cm.isProtected(target.Path)
 		}
 	}
 
@@ -100,6 +228,54 @@ func (cm *CleanupManager) DiscoverTargets(categories []string) ([]*models.Cleanu
 	return nonEmptyTargets, nil
 }
 
+// dropNested removes the targets that lie inside another target with the
+// same action, such as CBS Logs inside Windows Logs. Cleaning the outer
+// target covers them, and sizing both would count their files twice.
+func dropNested(targets []*models.CleanupTarget) []*models.CleanupTarget {
+	var kept []*models.CleanupTarget
+	for _, t := range targets {
+		inner := normalizeWhitelistPath(t.Path)
+		nested := false
+		for _, outer := range targets {
+			if outer == t || outer.Action != t.Action || outer.Category == models.CategoryRecycleBin {
+				continue
+			}
+			if strings.HasPrefix(inner, normalizeWhitelistPath(outer.Path)+"/") {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// sizeTarget estimates what cleaning target frees. Whitelisted entries are
+// left out, and every file is probed so that those in use are reported as
+// blocked.
+func (cm *CleanupManager) sizeTarget(target *models.CleanupTarget) error {
+	target.Protected = cm.isProtected(target.Path)
+	if target.Protected {
+		// Keep the size visible so users can see what the whitelist saves.
+		size, count, err := utils.GetDirSize(target.Path)
+		target.Size = size
+		target.ItemCount = count
+		return err
+	}
+
+	opts := cm.walkOptions()
+	opts.Probe = cm.probe
+	stats, err := utils.GetDirStats(target.Path, opts)
+	target.Size = stats.Size
+	target.ItemCount = stats.Files
+	target.ProtectedItems = stats.ExcludedFiles
+	target.BlockedSize = stats.Blocked
+	target.BlockedItems = stats.BlockedFiles
+	return err
+}
+
 func (cm *CleanupManager) getTempTargets(paths map[string]string) []*models.CleanupTarget {
 	var targets []*models.CleanupTarget
 
@@ -240,18 +416,21 @@ func (cm *CleanupManager) getLogTargets(paths map[string]string) []*models.Clean
 			Path:        filepath.Join(windir, "Logs"),
 			Description: "Windows system logs",
 			Category:    models.CategoryLogs,
+			Action:      cm.logAction(),
 		},
 		&models.CleanupTarget{
 			Name:        "CBS Logs",
 			Path:        filepath.Join(windir, "Logs", "CBS"),
 			Description: "Component-Based Servicing logs",
 			Category:    models.CategoryLogs,
+			Action:      cm.logAction(),
 		},
 		&models.CleanupTarget{
 			Name:        "Panther Logs",
 			Path:        filepath.Join(windir, "Panther"),
 			Description: "Windows installation logs",
 			Category:    models.CategoryLogs,
+			Action:      cm.logAction(),
 		},
 	)
 
@@ -261,14 +440,7 @@ func (cm *CleanupManager) getLogTargets(paths map[string]string) []*models.Clean
 func (cm *CleanupManager) getOtherTargets(paths map[string]string) []*models.CleanupTarget {
 	var targets []*models.CleanupTarget
 
-	if sysRoot := paths["SYSTEMROOT"]; sysRoot != "" {
-		targets = append(targets, &models.CleanupTarget{
-			Name:        "Recycle Bin",
-			Path:        filepath.Join(sysRoot, "$Recycle.Bin"),
-			Description: "Recycle bin contents",
-			Category:    models.CategoryRecycleBin,
-		})
-	}
+	targets = append(targets, cm.getRecycleBinTargets()...)
 
 	if progData := paths["PROGRAMDATA"]; progData != "" {
 		targets = append(targets, &models.CleanupTarget{
@@ -290,6 +462,9 @@ func (cm *CleanupManager) ExecuteCleanup(targets []*models.CleanupTarget) *Clean
 	}
 
 	for i, target := range targets {
+		if cm.stopped() {
+			break
+		}
 		if target.Protected {
 			continue
 		}
@@ -300,16 +475,27 @@ func (cm *CleanupManager) ExecuteCleanup(targets []*models.CleanupTarget) *Clean
 		result := cm.cleanTarget(target)
 		summary.Results = append(summary.Results, result)
 
+		if target.Action != models.ActionCompress {
+			summary.EstimatedSpace += target.Size
+			summary.GuaranteedSpace += target.GuaranteedSize()
+		}
+
+		summary.TotalProtected += result.FilesProtected
+		summary.addSkipped(result)
+
 		if result.Success {
 			summary.SuccessfulCleans++
 			summary.TotalSpaceFreed += result.SpaceFreed
 			summary.TotalFilesRemoved += result.FilesRemoved
+			summary.TotalSpaceCompressed += result.SpaceCompressed
+			summary.TotalFilesCompressed += result.FilesCompressed
 		} else {
 			summary.FailedCleans++
 		}
 	}
 
 	fmt.Println()
+	cm.findHolders(summary)
 	return summary
 }
 
@@ -319,13 +505,32 @@ func (cm *CleanupManager) cleanTarget(target *models.CleanupTarget) *CleanupResu
 		Success: true,
 	}
 
+	if target.Action == models.ActionCompress {
+		return cm.compressTarget(target)
+	}
+
 	if cm.dryRun {
 		result.SpaceFreed = target.Size
 		result.FilesRemoved = target.ItemCount
+		result.FilesProtected = target.ProtectedItems
+		cm.recordDryRun(result)
 		return result
 	}
 
-	freedSpace, filesRemoved, filesSkipped, err := utils.CleanDirectory(target.Path, 3)
+	if target.Category == models.CategoryRecycleBin {
+		return cm.emptyRecycleBin(target)
+	}
+
+	stats, err := utils.CleanDirectoryWithOptions(target.Path, utils.CleanOptions{
+		MaxRetries: cm.retries,
+		Walk:       cm.walkOptions(),
+		Context:    cm.ctx,
+		Report: func(path string, size int64, err error) {
+			cm.record(result, DisposeDelete, path, size, err)
+		},
+	})
+	freedSpace, filesRemoved, filesSkipped := stats.Freed, stats.Removed, stats.Skipped
+	result.FilesProtected = stats.ExcludedFiles
 
 	if err != nil {
 		result.Success = false
@@ -341,163 +546,59 @@ func (cm *CleanupManager) cleanTarget(target *models.CleanupTarget) *CleanupResu
 
 	if filesRemoved == 0 && filesSkipped > 0 {
 		result.Success = false
-		result.Error = fmt.Errorf("%d files locked or in use (skipped)", filesSkipped)
+		result.Error = fmt.Errorf("%d files skipped (%s)", filesSkipped, DescribeSkipped(result.Skipped))
 	}
 
 	if cm.debug && filesSkipped > 0 {
-		color.Yellow("\n  %s: %d files skipped (locked/in-use)", target.Name, filesSkipped)
+		color.Yellow("\n  %s: %d files skipped (%s)", target.Name, filesSkipped, DescribeSkipped(result.Skipped))
 	}
 
 	return result
 }
 
-func (cm *CleanupManager) isProtected(path string) bool {
-	cm.mutex.Lock()
-	defer cm.mutex.Unlock()
-	return cm.whitelist[strings.ToLower(path)]
-}
-
-func contains(slice []string, item string) bool {
-	for _, s := range slice {
-		if strings.EqualFold(s, item) {
-			return true
+// recordDryRun records what cleaning result's target would remove and leave
+// behind, as a real clean would, without removing anything.
+func (cm *CleanupManager) recordDryRun(result *CleanupResult) {
+	target := result.Target
+	if target.Category == models.CategoryRecycleBin {
+		due, protected, err := cm.recycleBinEntries(target.Path)
+		if err != nil {
+			return
+		}
+		for _, e := range protected {
+			cm.recordBinItem(result, e, errProtected)
 		}
+		for _, e := range due {
+			cm.recordBinItem(result, e, nil)
+		}
+		return
 	}
-	return false
-}
 
-// whitelistConfig is the on-disk format for the whitelist file.
-type whitelistConfig struct {
-	Paths []string `json:"protected_paths"`
+	_, _ = utils.CleanDirectoryWithOptions(target.Path, utils.CleanOptions{
+		Walk:    cm.walkOptions(),
+		Context: cm.ctx,
+		DryRun:  true,
+		Report: func(path string, size int64, err error) {
+			cm.record(result, DisposeDelete, path, size, err)
+		},
+	})
 }
 
-func getWhitelistPath() string {
-	configDir, err := utils.GetConfigDir()
-	if err != nil {
-		return ""
-	}
-	return filepath.Join(configDir, "whitelist.json")
+// walkOptions returns traversal options that skip whitelisted entries.
+func (cm *CleanupManager) walkOptions() utils.WalkOptions {
+	return utils.WalkOptions{Exclude: cm.isProtected}
 }
 
-func loadWhitelist() map[string]bool {
-	result := make(map[string]bool)
-
-	path := getWhitelistPath()
-	if path == "" {
-		return result
-	}
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return result
-	}
-
-	var cfg whitelistConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return result
-	}
-
-	for _, p := range cfg.Paths {
-		result[strings.ToLower(p)] = true
-	}
-
-	return result
+// blocked reports whether probing found path in use or not deletable.
+func (cm *CleanupManager) blocked(path string) bool {
+	return cm.probe != nil && cm.probe(path) != nil
 }
 
-func saveWhitelist(paths map[string]bool) error {
-	wlPath := getWhitelistPath()
-	if wlPath == "" {
-		return fmt.Errorf("cannot determine config directory")
-	}
-
-	var pathList []string
-	for p := range paths {
-		pathList = append(pathList, p)
-	}
-
-	cfg := whitelistConfig{Paths: pathList}
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		return fmt.Errorf("cannot marshal whitelist: %w", err)
-	}
-
-	if err := os.WriteFile(wlPath, data, 0o644); err != nil {
-		return fmt.Errorf("cannot write whitelist file: %w", err)
-	}
-
-	return nil
-}
-
-// ManageWhitelist provides interactive whitelist management.
-func ManageWhitelist() {
-	color.Cyan("\nWhitelist Management")
-	color.White("════════════════════════════════════════════════════════\n")
-	color.White("Protected paths will not be cleaned during cleanup operations.\n")
-
-	wl := loadWhitelist()
-
-	if len(wl) > 0 {
-		color.White("\nCurrently protected paths:\n")
-		i := 1
-		for p := range wl {
-			fmt.Printf("  %d. %s\n", i, p)
-			i++
-		}
-	} else {
-		color.Yellow("\nNo paths are currently protected.\n")
-	}
-
-	fmt.Println()
-	color.White("Options:\n")
-	color.White("  1. Add a path to whitelist\n")
-	color.White("  2. Remove a path from whitelist\n")
-	color.White("  3. Exit whitelist management\n")
-	fmt.Print("\nSelect option (1-3): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	choice, _ := reader.ReadString('\n')
-	choice = strings.TrimSpace(choice)
-
-	switch choice {
-	case "1":
-		fmt.Print("Enter path to protect: ")
-		path, _ := reader.ReadString('\n')
-		path = strings.TrimSpace(path)
-		if path == "" {
-			color.Yellow("No path entered.")
-			return
-		}
-		wl[strings.ToLower(path)] = true
-		if err := saveWhitelist(wl); err != nil {
-			color.Red("Error saving whitelist: %v", err)
-			return
-		}
-		color.Green("Path added to whitelist: %s", path)
-
-	case "2":
-		fmt.Print("Enter path to remove: ")
-		path, _ := reader.ReadString('\n')
-		path = strings.TrimSpace(path)
-		if path == "" {
-			color.Yellow("No path entered.")
-			return
-		}
-		lower := strings.ToLower(path)
-		if !wl[lower] {
-			color.Yellow("Path not found in whitelist.")
-			return
-		}
-		delete(wl, lower)
-		if err := saveWhitelist(wl); err != nil {
-			color.Red("Error saving whitelist: %v", err)
-			return
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if strings.EqualFold(s, item) {
+			return true
 		}
-		color.Green("Path removed from whitelist: %s", path)
-
-	case "3":
-		return
-
-	default:
-		color.Yellow("Invalid option.")
 	}
+	return false
 }
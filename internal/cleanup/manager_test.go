@@ -1,13 +1,17 @@
 package cleanup
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/zs0c131y/burrow/pkg/models"
+	"github.com/zs0c131y/burrow/pkg/utils"
 )
 
 func TestContains(t *testing.T) {
@@ -67,13 +71,12 @@ func TestLoadSaveWhitelist(t *testing.T) {
 	os.Setenv("APPDATA", tmpDir)
 	defer os.Unsetenv("APPDATA")
 
-	paths := map[string]bool{
-		`c:\important\folder`: true,
-		`c:\another\path`:     true,
-	}
+	wl := &Whitelist{}
+	wl.Add(WhitelistEntry{Pattern: `c:\important\folder`})
+	wl.Add(WhitelistEntry{Pattern: `c:\another\path`})
 
-	if err := saveWhitelist(paths); err != nil {
-		t.Fatalf("saveWhitelist error: %v", err)
+	if err := wl.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
 	}
 
 	wlPath := filepath.Join(configDir, "whitelist.json")
@@ -95,6 +98,10 @@ func TestLoadSaveWhitelist(t *testing.T) {
 		t.Errorf("Expected 2 paths in config, got %d", len(cfg.Paths))
 	}
 
+	if cfg.Version != whitelistSchemaVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, whitelistSchemaVersion)
+	}
+
 	loaded := loadWhitelist()
 	if len(loaded) != 2 {
 		t.Errorf("loadWhitelist returned %d entries, want 2", len(loaded))
@@ -204,3 +211,348 @@ func TestGetWhitelistPath(t *testing.T) {
 		t.Errorf("getWhitelistPath = %q, should end with whitelist.json", path)
 	}
 }
+
+func TestIsProtectedPatterns(t *testing.T) {
+	cm := &CleanupManager{
+		whitelist: map[string]bool{
+			`c:\users\me\appdata\local\temp\important`: true,
+			`c:\users\me\appdata\local\temp\keep*`:     true,
+			`**\*.pst`:                                 true,
+			`*.bak`:                                    true,
+		},
+	}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{`C:\Users\me\AppData\Local\Temp\important`, true},
+		{`C:\Users\me\AppData\Local\Temp\important\nested\file.txt`, true},
+		{`C:\Users\me\AppData\Local\Temp\important-not`, false},
+		{`C:\Users\me\AppData\Local\Temp\keep-this`, true},
+		{`C:\Users\me\AppData\Local\Temp\keep-this\inner.dat`, true},
+		{`C:\Users\me\AppData\Local\Temp\other\keep-this`, false},
+		{`D:\mail\archive.PST`, true},
+		{`C:\Temp\old\config.bak`, true},
+		{`C:\Users\me\AppData\Local\Temp`, false},
+		{`C:\Users\me\AppData\Local\Temp\scratch.tmp`, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			if got := cm.isProtected(tc.path); got != tc.expected {
+				t.Errorf("isProtected(%q) = %v, want %v", tc.path, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCleanTargetSkipsProtectedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	keepDir := filepath.Join(tmpDir, "important")
+	if err := os.MkdirAll(keepDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]int{
+		filepath.Join(tmpDir, "junk.tmp"):     100,
+		filepath.Join(tmpDir, "notes.keep"):   200,
+		filepath.Join(keepDir, "a.dat"):       300,
+		filepath.Join(keepDir, "b.dat"):       400,
+		filepath.Join(tmpDir, "other.tmp"):    500,
+		filepath.Join(tmpDir, "sub", "x.tmp"): 600,
+	}
+	for path, size := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cm := &CleanupManager{
+		whitelist: map[string]bool{
+			strings.ToLower(keepDir): true,
+			"*.keep":                 true,
+		},
+	}
+
+	target := &models.CleanupTarget{Name: "Test Temp", Path: tmpDir}
+	if cm.isProtected(target.Path) {
+		t.Fatal("target root should not be protected")
+	}
+
+	stats, err := utils.GetDirStats(target.Path, cm.walkOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Size != 1200 {
+		t.Errorf("estimated size = %d, want 1200 (protected entries excluded)", stats.Size)
+	}
+	if stats.Excluded != 2 || stats.ExcludedFiles != 3 {
+		t.Errorf("estimated protected entries = %d holding %d files, want 2 holding 3", stats.Excluded, stats.ExcludedFiles)
+	}
+
+	result := cm.cleanTarget(target)
+	if !result.Success {
+		t.Fatalf("cleanTarget failed: %v", result.Error)
+	}
+	if result.SpaceFreed != 1200 {
+		t.Errorf("SpaceFreed = %d, want 1200", result.SpaceFreed)
+	}
+	// The protected folder counts as the two files it holds.
+	if result.FilesProtected != 3 {
+		t.Errorf("FilesProtected = %d, want 3", result.FilesProtected)
+	}
+
+	for _, kept := range []string{
+		filepath.Join(tmpDir, "notes.keep"),
+		filepath.Join(keepDir, "a.dat"),
+		filepath.Join(keepDir, "b.dat"),
+	} {
+		if !utils.PathExists(kept) {
+			t.Errorf("protected file %s was deleted", kept)
+		}
+	}
+	if utils.PathExists(filepath.Join(tmpDir, "junk.tmp")) {
+		t.Error("unprotected file was not deleted")
+	}
+}
+
+func TestParseWhitelistLegacyFormat(t *testing.T) {
+	legacy := []byte(`{"protected_paths": ["c:\\legacy\\one", "c:\\legacy\\two"]}`)
+
+	wl, err := ParseWhitelist(legacy)
+	if err != nil {
+		t.Fatalf("ParseWhitelist error: %v", err)
+	}
+	if len(wl.Entries) != 2 {
+		t.Fatalf("Entries = %d, want 2", len(wl.Entries))
+	}
+	if wl.Entries[0].Pattern != `c:\legacy\one` {
+		t.Errorf("Entries[0] = %q, want c:\\legacy\\one", wl.Entries[0].Pattern)
+	}
+
+	if _, err := ParseWhitelist([]byte(`{"version": 99}`)); err == nil {
+		t.Error("ParseWhitelist should reject a newer schema version")
+	}
+}
+
+func TestWhitelistExpiry(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.Setenv("APPDATA", tmpDir)
+	defer os.Unsetenv("APPDATA")
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+
+	wl := &Whitelist{}
+	wl.Add(WhitelistEntry{Pattern: `c:\expired`, Comment: "old project", Expires: &past})
+	wl.Add(WhitelistEntry{Pattern: `c:\active`, Expires: &future})
+	if err := wl.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := loadWhitelist()
+	if loaded[`c:\expired`] {
+		t.Error("expired entry should not be active")
+	}
+	if !loaded[`c:\active`] {
+		t.Error("unexpired entry should be active")
+	}
+
+	reloaded, err := LoadWhitelist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Entries) != 2 {
+		t.Fatalf("Entries = %d, want 2 (expired entries are kept on disk)", len(reloaded.Entries))
+	}
+	if reloaded.Entries[0].Comment != "old project" {
+		t.Errorf("Comment = %q, want %q", reloaded.Entries[0].Comment, "old project")
+	}
+}
+
+func TestWhitelistAddRemove(t *testing.T) {
+	wl := &Whitelist{}
+
+	if !wl.Add(WhitelistEntry{Pattern: `C:\Data`}) {
+		t.Error("Add of a new pattern should report true")
+	}
+	if wl.Add(WhitelistEntry{Pattern: `c:\data\`, Comment: "updated"}) {
+		t.Error("Add of an equivalent pattern should replace the entry")
+	}
+	if len(wl.Entries) != 1 || wl.Entries[0].Comment != "updated" {
+		t.Errorf("Entries = %+v, want one updated entry", wl.Entries)
+	}
+
+	if !wl.Remove(`C:\DATA`) {
+		t.Error("Remove should match case-insensitively")
+	}
+	if wl.Remove(`C:\DATA`) {
+		t.Error("Remove of a missing pattern should report false")
+	}
+}
+
+func TestValidateWhitelistPattern(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := ValidateWhitelistPattern(tmpDir); err != nil {
+		t.Errorf("existing path rejected: %v", err)
+	}
+	if err := ValidateWhitelistPattern(filepath.Join(tmpDir, "missing")); err == nil {
+		t.Error("missing path should be rejected")
+	}
+	if err := ValidateWhitelistPattern(`**\*.pst`); err != nil {
+		t.Errorf("valid glob rejected: %v", err)
+	}
+	if err := ValidateWhitelistPattern(`c:\bad[pattern`); err == nil {
+		t.Error("malformed glob should be rejected")
+	}
+	if err := ValidateWhitelistPattern("  "); err == nil {
+		t.Error("empty pattern should be rejected")
+	}
+}
+
+func TestEstimateExcludesProtectedAndInUse(t *testing.T) {
+	root := t.TempDir()
+	keep := filepath.Join(root, "keep")
+	if err := os.MkdirAll(keep, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	busy := filepath.Join(root, "busy.tmp")
+	os.WriteFile(filepath.Join(keep, "saved.tmp"), make([]byte, 500), 0o644)
+	os.WriteFile(busy, make([]byte, 300), 0o644)
+	os.WriteFile(filepath.Join(root, "free.tmp"), make([]byte, 100), 0o644)
+
+	cm := &CleanupManager{
+		retries:   1,
+		whitelist: map[string]bool{strings.ToLower(keep): true},
+		probe: func(path string) error {
+			if path == busy {
+				return errors.New("in use")
+			}
+			return nil
+		},
+	}
+
+	target := &models.CleanupTarget{Name: "Test Temp", Path: root}
+	if err := cm.sizeTarget(target); err != nil {
+		t.Fatal(err)
+	}
+	if target.Size != 400 || target.ProtectedItems != 1 {
+		t.Errorf("estimate = %d bytes with %d protected, want 400 with 1", target.Size, target.ProtectedItems)
+	}
+	if target.BlockedSize != 300 || target.BlockedItems != 1 || target.GuaranteedSize() != 100 {
+		t.Errorf("blocked = %d bytes / %d files, guaranteed %d; want 300 / 1, 100",
+			target.BlockedSize, target.BlockedItems, target.GuaranteedSize())
+	}
+
+	summary := cm.ExecuteCleanup([]*models.CleanupTarget{target})
+	if summary.EstimatedSpace != 400 || summary.GuaranteedSpace != 100 {
+		t.Errorf("summary estimate = %d, guaranteed %d; want 400, 100", summary.EstimatedSpace, summary.GuaranteedSpace)
+	}
+	if acc, ok := summary.EstimateAccuracy(); !ok || acc != 100 {
+		t.Errorf("EstimateAccuracy = %v, %v; want 100, true", acc, ok)
+	}
+}
+
+func TestAbsWhitelistPattern(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := AbsWhitelistPattern("./foo"), filepath.Join(wd, "foo"); got != want {
+		t.Errorf("AbsWhitelistPattern(./foo) = %q, want %q", got, want)
+	}
+	if got := AbsWhitelistPattern("*.pst"); got != "*.pst" {
+		t.Errorf("glob pattern changed to %q", got)
+	}
+
+	cm := &CleanupManager{whitelist: map[string]bool{strings.ToLower(AbsWhitelistPattern("./foo")): true}}
+	if !cm.isProtected(filepath.Join(wd, "foo", "bar.tmp")) {
+		t.Error("relative entry should protect the absolute path beneath it")
+	}
+}
+
+func TestParseWhitelistExpiryIsInclusive(t *testing.T) {
+	expires, err := ParseWhitelistExpiry("2026-03-14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := WhitelistEntry{Pattern: "x", Expires: &expires}
+
+	if e.Expired(time.Date(2026, 3, 14, 18, 0, 0, 0, time.Local)) {
+		t.Error("entry should still be active during its expiry day")
+	}
+	if !e.Expired(time.Date(2026, 3, 15, 0, 0, 0, 0, time.Local)) {
+		t.Error("entry should have expired the day after")
+	}
+	if _, err := ParseWhitelistExpiry("14/03/2026"); err == nil {
+		t.Error("malformed date should be rejected")
+	}
+}
+
+func TestExecuteCleanupStopsWhenContextDone(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "a.tmp")
+	os.WriteFile(file, make([]byte, 100), 0o644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{}}
+	cm.SetContext(ctx)
+	summary := cm.ExecuteCleanup([]*models.CleanupTarget{{Name: "Test Temp", Path: root, Size: 100}})
+
+	if len(summary.Results) != 0 || summary.TotalFilesRemoved != 0 {
+		t.Errorf("cleanup ran after cancellation: %+v", summary)
+	}
+	if !utils.PathExists(file) {
+		t.Error("file removed after cancellation")
+	}
+}
+
+func TestNestedTargetsCountedOnce(t *testing.T) {
+	root := t.TempDir()
+	logs := filepath.Join(root, "Logs")
+	cbs := filepath.Join(logs, "CBS")
+	sibling := filepath.Join(root, "Logs2")
+	for _, dir := range []string{cbs, sibling} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(logs, "setup.log"), make([]byte, 100), 0o644)
+	os.WriteFile(filepath.Join(cbs, "cbs.log"), make([]byte, 1000), 0o644)
+	os.WriteFile(filepath.Join(sibling, "other.log"), make([]byte, 10), 0o644)
+
+	targets := dropNested([]*models.CleanupTarget{
+		{Name: "Windows Logs", Path: logs, Action: models.ActionDelete},
+		{Name: "CBS Logs", Path: cbs, Action: models.ActionDelete},
+		{Name: "Other Logs", Path: sibling, Action: models.ActionDelete},
+	})
+	var names []string
+	for _, target := range targets {
+		names = append(names, target.Name)
+	}
+	if strings.Join(names, ",") != "Windows Logs,Other Logs" {
+		t.Fatalf("dropNested kept %v", names)
+	}
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{}}
+	for _, target := range targets {
+		if err := cm.sizeTarget(target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	summary := cm.ExecuteCleanup(targets)
+	if summary.EstimatedSpace != 1110 || summary.TotalSpaceFreed != 1110 {
+		t.Errorf("estimate = %d, freed = %d; want 1110 each", summary.EstimatedSpace, summary.TotalSpaceFreed)
+	}
+	if acc, ok := summary.EstimateAccuracy(); !ok || acc != 100 {
+		t.Errorf("EstimateAccuracy = %v, %v; want 100, true", acc, ok)
+	}
+}
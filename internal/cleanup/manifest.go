@@ -0,0 +1,172 @@
+package cleanup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// manifestVersion is the schema version written by WriteManifest.
+const manifestVersion = 1
+
+// Reasons a file was left behind, recorded as a manifest entry's error class.
+const (
+	ReasonLocked     = "locked"
+	ReasonPermission = "permission_denied"
+	ReasonNotFound   = "not_found"
+	ReasonProtected  = "protected"
+	ReasonChanged    = "changed"
+	ReasonNoSaving   = "incompressible"
+	ReasonOther      = "error"
+)
+
+// Reasons lists the error classes in the order they are reported.
+var Reasons = []string{ReasonLocked, ReasonPermission, ReasonNotFound, ReasonChanged, ReasonNoSaving, ReasonProtected, ReasonOther}
+
+// ManifestEntry records what a cleanup did with one file. ErrorClass is empty
+// when the action succeeded.
+type ManifestEntry struct {
+	Path       string   `json:"path"`
+	Size       int64    `json:"size"`
+	Target     string   `json:"target"`
+	Action     string   `json:"action"`
+	ErrorClass string   `json:"error_class,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Holders    []string `json:"holders,omitempty"` // processes holding a locked file
+	// OriginalPath is where a Recycle Bin item was deleted from; Path is
+	// the item's data file in the bin.
+	OriginalPath string `json:"original_path,omitempty"`
+}
+
+// Manifest lists every file a cleanup run removed, compressed or left
+// behind.
+type Manifest struct {
+	Version int             `json:"version"`
+	Started time.Time       `json:"started"`
+	DryRun  bool            `json:"dry_run,omitempty"`
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// KeepManifest makes the manager record a per-file manifest of the cleanup,
+// available from Manifest.
+func (cm *CleanupManager) KeepManifest() {
+	cm.manifest = &Manifest{Version: manifestVersion, Started: time.Now(), DryRun: cm.dryRun}
+}
+
+// Manifest returns the manifest recorded so far, or nil if none is kept.
+func (cm *CleanupManager) Manifest() *Manifest {
+	return cm.manifest
+}
+
+// ClassifyError returns the error class of err, or "" for nil.
+func ClassifyError(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, utils.ErrExcluded):
+		return ReasonProtected
+	case errors.Is(err, ErrCandidateChanged), errors.Is(err, errChangedWhileCompressing):
+		return ReasonChanged
+	case errors.Is(err, errNotSmaller):
+		return ReasonNoSaving
+	case utils.IsLocked(err):
+		return ReasonLocked
+	case errors.Is(err, fs.ErrPermission):
+		return ReasonPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return ReasonNotFound
+	}
+	return ReasonOther
+}
+
+// errProtected is recorded for files the whitelist kept.
+var errProtected = fmt.Errorf("whitelisted: %w", utils.ErrExcluded)
+
+// record notes the outcome of action on one file of result: failures are
+// counted by error class and, when a manifest is kept, an entry is added. A
+// file that another process kept from being deleted is also added to the
+// result's locked files, which may be scheduled for deletion at the next
+// restart; a failed compress or move is not, as deleting the file then would
+// lose it.
+func (cm *CleanupManager) record(result *CleanupResult, action, path string, size int64, err error) {
+	cm.recordEntry(result, ManifestEntry{Path: path, Size: size, Action: action}, err)
+
+	if action != DisposeDelete || ClassifyError(err) != ReasonLocked {
+		return
+	}
+	if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	cm.mutex.Lock()
+	result.Locked = append(result.Locked, LockedFile{Path: path, Size: size})
+	cm.mutex.Unlock()
+}
+
+// recordEntry is record for an entry that carries more than a path, size and
+// action. The entry's target and error are filled in from result and err.
+// It never adds to the result's locked files.
+func (cm *CleanupManager) recordEntry(result *CleanupResult, entry ManifestEntry, err error) {
+	class := ClassifyError(err)
+
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if class != "" {
+		if result.Skipped == nil {
+			result.Skipped = make(map[string]int)
+		}
+		result.Skipped[class]++
+	}
+	if cm.manifest == nil {
+		return
+	}
+
+	entry.Target = result.Target.Name
+	entry.ErrorClass = class
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	cm.manifest.Entries = append(cm.manifest.Entries, entry)
+}
+
+// WriteManifest saves m to path as JSON, or as CSV when path ends in ".csv".
+func WriteManifest(path string, m *Manifest) error {
+	if m.Entries == nil {
+		m.Entries = []ManifestEntry{}
+	}
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		data = manifestCSV(m)
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(m, "", "  "); err != nil {
+			return fmt.Errorf("failed to encode cleanup report: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cleanup report: %w", err)
+	}
+	return nil
+}
+
+func manifestCSV(m *Manifest) []byte {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{"path", "size", "target", "action", "error_class", "error", "holders", "original_path"})
+	for _, e := range m.Entries {
+		_ = w.Write([]string{e.Path, strconv.FormatInt(e.Size, 10), e.Target, e.Action, e.ErrorClass, e.Error, strings.Join(e.Holders, "; "), e.OriginalPath})
+	}
+	w.Flush()
+	return []byte(b.String())
+}
@@ -0,0 +1,165 @@
+package cleanup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/models"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{errProtected, ReasonProtected},
+		{ErrCandidateChanged, ReasonChanged},
+		{fmt.Errorf("wrapped: %w", errChangedWhileCompressing), ReasonChanged},
+		{errNotSmaller, ReasonNoSaving},
+		{&os.PathError{Op: "remove", Path: "x", Err: os.ErrPermission}, ReasonPermission},
+		{&os.PathError{Op: "lstat", Path: "x", Err: syscall.ENOENT}, ReasonNotFound},
+		{errors.New("disk on fire"), ReasonOther},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != tt.want {
+			t.Errorf("ClassifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeSkipped(t *testing.T) {
+	got := DescribeSkipped(map[string]int{ReasonPermission: 1, ReasonLocked: 3, ReasonProtected: 9})
+	if got != "3 locked, 1 permission denied" {
+		t.Errorf("DescribeSkipped = %q", got)
+	}
+	if got := DescribeSkipped(nil); got != "" {
+		t.Errorf("DescribeSkipped(nil) = %q", got)
+	}
+}
+
+func TestCleanTargetManifest(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"a.tmp": "aaaa", "sub/b.tmp": "bb", "keep/c.tmp": "c"}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{
+		"a.tmp 4 User Temp delete ",
+		"keep 0 User Temp delete protected",
+		"sub/b.tmp 2 User Temp delete ",
+	}
+
+	// The dry run goes first and must leave the files for the real clean.
+	for _, dryRun := range []bool{true, false} {
+		cm := &CleanupManager{dryRun: dryRun, retries: 1, whitelist: map[string]bool{filepath.Join(root, "keep"): true}}
+		cm.KeepManifest()
+		target := &models.CleanupTarget{Name: "User Temp", Path: root, Category: models.CategoryTemp}
+		summary := cm.ExecuteCleanup([]*models.CleanupTarget{target})
+
+		var got []string
+		for _, e := range cm.Manifest().Entries {
+			rel, _ := filepath.Rel(root, e.Path)
+			got = append(got, fmt.Sprintf("%s %d %s %s %s", filepath.ToSlash(rel), e.Size, e.Target, e.Action, e.ErrorClass))
+		}
+		sort.Strings(got)
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("dry run %v: manifest entries:\n%s\nwant:\n%s", dryRun, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+		if cm.Manifest().DryRun != dryRun || summary.TotalSkipped[ReasonProtected] != 1 {
+			t.Errorf("dry run %v: manifest dry_run %v, summary skipped %v", dryRun, cm.Manifest().DryRun, summary.TotalSkipped)
+		}
+
+		_, err := os.Stat(filepath.Join(root, "a.tmp"))
+		if dryRun && err != nil {
+			t.Fatalf("dry run removed a.tmp: %v", err)
+		}
+		if !dryRun && summary.TotalFilesRemoved != 2 {
+			t.Errorf("removed %d files, want 2", summary.TotalFilesRemoved)
+		}
+	}
+}
+
+func TestCleanCandidatesManifest(t *testing.T) {
+	dir := t.TempDir()
+	gone := filepath.Join(dir, "gone.log")
+	changed := filepath.Join(dir, "changed.log")
+	ok := filepath.Join(dir, "ok.log")
+	for _, p := range []string{changed, ok} {
+		if err := os.WriteFile(p, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, _ := os.Stat(ok)
+
+	list := &CandidateList{Items: []Candidate{
+		{Path: gone, Size: 1, ModTime: time.Now()},
+		{Path: changed, Size: 99, ModTime: info.ModTime()},
+		{Path: ok, Size: 4, ModTime: info.ModTime()},
+	}}
+	cm := &CleanupManager{retries: 1, disposal: DisposeDelete, whitelist: map[string]bool{}}
+	cm.KeepManifest()
+	summary := cm.CleanCandidates(list, "Candidate list")
+
+	classes := make(map[string]string)
+	for _, e := range cm.Manifest().Entries {
+		classes[filepath.Base(e.Path)] = e.ErrorClass
+	}
+	want := map[string]string{"gone.log": ReasonNotFound, "changed.log": ReasonChanged, "ok.log": ""}
+	for name, class := range want {
+		if classes[name] != class {
+			t.Errorf("%s recorded as %q, want %q", name, classes[name], class)
+		}
+	}
+	if summary.TotalSkipped[ReasonNotFound] != 1 || summary.TotalSkipped[ReasonChanged] != 1 {
+		t.Errorf("TotalSkipped = %v", summary.TotalSkipped)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	m := &Manifest{Version: manifestVersion, Entries: []ManifestEntry{
+		{Path: `C:\Temp\a.tmp`, Size: 10, Target: "Temp", Action: DisposeDelete},
+		{Path: `C:\Temp\b, "locked".tmp`, Size: 20, Target: "Temp", Action: DisposeDelete, ErrorClass: ReasonLocked, Error: "in use"},
+	}}
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "report.json")
+	if err := WriteManifest(jsonPath, m); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Manifest
+	data, _ := os.ReadFile(jsonPath)
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded.Entries) != 2 || decoded.Entries[1].ErrorClass != ReasonLocked {
+		t.Errorf("JSON report = %+v (%v)", decoded, err)
+	}
+
+	csvPath := filepath.Join(dir, "report.CSV")
+	if err := WriteManifest(csvPath, m); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.Open(csvPath)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[2][0] != `C:\Temp\b, "locked".tmp` || rows[2][4] != ReasonLocked {
+		t.Errorf("CSV report = %q", rows)
+	}
+}
@@ -0,0 +1,134 @@
+package cleanup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/zs0c131y/burrow/internal/recyclebin"
+	"github.com/zs0c131y/burrow/pkg/models"
+)
+
+// SetRecycleBinAge limits Recycle Bin cleanup to items deleted more than
+// days ago. 0 empties the bins completely.
+func (cm *CleanupManager) SetRecycleBinAge(days int) {
+	if days < 0 {
+		days = 0
+	}
+	cm.recycleAge = time.Duration(days) * 24 * time.Hour
+}
+
+// getRecycleBinTargets returns the current user's Recycle Bin on every fixed
+// drive.
+func (cm *CleanupManager) getRecycleBinTargets() []*models.CleanupTarget {
+	bins, err := recyclebin.Bins()
+	if err != nil {
+		if cm.debug {
+			color.Yellow("  Warning: cannot list recycle bins: %v", err)
+		}
+		return nil
+	}
+
+	description := "Recycle bin contents"
+	if cm.recycleAge > 0 {
+		description = fmt.Sprintf("Items deleted more than %d days ago", int(cm.recycleAge.Hours()/24))
+	}
+
+	var targets []*models.CleanupTarget
+	for _, bin := range bins {
+		targets = append(targets, &models.CleanupTarget{
+			Name:        fmt.Sprintf("Recycle Bin (%s)", bin.Drive),
+			Path:        bin.Path,
+			Description: description,
+			Category:    models.CategoryRecycleBin,
+		})
+	}
+	return targets
+}
+
+// recycleBinEntries returns the items of the bin at path that are due to be
+// emptied, and those kept because their original path is whitelisted.
+func (cm *CleanupManager) recycleBinEntries(path string) (due, protected []recyclebin.Entry, err error) {
+	entries, err := recyclebin.ReadBin(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cutoff := time.Now().Add(-cm.recycleAge)
+	for _, e := range entries {
+		if cm.recycleAge > 0 && e.Deleted.After(cutoff) {
+			continue
+		}
+		if cm.isProtected(e.OriginalPath) {
+			protected = append(protected, e)
+			continue
+		}
+		due = append(due, e)
+	}
+	return due, protected, nil
+}
+
+// sizeRecycleBin fills in the size of a Recycle Bin target from the items
+// that would be emptied.
+func (cm *CleanupManager) sizeRecycleBin(target *models.CleanupTarget) error {
+	due, protected, err := cm.recycleBinEntries(target.Path)
+	if err != nil {
+		return err
+	}
+	target.Size = 0
+	target.BlockedSize, target.BlockedItems = 0, 0
+	for _, e := range due {
+		target.Size += e.Size
+		if cm.blocked(e.DataPath) {
+			target.BlockedSize += e.Size
+			target.BlockedItems++
+		}
+	}
+	target.ItemCount = len(due)
+	target.ProtectedItems = len(protected)
+	return nil
+}
+
+// emptyRecycleBin permanently deletes the due items of a Recycle Bin target,
+// leaving the bin folder and its desktop.ini in place.
+func (cm *CleanupManager) emptyRecycleBin(target *models.CleanupTarget) *CleanupResult {
+	result := &CleanupResult{Target: target, Success: true}
+
+	due, protected, err := cm.recycleBinEntries(target.Path)
+	if err != nil {
+		result.Success = false
+		result.Error = err
+		return result
+	}
+	result.FilesProtected = len(protected)
+	for _, e := range protected {
+		cm.recordBinItem(result, e, errProtected)
+	}
+
+	var failed int
+	for _, e := range due {
+		if cm.stopped() {
+			break
+		}
+		err := e.Remove()
+		cm.recordBinItem(result, e, err)
+		if err != nil {
+			failed++
+			continue
+		}
+		result.SpaceFreed += e.Size
+		result.FilesRemoved++
+	}
+
+	if failed > 0 && result.FilesRemoved == 0 {
+		result.Success = false
+		result.Error = fmt.Errorf("%d items skipped (%s)", failed, DescribeSkipped(result.Skipped))
+	}
+	return result
+}
+
+// recordBinItem records the outcome for a Recycle Bin item under its data
+// file, the path actually removed, along with where it was deleted from.
+func (cm *CleanupManager) recordBinItem(result *CleanupResult, e recyclebin.Entry, err error) {
+	cm.recordEntry(result, ManifestEntry{Path: e.DataPath, Size: e.Size, Action: DisposeDelete, OriginalPath: e.OriginalPath}, err)
+}
@@ -0,0 +1,119 @@
+package cleanup
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/zs0c131y/burrow/pkg/models"
+)
+
+// writeBinItem writes a version 2 $I file and its $R data file into bin.
+func writeBinItem(t *testing.T, bin, id, original string, size int64, deleted time.Time) {
+	t.Helper()
+
+	path := append(utf16.Encode([]rune(original)), 0)
+	info := make([]byte, 28+2*len(path))
+	binary.LittleEndian.PutUint64(info[0:], 2)
+	binary.LittleEndian.PutUint64(info[8:], uint64(size))
+	// FILETIME counts 100ns intervals since 1601-01-01.
+	ft := deleted.UnixNano()/100 + 116444736000000000
+	binary.LittleEndian.PutUint64(info[16:], uint64(ft))
+	binary.LittleEndian.PutUint32(info[24:], uint32(len(path)))
+	for i, c := range path {
+		binary.LittleEndian.PutUint16(info[28+2*i:], c)
+	}
+
+	if err := os.WriteFile(filepath.Join(bin, "$I"+id), info, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bin, "$R"+id), make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmptyRecycleBinAge(t *testing.T) {
+	bin := t.TempDir()
+	now := time.Now()
+	writeBinItem(t, bin, "OLD001.txt", "/home/ana/old.txt", 100, now.AddDate(0, 0, -60))
+	writeBinItem(t, bin, "NEW001.txt", "/home/ana/new.txt", 40, now.AddDate(0, 0, -2))
+	writeBinItem(t, bin, "KEEP01.txt", "/home/ana/keep/notes.txt", 7, now.AddDate(0, 0, -90))
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{"/home/ana/keep": true}}
+	cm.SetRecycleBinAge(30)
+
+	target := &models.CleanupTarget{Name: "Recycle Bin", Path: bin, Category: models.CategoryRecycleBin}
+	if err := cm.sizeRecycleBin(target); err != nil {
+		t.Fatalf("sizeRecycleBin error: %v", err)
+	}
+	if target.Size != 100 || target.ItemCount != 1 || target.ProtectedItems != 1 {
+		t.Errorf("target = %d bytes, %d items, %d protected; want 100, 1, 1",
+			target.Size, target.ItemCount, target.ProtectedItems)
+	}
+
+	result := cm.cleanTarget(target)
+	if !result.Success || result.SpaceFreed != 100 || result.FilesRemoved != 1 {
+		t.Errorf("cleanTarget = %+v", result)
+	}
+
+	for _, name := range []string{"$IOLD001.txt", "$ROLD001.txt"} {
+		if _, err := os.Stat(filepath.Join(bin, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", name)
+		}
+	}
+	for _, name := range []string{"$INEW001.txt", "$RNEW001.txt", "$IKEEP01.txt", "$RKEEP01.txt"} {
+		if _, err := os.Stat(filepath.Join(bin, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+}
+
+func TestEmptyRecycleBinAll(t *testing.T) {
+	bin := t.TempDir()
+	writeBinItem(t, bin, "A00001", "/home/ana/a", 3, time.Now())
+	writeBinItem(t, bin, "B00001", "/home/ana/b", 5, time.Now().AddDate(-1, 0, 0))
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{}}
+	target := &models.CleanupTarget{Name: "Recycle Bin", Path: bin, Category: models.CategoryRecycleBin}
+
+	result := cm.cleanTarget(target)
+	if !result.Success || result.SpaceFreed != 8 || result.FilesRemoved != 2 {
+		t.Errorf("cleanTarget = %+v", result)
+	}
+	entries, err := os.ReadDir(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("bin still holds %d entries", len(entries))
+	}
+}
+
+func TestEmptyRecycleBinManifest(t *testing.T) {
+	bin := t.TempDir()
+	writeBinItem(t, bin, "A00001", "/home/ana/a", 3, time.Now())
+	writeBinItem(t, bin, "K00001", "/home/ana/keep/k", 5, time.Now())
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{"/home/ana/keep": true}}
+	cm.KeepManifest()
+	cm.cleanTarget(&models.CleanupTarget{Name: "Recycle Bin", Path: bin, Category: models.CategoryRecycleBin})
+
+	// Entries name the data file actually removed, never the original
+	// location, which may hold a different file by now.
+	want := map[string]string{
+		filepath.Join(bin, "$RA00001"): "/home/ana/a",
+		filepath.Join(bin, "$RK00001"): "/home/ana/keep/k",
+	}
+	entries := cm.Manifest().Entries
+	if len(entries) != len(want) {
+		t.Fatalf("manifest = %+v", entries)
+	}
+	for _, e := range entries {
+		if original, ok := want[e.Path]; !ok || e.OriginalPath != original {
+			t.Errorf("entry %s from %s, want one of %v", e.Path, e.OriginalPath, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cleanup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// reportEntry is the part of a file entry that every JSON report written by
+// Burrow shares. Snapshot entries also carry their children.
+type reportEntry struct {
+	Path        string        `json:"path"`
+	Size        int64         `json:"size"`
+	ModTime     time.Time     `json:"mod_time"`
+	IsDirectory bool          `json:"is_directory"`
+	IsLink      bool          `json:"is_link"`
+	Children    []reportEntry `json:"children"`
+}
+
+// reportFile covers the layouts ReadReport understands.
+type reportFile struct {
+	Version      int           `json:"version"`
+	Source       string        `json:"source"`
+	Items        []reportEntry `json:"items"`         // candidate list
+	Root         *reportEntry  `json:"root"`          // 'wm analyze --export' snapshot
+	LargestFiles []reportEntry `json:"largest_files"` // 'wm analyze --json'
+	Directories  []struct {
+		Files []reportEntry `json:"files"`
+	} `json:"directories"` // 'wm analyze --stale --json'
+}
+
+// ReadReport loads the files listed in a report as deletion candidates. It
+// accepts a candidate list, a JSON snapshot from 'wm analyze --export', and
+// the --json output of 'wm analyze' (its largest files), 'wm analyze --stale'
+// and 'wm analyze --type'. Directories and links are left out, and each path
+// is listed once.
+func ReadReport(path string) (*CandidateList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+
+	var entries []reportEntry
+	source := path
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		// 'wm analyze --type --json' prints a bare list of files.
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse report: %w", err)
+		}
+	} else {
+		var doc reportFile
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse report: %w", err)
+		}
+		if doc.Items != nil && doc.Version > candidateListVersion {
+			return nil, fmt.Errorf("candidate list version %d is newer than supported (%d)", doc.Version, candidateListVersion)
+		}
+
+		if doc.Source != "" {
+			source = doc.Source
+		}
+		entries = append(entries, doc.Items...)
+		entries = append(entries, doc.LargestFiles...)
+		for _, d := range doc.Directories {
+			entries = append(entries, d.Files...)
+		}
+		if doc.Root != nil {
+			var walk func(e reportEntry)
+			walk = func(e reportEntry) {
+				entries = append(entries, e)
+				for _, child := range e.Children {
+					walk(child)
+				}
+			}
+			walk(*doc.Root)
+		}
+	}
+
+	list := &CandidateList{Version: candidateListVersion, Source: source, Items: []Candidate{}}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if e.Path == "" || e.IsDirectory || e.IsLink || seen[e.Path] {
+			continue
+		}
+		seen[e.Path] = true
+		list.Items = append(list.Items, Candidate{Path: e.Path, Size: e.Size, ModTime: e.ModTime})
+	}
+	return list, nil
+}
@@ -0,0 +1,103 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zs0c131y/burrow/internal/quarantine"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+func TestReadReport(t *testing.T) {
+	tests := []struct {
+		name      string
+		report    string
+		wantPaths []string
+	}{
+		{
+			"candidate list",
+			`{"version": 1, "source": "wm analyze --stale 365", "items": [{"path": "/d/a.log", "size": 5}]}`,
+			[]string{"/d/a.log"},
+		},
+		{
+			"analyze json",
+			`{"path": "/d", "is_directory": true, "children": [{"path": "/d/x", "is_directory": true}],
+			  "largest_files": [{"path": "/d/big.iso", "size": 9}, {"path": "/d/x/b.bin", "size": 4}]}`,
+			[]string{"/d/big.iso", "/d/x/b.bin"},
+		},
+		{
+			"stale json",
+			`{"directories": [{"path": "/d", "files": [{"path": "/d/old.zip"}, {"path": "/d/old.zip"}]}]}`,
+			[]string{"/d/old.zip"},
+		},
+		{
+			"type listing",
+			`[{"path": "/d/movie.mkv", "size": 7}, {"path": "/d/link", "is_link": true}]`,
+			[]string{"/d/movie.mkv"},
+		},
+		{
+			"snapshot",
+			`{"version": 1, "root": {"path": "/d", "is_directory": true, "children": [
+			  {"path": "/d/a.txt"}, {"path": "/d/sub", "is_directory": true, "children": [{"path": "/d/sub/b.txt"}]}]}}`,
+			[]string{"/d/a.txt", "/d/sub/b.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report.json")
+			if err := os.WriteFile(path, []byte(tt.report), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			list, err := ReadReport(path)
+			if err != nil {
+				t.Fatalf("ReadReport error: %v", err)
+			}
+			var got []string
+			for _, c := range list.Items {
+				got = append(got, c.Path)
+			}
+			if len(got) != len(tt.wantPaths) {
+				t.Fatalf("paths = %v, want %v", got, tt.wantPaths)
+			}
+			for i := range got {
+				if got[i] != tt.wantPaths[i] {
+					t.Errorf("paths = %v, want %v", got, tt.wantPaths)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCleanCandidatesQuarantine(t *testing.T) {
+	t.Setenv("APPDATA", t.TempDir())
+	tmpDir := t.TempDir()
+
+	path := filepath.Join(tmpDir, "big.iso")
+	if err := os.WriteFile(path, make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cm := &CleanupManager{retries: 1, whitelist: map[string]bool{}}
+	cm.SetDisposal(DisposeQuarantine)
+	list := &CandidateList{Items: []Candidate{{Path: path, Size: info.Size(), ModTime: info.ModTime()}}}
+	summary := cm.CleanCandidates(list, "Report")
+
+	if summary.TotalFilesRemoved != 1 || utils.PathExists(path) {
+		t.Fatalf("candidate was not moved: %+v", summary)
+	}
+	items, err := quarantine.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].OriginalPath != path {
+		t.Errorf("quarantine holds %+v, want %s", items, path)
+	}
+}
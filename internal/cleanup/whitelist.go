@@ -0,0 +1,401 @@
+package cleanup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// isProtected reports whether path is covered by a whitelist entry. An entry
+// protects a path when it names the path itself or one of its ancestors, or
+// when it is a glob pattern matching either of those. Patterns without a path
+// separator, such as "*.pst", are matched against every path component, and
+// "**" matches any number of directories. Matching is case-insensitive.
+func (cm *CleanupManager) isProtected(path string) bool {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if len(cm.whitelist) == 0 {
+		return false
+	}
+
+	target := normalizeWhitelistPath(path)
+	for pattern := range cm.whitelist {
+		if matchWhitelistEntry(normalizeWhitelistPath(pattern), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeWhitelistPath lower-cases p and converts it to forward slashes so
+// that Windows paths compare consistently regardless of case or separator.
+func normalizeWhitelistPath(p string) string {
+	p = strings.ToLower(strings.ReplaceAll(p, `\`, "/"))
+	if len(p) > 1 {
+		p = strings.TrimRight(path.Clean(p), "/")
+	}
+	return p
+}
+
+func matchWhitelistEntry(pattern, target string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return target == pattern || strings.HasPrefix(target, pattern+"/")
+	}
+
+	segments := strings.Split(target, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, seg := range segments {
+			if ok, _ := path.Match(pattern, seg); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), segments)
+}
+
+// matchSegments reports whether pattern matches a leading run of segments,
+// meaning the target is the matched path or lies beneath it.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// whitelistSchemaVersion is the current version of the whitelist file format.
+// Version 1 files (no "version" field) only contain protected_paths.
+const whitelistSchemaVersion = 2
+
+// whitelistConfig is the on-disk format for the whitelist file. Active
+// patterns are also written to protected_paths so that older releases, which
+// only understand that field, keep honouring the whitelist.
+type whitelistConfig struct {
+	Version int              `json:"version,omitempty"`
+	Paths   []string         `json:"protected_paths"`
+	Entries []WhitelistEntry `json:"entries,omitempty"`
+}
+
+// WhitelistEntry is a protected path or glob pattern with optional metadata.
+type WhitelistEntry struct {
+	Pattern string     `json:"pattern"`
+	Comment string     `json:"comment,omitempty"`
+	Added   *time.Time `json:"added,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// Expired reports whether the entry has an expiry date at or before now.
+func (e WhitelistEntry) Expired(now time.Time) bool {
+	return e.Expires != nil && !now.Before(*e.Expires)
+}
+
+// Whitelist is the set of protected entries stored in whitelist.json.
+type Whitelist struct {
+	Entries []WhitelistEntry
+}
+
+func getWhitelistPath() string {
+	configDir, err := utils.GetConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "whitelist.json")
+}
+
+// LoadWhitelist reads the whitelist file. A missing file yields an empty whitelist.
+func LoadWhitelist() (*Whitelist, error) {
+	path := getWhitelistPath()
+	if path == "" {
+		return nil, fmt.Errorf("cannot determine config directory")
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Whitelist{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read whitelist file: %w", err)
+	}
+
+	return ParseWhitelist(data)
+}
+
+// ParseWhitelist decodes whitelist data in any supported schema version.
+func ParseWhitelist(data []byte) (*Whitelist, error) {
+	var cfg whitelistConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid whitelist file: %w", err)
+	}
+
+	if cfg.Version > whitelistSchemaVersion {
+		return nil, fmt.Errorf("whitelist schema version %d is newer than supported version %d",
+			cfg.Version, whitelistSchemaVersion)
+	}
+
+	wl := &Whitelist{}
+	if cfg.Version >= 2 {
+		for _, e := range cfg.Entries {
+			wl.Add(e)
+		}
+		return wl, nil
+	}
+
+	for _, p := range cfg.Paths {
+		wl.Add(WhitelistEntry{Pattern: p})
+	}
+	return wl, nil
+}
+
+// Marshal encodes the whitelist in the current schema version.
+func (w *Whitelist) Marshal() ([]byte, error) {
+	cfg := whitelistConfig{
+		Version: whitelistSchemaVersion,
+		Paths:   []string{},
+		Entries: w.Entries,
+	}
+
+	now := time.Now()
+	for _, e := range w.Entries {
+		if !e.Expired(now) {
+			cfg.Paths = append(cfg.Paths, e.Pattern)
+		}
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal whitelist: %w", err)
+	}
+	return data, nil
+}
+
+// Save writes the whitelist to the config directory.
+func (w *Whitelist) Save() error {
+	wlPath := getWhitelistPath()
+	if wlPath == "" {
+		return fmt.Errorf("cannot determine config directory")
+	}
+
+	data, err := w.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(wlPath, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write whitelist file: %w", err)
+	}
+
+	return nil
+}
+
+// Find returns the index of the entry equivalent to pattern, or -1.
+func (w *Whitelist) Find(pattern string) int {
+	norm := normalizeWhitelistPath(pattern)
+	for i, e := range w.Entries {
+		if normalizeWhitelistPath(e.Pattern) == norm {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add inserts entry, replacing an existing entry for the same pattern.
+// It reports whether the pattern was new.
+func (w *Whitelist) Add(entry WhitelistEntry) bool {
+	entry.Pattern = strings.TrimSpace(entry.Pattern)
+	if entry.Pattern == "" {
+		return false
+	}
+
+	if i := w.Find(entry.Pattern); i >= 0 {
+		w.Entries[i] = entry
+		return false
+	}
+	w.Entries = append(w.Entries, entry)
+	return true
+}
+
+// Remove deletes the entry for pattern and reports whether it existed.
+func (w *Whitelist) Remove(pattern string) bool {
+	i := w.Find(pattern)
+	if i < 0 {
+		return false
+	}
+	w.Entries = append(w.Entries[:i], w.Entries[i+1:]...)
+	return true
+}
+
+// AbsWhitelistPattern makes a plain path absolute, since cleanup compares
+// entries with absolute paths. Glob patterns are returned unchanged.
+func AbsWhitelistPattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || strings.ContainsAny(pattern, "*?[") {
+		return pattern
+	}
+	if abs, err := filepath.Abs(pattern); err == nil {
+		return abs
+	}
+	return pattern
+}
+
+// ParseWhitelistExpiry parses a YYYY-MM-DD expiry date. An entry stays active
+// through the whole of the named day, so the expiry is the end of that day in
+// local time.
+func ParseWhitelistExpiry(date string) (time.Time, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// ValidateWhitelistPattern checks that pattern is usable as a whitelist entry:
+// glob patterns must be syntactically valid and plain paths must exist.
+func ValidateWhitelistPattern(pattern string) error {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return fmt.Errorf("empty path or pattern")
+	}
+
+	if strings.ContainsAny(pattern, "*?[") {
+		norm := normalizeWhitelistPath(pattern)
+		for _, seg := range strings.Split(norm, "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+		return nil
+	}
+
+	if !utils.PathExists(pattern) {
+		return fmt.Errorf("path does not exist: %s", pattern)
+	}
+	return nil
+}
+
+// loadWhitelist returns the active (unexpired) whitelist patterns, lower-cased.
+// Errors are ignored so that a damaged file never blocks a cleanup.
+func loadWhitelist() map[string]bool {
+	result := make(map[string]bool)
+
+	wl, err := LoadWhitelist()
+	if err != nil {
+		return result
+	}
+
+	now := time.Now()
+	for _, e := range wl.Entries {
+		if !e.Expired(now) {
+			result[strings.ToLower(e.Pattern)] = true
+		}
+	}
+
+	return result
+}
+
+// ManageWhitelist provides interactive whitelist management.
+func ManageWhitelist() {
+	color.Cyan("\nWhitelist Management")
+	color.White("════════════════════════════════════════════════════════\n")
+	color.White("Protected paths will not be cleaned during cleanup operations.\n")
+	color.White("Entries may be folders, files or glob patterns (e.g. C:\\Temp\\keep*, **\\*.pst).\n")
+
+	wl, err := LoadWhitelist()
+	if err != nil {
+		color.Red("Error loading whitelist: %v", err)
+		return
+	}
+
+	if len(wl.Entries) > 0 {
+		color.White("\nCurrently protected paths:\n")
+		for i, e := range wl.Entries {
+			fmt.Printf("  %d. %s\n", i+1, e.Pattern)
+		}
+	} else {
+		color.Yellow("\nNo paths are currently protected.\n")
+	}
+
+	fmt.Println()
+	color.White("Options:\n")
+	color.White("  1. Add a path to whitelist\n")
+	color.White("  2. Remove a path from whitelist\n")
+	color.White("  3. Exit whitelist management\n")
+	fmt.Print("\nSelect option (1-3): ")
+
+	reader := bufio.NewReader(os.Stdin)
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		fmt.Print("Enter path or pattern to protect: ")
+		path, _ := reader.ReadString('\n')
+		path = AbsWhitelistPattern(path)
+		if path == "" {
+			color.Yellow("No path entered.")
+			return
+		}
+		now := time.Now()
+		wl.Add(WhitelistEntry{Pattern: path, Added: &now})
+		if err := wl.Save(); err != nil {
+			color.Red("Error saving whitelist: %v", err)
+			return
+		}
+		color.Green("Path added to whitelist: %s", path)
+
+	case "2":
+		fmt.Print("Enter path or pattern to remove: ")
+		path, _ := reader.ReadString('\n')
+		path = strings.TrimSpace(path)
+		if path == "" {
+			color.Yellow("No path entered.")
+			return
+		}
+		if !wl.Remove(path) {
+			color.Yellow("Path not found in whitelist.")
+			return
+		}
+		if err := wl.Save(); err != nil {
+			color.Red("Error saving whitelist: %v", err)
+			return
+		}
+		color.Green("Path removed from whitelist: %s", path)
+
+	case "3":
+		return
+
+	default:
+		color.Yellow("Invalid option.")
+	}
+}
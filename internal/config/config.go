@@ -0,0 +1,536 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// FileName is the name of the configuration file inside the config directory.
+const FileName = "config.toml"
+
+// EnvPrefix prefixes the environment variables that override settings, e.g.
+// BURROW_ANALYZE_DEPTH overrides analyze.depth. BURROW_PROFILE selects a profile.
+const EnvPrefix = "BURROW_"
+
+// Settings holds the effective user defaults for every command.
+type Settings struct {
+	Clean    CleanSettings    `toml:"clean"`
+	Analyze  AnalyzeSettings  `toml:"analyze"`
+	Optimize OptimizeSettings `toml:"optimize"`
+	Watch    WatchSettings    `toml:"watch"`
+	Logs     LogsSettings     `toml:"logs"`
+	Output   OutputSettings   `toml:"output"`
+}
+
+// CleanSettings configures `wm clean`.
+type CleanSettings struct {
+	Categories     []string `toml:"categories"`
+	Retries        int      `toml:"retries"`
+	RecycleBinDays int      `toml:"recycle_bin_days"` // 0 empties the Recycle Bin completely
+
+	LogAction      string `toml:"log_action"`      // "delete" or "compress"
+	CompressDays   int    `toml:"compress_days"`   // compress logs older than this
+	CompressFormat string `toml:"compress_format"` // "gzip" or "zip"
+	CompressDir    string `toml:"compress_dir"`    // empty compresses in place
+}
+
+// AnalyzeSettings configures `wm analyze`.
+type AnalyzeSettings struct {
+	Depth       int    `toml:"depth"`
+	MinSize     int64  `toml:"min_size"`
+	Hidden      bool   `toml:"hidden"`
+	FollowLinks bool   `toml:"follow_links"`
+	Cache       bool   `toml:"cache"`
+	Workers     int    `toml:"workers"`
+	Size        string `toml:"size"` // "apparent" or "allocated"
+
+	Exclude       []string `toml:"exclude"`
+	OneFileSystem bool     `toml:"one_file_system"`
+}
+
+// OptimizeSettings configures `wm optimize`.
+type OptimizeSettings struct {
+	DisabledTasks []string `toml:"disabled_tasks"`
+}
+
+// WatchSettings configures `wm watch`. A volume is low on space when its
+// free space is below min_free_percent of its capacity or below min_free_gb,
+// whichever is larger; a threshold of 0 is ignored.
+type WatchSettings struct {
+	MinFreePercent    int      `toml:"min_free_percent"`
+	MinFreeGB         int      `toml:"min_free_gb"`
+	HysteresisPercent int      `toml:"hysteresis_percent"`
+	Cooldown          int      `toml:"cooldown"` // minutes
+	Interval          int      `toml:"interval"` // seconds
+	Profile           string   `toml:"profile"`
+	Mounts            []string `toml:"mounts"`
+}
+
+// LogsSettings configures the logs written by `wm daemon` and `wm watch`.
+type LogsSettings struct {
+	MaxSize int64 `toml:"max_size"` // MB before a log is rotated, 0 never rotates
+	Keep    int   `toml:"keep"`     // rotated files to retain
+}
+
+// OutputSettings configures how results are printed.
+type OutputSettings struct {
+	Format string `toml:"format"`
+}
+
+// Output formats accepted by output.format.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// fileFormat is the on-disk layout of config.toml: top-level settings plus
+// named profiles that override any subset of them.
+type fileFormat struct {
+	Settings
+	Profiles map[string]toml.Primitive `toml:"profiles"`
+}
+
+// Defaults returns the built-in settings used when nothing is configured.
+func Defaults() *Settings {
+	return &Settings{
+		Clean:   CleanSettings{Retries: 3, LogAction: "delete", CompressDays: 7, CompressFormat: "gzip"},
+		Analyze: AnalyzeSettings{Depth: 3, Cache: true, Size: "apparent"},
+		Watch: WatchSettings{
+			MinFreePercent:    10,
+			HysteresisPercent: 5,
+			Cooldown:          60,
+			Interval:          60,
+		},
+		Logs:   LogsSettings{MaxSize: 10, Keep: 3},
+		Output: OutputSettings{Format: FormatText},
+	}
+}
+
+// Path returns the location of config.toml.
+func Path() (string, error) {
+	dir, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load returns the effective settings: built-in defaults, overlaid by
+// config.toml, then by the named profile (or BURROW_PROFILE when profile is
+// empty), then by BURROW_* environment variables. A missing file is not an
+// error; out-of-range values are.
+func Load(profile string) (*Settings, error) {
+	s := Defaults()
+
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	if profile == "" {
+		profile = os.Getenv(EnvPrefix + "PROFILE")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+
+	if err := decode(data, profile, s); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnv(s); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings: %w", err)
+	}
+
+	return s, nil
+}
+
+// decode overlays the settings in data, and then the named profile, onto s.
+func decode(data []byte, profile string, s *Settings) error {
+	file := fileFormat{Settings: *s}
+	md, err := toml.Decode(string(data), &file)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	*s = file.Settings
+
+	if profile == "" {
+		return nil
+	}
+
+	prim, ok := file.Profiles[profile]
+	if !ok {
+		return fmt.Errorf("profile %q is not defined in %s", profile, FileName)
+	}
+	if err := md.PrimitiveDecode(prim, s); err != nil {
+		return fmt.Errorf("invalid profile %q: %w", profile, err)
+	}
+	return nil
+}
+
+// Profiles returns the names of the profiles defined in config.toml.
+func Profiles() ([]string, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	var file fileFormat
+	if _, err := toml.DecodeFile(path, &file); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Validate checks the settings for out-of-range values.
+func (s *Settings) Validate() error {
+	var problems []string
+
+	if s.Clean.Retries < 1 || s.Clean.Retries > 10 {
+		problems = append(problems, fmt.Sprintf("clean.retries must be between 1 and 10, got %d", s.Clean.Retries))
+	}
+	if s.Clean.RecycleBinDays < 0 {
+		problems = append(problems, fmt.Sprintf("clean.recycle_bin_days must not be negative, got %d", s.Clean.RecycleBinDays))
+	}
+	if s.Clean.LogAction != "delete" && s.Clean.LogAction != "compress" {
+		problems = append(problems, fmt.Sprintf("clean.log_action must be \"delete\" or \"compress\", got %q", s.Clean.LogAction))
+	}
+	if s.Clean.CompressDays < 0 {
+		problems = append(problems, fmt.Sprintf("clean.compress_days must not be negative, got %d", s.Clean.CompressDays))
+	}
+	if s.Clean.CompressFormat != "gzip" && s.Clean.CompressFormat != "zip" {
+		problems = append(problems, fmt.Sprintf("clean.compress_format must be \"gzip\" or \"zip\", got %q", s.Clean.CompressFormat))
+	}
+	if s.Analyze.Depth < 1 || s.Analyze.Depth > 10 {
+		problems = append(problems, fmt.Sprintf("analyze.depth must be between 1 and 10, got %d", s.Analyze.Depth))
+	}
+	if s.Analyze.Workers < 0 || s.Analyze.Workers > 256 {
+		problems = append(problems, fmt.Sprintf("analyze.workers must be between 0 (automatic) and 256, got %d", s.Analyze.Workers))
+	}
+	if s.Analyze.MinSize < 0 {
+		problems = append(problems, fmt.Sprintf("analyze.min_size must not be negative, got %d", s.Analyze.MinSize))
+	}
+	if s.Analyze.Size != "apparent" && s.Analyze.Size != "allocated" {
+		problems = append(problems, fmt.Sprintf("analyze.size must be \"apparent\" or \"allocated\", got %q", s.Analyze.Size))
+	}
+	if _, err := utils.NewExcludeList(s.Analyze.Exclude); err != nil {
+		problems = append(problems, fmt.Sprintf("analyze.exclude: %v", err))
+	}
+	if s.Watch.MinFreePercent < 0 || s.Watch.MinFreePercent > 100 {
+		problems = append(problems, fmt.Sprintf("watch.min_free_percent must be between 0 and 100, got %d", s.Watch.MinFreePercent))
+	}
+	if s.Watch.MinFreeGB < 0 {
+		problems = append(problems, fmt.Sprintf("watch.min_free_gb must not be negative, got %d", s.Watch.MinFreeGB))
+	}
+	if s.Watch.HysteresisPercent < 0 || s.Watch.HysteresisPercent > 50 {
+		problems = append(problems, fmt.Sprintf("watch.hysteresis_percent must be between 0 and 50, got %d", s.Watch.HysteresisPercent))
+	}
+	if s.Watch.Cooldown < 0 {
+		problems = append(problems, fmt.Sprintf("watch.cooldown must not be negative, got %d", s.Watch.Cooldown))
+	}
+	if s.Watch.Interval < 5 {
+		problems = append(problems, fmt.Sprintf("watch.interval must be at least 5 seconds, got %d", s.Watch.Interval))
+	}
+	if s.Logs.MaxSize < 0 {
+		problems = append(problems, fmt.Sprintf("logs.max_size must not be negative, got %d", s.Logs.MaxSize))
+	}
+	if s.Logs.Keep < 0 || s.Logs.Keep > 100 {
+		problems = append(problems, fmt.Sprintf("logs.keep must be between 0 and 100, got %d", s.Logs.Keep))
+	}
+	if s.Output.Format != FormatText && s.Output.Format != FormatJSON {
+		problems = append(problems, fmt.Sprintf("output.format must be %q or %q, got %q", FormatText, FormatJSON, s.Output.Format))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ValidateFile checks config.toml for syntax errors, unknown keys and invalid
+// values, including every profile applied on top of the base settings.
+func ValidateFile() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+
+	return validateData(data)
+}
+
+func validateData(data []byte) error {
+	file := fileFormat{Settings: *Defaults()}
+	md, err := toml.Decode(string(data), &file)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+
+	if err := file.Settings.Validate(); err != nil {
+		return err
+	}
+
+	for name, prim := range file.Profiles {
+		s := file.Settings
+		if err := md.PrimitiveDecode(prim, &s); err != nil {
+			return fmt.Errorf("invalid profile %q: %w", name, err)
+		}
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		var keys []string
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		return fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+	}
+
+	return nil
+}
+
+// Encode renders s as TOML.
+func (s *Settings) Encode() (string, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(s); err != nil {
+		return "", fmt.Errorf("cannot encode settings: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Keys returns the names of all settings, e.g. "analyze.depth".
+func Keys() []string {
+	names := make([]string, 0, len(settingKeys))
+	for _, k := range settingKeys {
+		names = append(names, k.name)
+	}
+	return names
+}
+
+// Get returns the value of the named setting formatted as a string.
+func (s *Settings) Get(name string) (string, error) {
+	k, err := lookupKey(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := k.field(s).(type) {
+	case *int:
+		return strconv.Itoa(*v), nil
+	case *int64:
+		return strconv.FormatInt(*v, 10), nil
+	case *bool:
+		return strconv.FormatBool(*v), nil
+	case *string:
+		return *v, nil
+	case *[]string:
+		return strings.Join(*v, ","), nil
+	}
+	return "", fmt.Errorf("unsupported setting %s", name)
+}
+
+// Set parses value and assigns it to the named setting.
+func (s *Settings) Set(name, value string) error {
+	k, err := lookupKey(name)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseValue(k.field(s), value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", name, err)
+	}
+
+	switch v := k.field(s).(type) {
+	case *int:
+		*v = int(parsed.(int64))
+	case *int64:
+		*v = parsed.(int64)
+	case *bool:
+		*v = parsed.(bool)
+	case *string:
+		*v = parsed.(string)
+	case *[]string:
+		*v = parsed.([]string)
+	}
+	return nil
+}
+
+// SetInFile writes a single setting to config.toml, either at the top level or
+// inside the named profile. The value is validated before the file is written.
+func SetInFile(profile, name, value string) error {
+	k, err := lookupKey(name)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseValue(k.field(Defaults()), value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", name, err)
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	raw := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+
+	section := raw
+	if profile != "" {
+		section = subTable(subTable(raw, "profiles"), profile)
+	}
+	parts := strings.SplitN(name, ".", 2)
+	subTable(section, parts[0])[parts[1]] = parsed
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return fmt.Errorf("cannot encode %s: %w", FileName, err)
+	}
+
+	if err := validateData(buf.Bytes()); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	return nil
+}
+
+func subTable(m map[string]interface{}, key string) map[string]interface{} {
+	if t, ok := m[key].(map[string]interface{}); ok {
+		return t
+	}
+	t := make(map[string]interface{})
+	m[key] = t
+	return t
+}
+
+// settingKey describes one configurable value. field returns a pointer to
+// the value inside a Settings.
+type settingKey struct {
+	name  string
+	field func(s *Settings) interface{}
+}
+
+var settingKeys = []settingKey{
+	{"clean.categories", func(s *Settings) interface{} { return &s.Clean.Categories }},
+	{"clean.retries", func(s *Settings) interface{} { return &s.Clean.Retries }},
+	{"clean.recycle_bin_days", func(s *Settings) interface{} { return &s.Clean.RecycleBinDays }},
+	{"clean.log_action", func(s *Settings) interface{} { return &s.Clean.LogAction }},
+	{"clean.compress_days", func(s *Settings) interface{} { return &s.Clean.CompressDays }},
+	{"clean.compress_format", func(s *Settings) interface{} { return &s.Clean.CompressFormat }},
+	{"clean.compress_dir", func(s *Settings) interface{} { return &s.Clean.CompressDir }},
+	{"analyze.depth", func(s *Settings) interface{} { return &s.Analyze.Depth }},
+	{"analyze.min_size", func(s *Settings) interface{} { return &s.Analyze.MinSize }},
+	{"analyze.hidden", func(s *Settings) interface{} { return &s.Analyze.Hidden }},
+	{"analyze.follow_links", func(s *Settings) interface{} { return &s.Analyze.FollowLinks }},
+	{"analyze.cache", func(s *Settings) interface{} { return &s.Analyze.Cache }},
+	{"analyze.workers", func(s *Settings) interface{} { return &s.Analyze.Workers }},
+	{"analyze.size", func(s *Settings) interface{} { return &s.Analyze.Size }},
+	{"analyze.exclude", func(s *Settings) interface{} { return &s.Analyze.Exclude }},
+	{"analyze.one_file_system", func(s *Settings) interface{} { return &s.Analyze.OneFileSystem }},
+	{"optimize.disabled_tasks", func(s *Settings) interface{} { return &s.Optimize.DisabledTasks }},
+	{"watch.min_free_percent", func(s *Settings) interface{} { return &s.Watch.MinFreePercent }},
+	{"watch.min_free_gb", func(s *Settings) interface{} { return &s.Watch.MinFreeGB }},
+	{"watch.hysteresis_percent", func(s *Settings) interface{} { return &s.Watch.HysteresisPercent }},
+	{"watch.cooldown", func(s *Settings) interface{} { return &s.Watch.Cooldown }},
+	{"watch.interval", func(s *Settings) interface{} { return &s.Watch.Interval }},
+	{"watch.profile", func(s *Settings) interface{} { return &s.Watch.Profile }},
+	{"watch.mounts", func(s *Settings) interface{} { return &s.Watch.Mounts }},
+	{"logs.max_size", func(s *Settings) interface{} { return &s.Logs.MaxSize }},
+	{"logs.keep", func(s *Settings) interface{} { return &s.Logs.Keep }},
+	{"output.format", func(s *Settings) interface{} { return &s.Output.Format }},
+}
+
+func lookupKey(name string) (settingKey, error) {
+	for _, k := range settingKeys {
+		if k.name == name {
+			return k, nil
+		}
+	}
+	return settingKey{}, fmt.Errorf("unknown setting %q (valid: %s)", name, strings.Join(Keys(), ", "))
+}
+
+// parseValue converts value to the type of the field pointer.
+func parseValue(field interface{}, value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+
+	switch field.(type) {
+	case *int, *int64:
+		return strconv.ParseInt(value, 10, 64)
+	case *bool:
+		return strconv.ParseBool(value)
+	case *string:
+		return value, nil
+	case *[]string:
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", field)
+}
+
+// applyEnv overrides settings from BURROW_<SECTION>_<KEY> environment variables.
+func applyEnv(s *Settings) error {
+	for _, k := range settingKeys {
+		env := EnvName(k.name)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := s.Set(k.name, value); err != nil {
+			return fmt.Errorf("%s: %w", env, err)
+		}
+	}
+	return nil
+}
+
+// EnvName returns the environment variable that overrides the named setting.
+func EnvName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+}
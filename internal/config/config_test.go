@@ -0,0 +1,206 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sampleConfig = `
+[clean]
+categories = ["temp", "cache"]
+retries = 5
+
+[analyze]
+depth = 4
+
+[profiles.ci.clean]
+retries = 1
+
+[profiles.ci.output]
+format = "json"
+
+[profiles.aggressive.clean]
+categories = ["temp", "cache", "browser", "updates", "logs"]
+`
+
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	os.Setenv("APPDATA", tmpDir)
+	t.Cleanup(func() { os.Unsetenv("APPDATA") })
+
+	dir := filepath.Join(tmpDir, "Burrow")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if content != "" {
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	writeConfig(t, "")
+
+	s, err := Load("")
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if !reflect.DeepEqual(s, Defaults()) {
+		t.Errorf("Load without a file = %+v, want defaults %+v", s, Defaults())
+	}
+}
+
+func TestLoadFileAndProfile(t *testing.T) {
+	writeConfig(t, sampleConfig)
+
+	s, err := Load("")
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if s.Clean.Retries != 5 || s.Analyze.Depth != 4 {
+		t.Errorf("base settings = %+v", s)
+	}
+	if s.Output.Format != FormatText {
+		t.Errorf("unset keys should keep defaults, got format %q", s.Output.Format)
+	}
+
+	ci, err := Load("ci")
+	if err != nil {
+		t.Fatalf("Load(ci) error: %v", err)
+	}
+	if ci.Clean.Retries != 1 || ci.Output.Format != FormatJSON {
+		t.Errorf("ci profile not applied: %+v", ci)
+	}
+	if !reflect.DeepEqual(ci.Clean.Categories, []string{"temp", "cache"}) {
+		t.Errorf("profile should inherit base categories, got %v", ci.Clean.Categories)
+	}
+	if ci.Analyze.Depth != 4 {
+		t.Errorf("profile should inherit base depth, got %d", ci.Analyze.Depth)
+	}
+
+	if _, err := Load("missing"); err == nil {
+		t.Error("Load of an undefined profile should fail")
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	writeConfig(t, sampleConfig)
+
+	t.Setenv("BURROW_PROFILE", "aggressive")
+	t.Setenv("BURROW_ANALYZE_DEPTH", "7")
+	t.Setenv("BURROW_OPTIMIZE_DISABLED_TASKS", "reset_network, check_system_files")
+
+	s, err := Load("")
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if len(s.Clean.Categories) != 5 {
+		t.Errorf("BURROW_PROFILE not applied: categories = %v", s.Clean.Categories)
+	}
+	if s.Analyze.Depth != 7 {
+		t.Errorf("Analyze.Depth = %d, want 7", s.Analyze.Depth)
+	}
+	if !reflect.DeepEqual(s.Optimize.DisabledTasks, []string{"reset_network", "check_system_files"}) {
+		t.Errorf("DisabledTasks = %v", s.Optimize.DisabledTasks)
+	}
+
+	t.Setenv("BURROW_CLEAN_RETRIES", "lots")
+	if _, err := Load(""); err == nil {
+		t.Error("Load should reject a malformed environment override")
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	writeConfig(t, "[analyze]\ndepth = 40\n")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "analyze.depth") {
+		t.Errorf("out-of-range file value not rejected, got %v", err)
+	}
+
+	writeConfig(t, sampleConfig)
+	t.Setenv("BURROW_OUTPUT_FORMAT", "xml")
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "output.format") {
+		t.Errorf("invalid environment override not rejected, got %v", err)
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	s := Defaults()
+
+	if err := s.Set("clean.categories", "temp, logs"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get("clean.categories"); got != "temp,logs" {
+		t.Errorf("Get(clean.categories) = %q", got)
+	}
+
+	if err := s.Set("analyze.hidden", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Analyze.Hidden {
+		t.Error("analyze.hidden not set")
+	}
+
+	if err := s.Set("analyze.depth", "deep"); err == nil {
+		t.Error("Set should reject a non-numeric depth")
+	}
+	if _, err := s.Get("no.such.key"); err == nil {
+		t.Error("Get should reject an unknown key")
+	}
+}
+
+func TestSetInFile(t *testing.T) {
+	writeConfig(t, sampleConfig)
+
+	if err := SetInFile("", "analyze.depth", "6"); err != nil {
+		t.Fatalf("SetInFile error: %v", err)
+	}
+	if err := SetInFile("nightly", "clean.retries", "2"); err != nil {
+		t.Fatalf("SetInFile profile error: %v", err)
+	}
+	if err := SetInFile("", "analyze.depth", "42"); err == nil {
+		t.Error("SetInFile should reject an out-of-range value")
+	}
+
+	s, err := Load("nightly")
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if s.Analyze.Depth != 6 || s.Clean.Retries != 2 {
+		t.Errorf("settings after SetInFile = %+v", s)
+	}
+
+	names, err := Profiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"aggressive", "ci", "nightly"}) {
+		t.Errorf("Profiles = %v", names)
+	}
+}
+
+func TestValidateFile(t *testing.T) {
+	writeConfig(t, sampleConfig)
+	if err := ValidateFile(); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+
+	writeConfig(t, "[analyze]\ndepth = 3\ncolour = true\n")
+	if err := ValidateFile(); err == nil || !strings.Contains(err.Error(), "colour") {
+		t.Errorf("unknown key not reported, got %v", err)
+	}
+
+	writeConfig(t, "[profiles.bad.output]\nformat = \"xml\"\n")
+	if err := ValidateFile(); err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("invalid profile not reported, got %v", err)
+	}
+
+	writeConfig(t, "[watch]\nmin_free_percent = 150\n")
+	if err := ValidateFile(); err == nil || !strings.Contains(err.Error(), "watch.min_free_percent") {
+		t.Errorf("out-of-range watch threshold not reported, got %v", err)
+	}
+}
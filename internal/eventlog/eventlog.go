@@ -0,0 +1,286 @@
+// Package eventlog lists, archives and clears Windows event log channels
+// through wevtutil.
+package eventlog
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const wevtutil = "wevtutil"
+
+// Channel describes one event log channel and its .evtx file.
+type Channel struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Size    int64  `json:"size"`
+	Records int64  `json:"records"` // -1 when wevtutil cannot report it
+}
+
+// ArchiveResult describes a bundle written by Archive or ArchiveAndClear.
+type ArchiveResult struct {
+	Path       string   `json:"path"`
+	Channels   []string `json:"channels"`
+	Size       int64    `json:"size"`       // exported bytes before compression
+	Compressed int64    `json:"compressed"` // size of the bundle
+	Cleared    []string `json:"cleared,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// Manager works on the channels whose log files live in one directory.
+type Manager struct {
+	runner Runner
+	dir    string
+}
+
+// NewManager creates a Manager that runs wevtutil through runner and reads
+// log files from dir.
+func NewManager(runner Runner, dir string) *Manager {
+	return &Manager{runner: runner, dir: dir}
+}
+
+// DefaultDir returns the folder Windows keeps .evtx files in, or "" when
+// WINDIR is not set.
+func DefaultDir() string {
+	windir := os.Getenv("WINDIR")
+	if windir == "" {
+		return ""
+	}
+	return filepath.Join(windir, "System32", "winevt", "Logs")
+}
+
+// ChannelName returns the channel stored in an .evtx file. Windows writes the
+// "/" of channel names such as "Microsoft-Windows-PowerShell/Operational" as
+// "%4" in file names.
+func ChannelName(file string) string {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return strings.ReplaceAll(name, "%4", "/")
+}
+
+// FileName returns the .evtx file name used for channel.
+func FileName(channel string) string {
+	return strings.ReplaceAll(channel, "/", "%4") + ".evtx"
+}
+
+// List returns the channels whose log file is at least minSize bytes,
+// largest first. Record counts are only queried for the channels returned.
+func (m *Manager) List(minSize int64) ([]Channel, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read event log folder: %w", err)
+	}
+
+	var channels []Channel
+	for _, e := range entries {
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".evtx") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.Size() < minSize {
+			continue
+		}
+		channels = append(channels, Channel{
+			Name: ChannelName(e.Name()),
+			File: filepath.Join(m.dir, e.Name()),
+			Size: info.Size(),
+		})
+	}
+
+	sort.Slice(channels, func(i, j int) bool {
+		if channels[i].Size != channels[j].Size {
+			return channels[i].Size > channels[j].Size
+		}
+		return channels[i].Name < channels[j].Name
+	})
+
+	for i := range channels {
+		n, err := m.records(channels[i].Name)
+		if err != nil {
+			n = -1
+		}
+		channels[i].Records = n
+	}
+	return channels, nil
+}
+
+// records returns the number of records in channel from `wevtutil gli`.
+func (m *Manager) records(channel string) (int64, error) {
+	out, err := m.runner.Run(wevtutil, "gli", channel)
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(key) == "numberOfLogRecords" {
+			return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		}
+	}
+	return 0, fmt.Errorf("no record count reported for %s", channel)
+}
+
+// Archive exports channels and writes them to a zip bundle at dest. The
+// bundle is only created once every channel has been exported.
+func (m *Manager) Archive(channels []string, dest string) (*ArchiveResult, error) {
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("no channels to archive")
+	}
+
+	tmp, err := os.MkdirTemp("", "burrow-evtx-")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create export folder: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	result := &ArchiveResult{Path: dest}
+	var exported []string
+	for _, ch := range channels {
+		out := filepath.Join(tmp, FileName(ch))
+		if _, err := m.runner.Run(wevtutil, "epl", ch, out, "/ow:true"); err != nil {
+			return nil, fmt.Errorf("cannot export %s: %w", ch, err)
+		}
+		exported = append(exported, out)
+		result.Channels = append(result.Channels, ch)
+	}
+
+	size, err := writeBundle(dest, exported)
+	if err != nil {
+		return nil, err
+	}
+	result.Size = size
+
+	if info, err := os.Stat(dest); err == nil {
+		result.Compressed = info.Size()
+	}
+	return result, nil
+}
+
+// ArchiveAndClear clears channels and writes the records they held to a zip
+// bundle at dest. Each channel is backed up and cleared by one
+// `wevtutil cl /bu:` call, so no event written in between is lost. A channel
+// that fails is left alone and reported in the result's Errors. The backups
+// are made beside dest rather than in the temp folder, and are kept there if
+// the bundle cannot be written.
+func (m *Manager) ArchiveAndClear(channels []string, dest string) (*ArchiveResult, error) {
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("no channels to archive")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create %s: %w", filepath.Dir(dest), err)
+	}
+	backups, err := os.MkdirTemp(filepath.Dir(dest), "eventlogs-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create backup folder: %w", err)
+	}
+
+	result := &ArchiveResult{Path: dest}
+	var files []string
+	for _, ch := range channels {
+		out := filepath.Join(backups, FileName(ch))
+		if _, err := m.runner.Run(wevtutil, "cl", ch, "/bu:"+out); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("cannot clear %s: %v", ch, err))
+			continue
+		}
+		files = append(files, out)
+		result.Channels = append(result.Channels, ch)
+		result.Cleared = append(result.Cleared, ch)
+	}
+
+	if len(files) == 0 {
+		os.RemoveAll(backups)
+		return nil, fmt.Errorf("no channel could be cleared: %s", strings.Join(result.Errors, "; "))
+	}
+
+	size, err := writeBundle(dest, files)
+	if err != nil {
+		return nil, fmt.Errorf("%w; the cleared records are kept in %s", err, backups)
+	}
+	os.RemoveAll(backups)
+	result.Size = size
+
+	if info, err := os.Stat(dest); err == nil {
+		result.Compressed = info.Size()
+	}
+	return result, nil
+}
+
+// Clear removes every record from channel.
+func (m *Manager) Clear(channel string) error {
+	if _, err := m.runner.Run(wevtutil, "cl", channel); err != nil {
+		return fmt.Errorf("cannot clear %s: %w", channel, err)
+	}
+	return nil
+}
+
+// writeBundle compresses files into a zip archive at dest and returns their
+// total uncompressed size. The archive is written to a temporary name and
+// renamed into place so a failed run leaves no partial bundle behind.
+func writeBundle(dest string, files []string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return 0, fmt.Errorf("cannot create %s: %w", filepath.Dir(dest), err)
+	}
+
+	tmp := dest + ".partial"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, fmt.Errorf("cannot create bundle: %w", err)
+	}
+
+	size, err := addFiles(zip.NewWriter(f), files)
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("cannot write bundle: %w", cerr)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("cannot write bundle: %w", err)
+	}
+	return size, nil
+}
+
+func addFiles(zw *zip.Writer, files []string) (int64, error) {
+	var total int64
+	for _, path := range files {
+		n, err := addFile(zw, path)
+		if err != nil {
+			return 0, fmt.Errorf("cannot add %s to bundle: %w", filepath.Base(path), err)
+		}
+		total += n
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("cannot write bundle: %w", err)
+	}
+	return total, nil
+}
+
+func addFile(zw *zip.Writer, path string) (int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     filepath.Base(path),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, src)
+}
@@ -0,0 +1,187 @@
+package eventlog
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeRunner stands in for wevtutil. Exports and backups write the channel
+// name to the output file; fail names the subcommand and channel that should
+// error.
+type fakeRunner struct {
+	records map[string]int64
+	fail    map[string]bool
+	calls   []string
+}
+
+func (f *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, strings.Join(args[:2], " "))
+	if f.fail[args[0]+" "+args[1]] {
+		return nil, errors.New("access denied")
+	}
+	switch args[0] {
+	case "gli":
+		n, ok := f.records[args[1]]
+		if !ok {
+			return nil, errors.New("channel not found")
+		}
+		return []byte(fmt.Sprintf("creationTime: 2026-01-01T00:00:00.000Z\nfileSize: 69632\nnumberOfLogRecords: %d\noldestRecordNumber: 1\n", n)), nil
+	case "epl":
+		return nil, os.WriteFile(args[2], []byte("records of "+args[1]), 0o644)
+	case "cl":
+		if len(args) > 2 {
+			return nil, os.WriteFile(strings.TrimPrefix(args[2], "/bu:"), []byte("records of "+args[1]), 0o644)
+		}
+	}
+	return nil, nil
+}
+
+func writeLogs(t *testing.T, sizes map[string]int) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, size := range sizes {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestChannelName(t *testing.T) {
+	tests := []struct {
+		file, channel string
+	}{
+		{"System.evtx", "System"},
+		{"Microsoft-Windows-PowerShell%4Operational.evtx", "Microsoft-Windows-PowerShell/Operational"},
+		{"Windows PowerShell.evtx", "Windows PowerShell"},
+	}
+	for _, tt := range tests {
+		if got := ChannelName(tt.file); got != tt.channel {
+			t.Errorf("ChannelName(%q) = %q, want %q", tt.file, got, tt.channel)
+		}
+		if got := FileName(tt.channel); got != tt.file {
+			t.Errorf("FileName(%q) = %q, want %q", tt.channel, got, tt.file)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := writeLogs(t, map[string]int{
+		"System.evtx":      300,
+		"Application.evtx": 500,
+		"Setup.evtx":       10,
+		"Microsoft-Windows-PowerShell%4Operational.evtx": 300,
+		"readme.txt": 900,
+	})
+	runner := &fakeRunner{records: map[string]int64{"System": 12, "Application": 40, "Microsoft-Windows-PowerShell/Operational": 7}}
+
+	channels, err := NewManager(runner, dir).List(100)
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+
+	var got []string
+	for _, ch := range channels {
+		got = append(got, fmt.Sprintf("%s:%d:%d", ch.Name, ch.Size, ch.Records))
+	}
+	want := []string{"Application:500:40", "Microsoft-Windows-PowerShell/Operational:300:7", "System:300:12"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("List = %v, want %v", got, want)
+	}
+
+	runner.records = nil
+	channels, err = NewManager(runner, dir).List(400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 1 || channels[0].Records != -1 {
+		t.Errorf("List without record counts = %+v, want Application with -1 records", channels)
+	}
+}
+
+func TestArchiveAndClear(t *testing.T) {
+	runner := &fakeRunner{fail: map[string]bool{"cl Security": true}}
+	dir := filepath.Join(t.TempDir(), "archive")
+	dest := filepath.Join(dir, "logs.zip")
+
+	result, err := NewManager(runner, t.TempDir()).ArchiveAndClear([]string{"System", "Security", "A/Operational"}, dest)
+	if err != nil {
+		t.Fatalf("ArchiveAndClear error: %v", err)
+	}
+
+	// Backing up and clearing is one call per channel; nothing is exported
+	// separately.
+	wantCalls := []string{"cl System", "cl Security", "cl A/Operational"}
+	if !reflect.DeepEqual(runner.calls, wantCalls) {
+		t.Errorf("calls = %v, want %v", runner.calls, wantCalls)
+	}
+	if !reflect.DeepEqual(result.Cleared, []string{"System", "A/Operational"}) || len(result.Errors) != 1 {
+		t.Errorf("cleared %v, errors %v", result.Cleared, result.Errors)
+	}
+	if result.Size != int64(len("records of System")+len("records of A/Operational")) {
+		t.Errorf("Size = %d", result.Size)
+	}
+
+	zr, err := zip.OpenReader(dest)
+	if err != nil {
+		t.Fatalf("bundle not readable: %v", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"System.evtx", "A%4Operational.evtx"}) {
+		t.Errorf("bundle holds %v", names)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("backup folder left beside the bundle: %v", entries)
+	}
+}
+
+func TestArchiveAndClearKeepsBackupsWhenBundleFails(t *testing.T) {
+	runner := &fakeRunner{}
+	dir := t.TempDir()
+	// A folder in the bundle's place makes writing the bundle fail.
+	dest := filepath.Join(dir, "logs.zip")
+	if err := os.MkdirAll(filepath.Join(dest, "blocker"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewManager(runner, t.TempDir()).ArchiveAndClear([]string{"System"}, dest); err == nil {
+		t.Fatal("ArchiveAndClear succeeded without writing the bundle")
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "eventlogs-backup-*", "System.evtx"))
+	if len(backups) != 1 {
+		t.Fatalf("backup of the cleared channel not kept: %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "records of System" {
+		t.Errorf("backup holds %q", data)
+	}
+}
+
+func TestArchiveExportFailureWritesNoBundle(t *testing.T) {
+	runner := &fakeRunner{fail: map[string]bool{"epl Security": true}}
+	dest := filepath.Join(t.TempDir(), "logs.zip")
+
+	if _, err := NewManager(runner, t.TempDir()).Archive([]string{"System", "Security"}, dest); err == nil {
+		t.Fatal("Archive succeeded despite a failed export")
+	}
+	for _, call := range runner.calls {
+		if strings.HasPrefix(call, "cl ") {
+			t.Errorf("channel cleared by Archive: %s", call)
+		}
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("bundle written after a failed export")
+	}
+}
@@ -0,0 +1,34 @@
+package eventlog
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner runs an external command and returns its standard output. It lets
+// the wevtutil calls be replaced in tests.
+type Runner interface {
+	Run(name string, args ...string) ([]byte, error)
+}
+
+// ExecRunner runs commands with os/exec.
+type ExecRunner struct{}
+
+// Run executes name with args. A failing command's error includes what it
+// printed, since wevtutil reports problems on its output streams.
+func (ExecRunner) Run(name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			msg = strings.TrimSpace(string(ee.Stderr))
+		}
+		if msg != "" {
+			return out, fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return out, fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return out, nil
+}
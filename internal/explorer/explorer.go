@@ -0,0 +1,583 @@
+// Package explorer is the full-screen disk usage browser behind
+// `wm analyze --interactive`.
+package explorer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/zs0c131y/burrow/internal/analyzer"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// Options configures the explorer. Delete and Quarantine are called for each
+// confirmed item; a nil function disables that action. Expand, if set, is
+// used to load the children of a folder that was summarized beyond the
+// analysis depth.
+type Options struct {
+	ReadOnly   bool
+	Delete     func(path string) error
+	Quarantine func(path string) error
+	Expand     func(path string) (*analyzer.DiskNode, error)
+}
+
+// Run shows the explorer for root until the user quits.
+func Run(root *analyzer.DiskNode, opts Options) error {
+	_, err := tea.NewProgram(New(root, opts), tea.WithAltScreen()).Run()
+	return err
+}
+
+type sortKey int
+
+const (
+	sortBySize sortKey = iota
+	sortByCount
+	sortByModTime
+)
+
+func (k sortKey) String() string {
+	switch k {
+	case sortByCount:
+		return "item count"
+	case sortByModTime:
+		return "last modified"
+	}
+	return "size"
+}
+
+type action int
+
+const (
+	actionDelete action = iota
+	actionQuarantine
+)
+
+func (a action) verb() string {
+	if a == actionQuarantine {
+		return "Quarantine"
+	}
+	return "Delete"
+}
+
+// pending is an action waiting for confirmation.
+type pending struct {
+	action  action
+	targets []*analyzer.DiskNode
+	size    int64
+}
+
+// actionDoneMsg reports the outcome of a confirmed action.
+type actionDoneMsg struct {
+	action action
+	done   []*analyzer.DiskNode
+	errs   []error
+}
+
+// expandedMsg carries the children loaded for a summarized folder.
+type expandedMsg struct {
+	node   *analyzer.DiskNode
+	result *analyzer.DiskNode
+	err    error
+}
+
+// Model is the bubbletea model of the explorer.
+type Model struct {
+	opts    Options
+	root    *analyzer.DiskNode
+	parents map[*analyzer.DiskNode]*analyzer.DiskNode
+
+	stack  []*analyzer.DiskNode
+	cursor int
+	offset int
+	sortBy sortKey
+
+	marked  map[*analyzer.DiskNode]bool
+	confirm *pending
+	busy    bool
+	status  string
+
+	width  int
+	height int
+}
+
+// New creates an explorer model positioned at root.
+func New(root *analyzer.DiskNode, opts Options) *Model {
+	m := &Model{
+		opts:    opts,
+		root:    root,
+		parents: make(map[*analyzer.DiskNode]*analyzer.DiskNode),
+		stack:   []*analyzer.DiskNode{root},
+		marked:  make(map[*analyzer.DiskNode]bool),
+		width:   100,
+		height:  30,
+	}
+	m.indexParents(root)
+	m.sortChildren()
+	return m
+}
+
+func (m *Model) indexParents(n *analyzer.DiskNode) {
+	for _, c := range n.Children {
+		m.parents[c] = n
+		m.indexParents(c)
+	}
+}
+
+// Init implements tea.Model.
+func (m *Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m *Model) current() *analyzer.DiskNode {
+	return m.stack[len(m.stack)-1]
+}
+
+func (m *Model) selected() *analyzer.DiskNode {
+	children := m.current().Children
+	if m.cursor < 0 || m.cursor >= len(children) {
+		return nil
+	}
+	return children[m.cursor]
+}
+
+// Update implements tea.Model.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.clampCursor()
+		return m, nil
+
+	case actionDoneMsg:
+		m.finishAction(msg)
+		return m, nil
+
+	case expandedMsg:
+		m.finishExpand(msg)
+		return m, nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg.String())
+	}
+	return m, nil
+}
+
+func (m *Model) handleKey(key string) (tea.Model, tea.Cmd) {
+	if key == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if m.busy {
+		return m, nil
+	}
+
+	if m.confirm != nil {
+		p := m.confirm
+		m.confirm = nil
+		if key == "y" || key == "Y" {
+			m.busy = true
+			m.status = fmt.Sprintf("%s in progress...", p.action.verb())
+			return m, m.runAction(p)
+		}
+		m.status = "Cancelled"
+		return m, nil
+	}
+
+	m.status = ""
+
+	switch key {
+	case "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor--
+	case "down", "j":
+		m.cursor++
+	case "pgup":
+		m.cursor -= m.listHeight()
+	case "pgdown":
+		m.cursor += m.listHeight()
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = len(m.current().Children) - 1
+	case "enter", "right", "l":
+		return m, m.enter()
+	case "left", "backspace", "h":
+		m.leave()
+	case "s":
+		m.sortBy = (m.sortBy + 1) % 3
+		sel := m.selected()
+		m.sortChildren()
+		m.cursor = m.indexOf(sel)
+	case " ":
+		m.toggleMark()
+	case "d":
+		m.requestAction(actionDelete)
+	case "m":
+		m.requestAction(actionQuarantine)
+	}
+
+	m.clampCursor()
+	return m, nil
+}
+
+func (m *Model) enter() tea.Cmd {
+	sel := m.selected()
+	if sel == nil || !sel.IsDirectory || sel.IsLink {
+		return nil
+	}
+
+	if len(sel.Children) == 0 && sel.ItemCount > 0 && m.opts.Expand != nil {
+		m.busy = true
+		m.status = "Scanning " + sel.Path + "..."
+		expand := m.opts.Expand
+		return func() tea.Msg {
+			result, err := expand(sel.Path)
+			return expandedMsg{node: sel, result: result, err: err}
+		}
+	}
+
+	m.stack = append(m.stack, sel)
+	m.cursor, m.offset = 0, 0
+	m.sortChildren()
+	return nil
+}
+
+func (m *Model) finishExpand(msg expandedMsg) {
+	m.busy = false
+	m.status = ""
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Cannot scan %s: %v", msg.node.Path, msg.err)
+		return
+	}
+
+	n, r := msg.node, msg.result
+	m.addTotals(n, r.Size-n.Size, r.ItemCount-n.ItemCount, r.LargeFiles-n.LargeFiles)
+	n.Children = r.Children
+	m.indexParents(n)
+
+	if m.selected() == n {
+		m.enter()
+	}
+}
+
+func (m *Model) leave() {
+	if len(m.stack) == 1 {
+		return
+	}
+	from := m.current()
+	m.stack = m.stack[:len(m.stack)-1]
+	m.sortChildren()
+	m.cursor = m.indexOf(from)
+	m.offset = 0
+}
+
+func (m *Model) indexOf(n *analyzer.DiskNode) int {
+	for i, c := range m.current().Children {
+		if c == n {
+			return i
+		}
+	}
+	return 0
+}
+
+func (m *Model) sortChildren() {
+	children := m.current().Children
+	var less func(a, b *analyzer.DiskNode) bool
+
+	switch m.sortBy {
+	case sortByCount:
+		less = func(a, b *analyzer.DiskNode) bool { return a.ItemCount > b.ItemCount }
+	case sortByModTime:
+		less = func(a, b *analyzer.DiskNode) bool { return a.ModTime.After(b.ModTime) }
+	default:
+		less = func(a, b *analyzer.DiskNode) bool { return a.Size > b.Size }
+	}
+
+	sort.SliceStable(children, func(i, j int) bool { return less(children[i], children[j]) })
+}
+
+func (m *Model) clampCursor() {
+	n := len(m.current().Children)
+	if m.cursor >= n {
+		m.cursor = n - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+
+	h := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m *Model) toggleMark() {
+	if m.opts.ReadOnly {
+		m.status = "Read-only mode: marking is disabled"
+		return
+	}
+	sel := m.selected()
+	if sel == nil {
+		return
+	}
+	if m.marked[sel] {
+		delete(m.marked, sel)
+	} else {
+		m.marked[sel] = true
+	}
+	m.cursor++
+}
+
+func (m *Model) requestAction(a action) {
+	if m.opts.ReadOnly {
+		m.status = "Read-only mode: nothing can be deleted or quarantined"
+		return
+	}
+	if (a == actionDelete && m.opts.Delete == nil) || (a == actionQuarantine && m.opts.Quarantine == nil) {
+		m.status = a.verb() + " is not available"
+		return
+	}
+
+	targets := m.targets()
+	if len(targets) == 0 {
+		return
+	}
+
+	p := &pending{action: a, targets: targets}
+	for _, t := range targets {
+		p.size += t.Size
+	}
+	m.confirm = p
+}
+
+// targets returns the marked nodes, or the selected node if none are marked.
+// Nodes inside another marked folder are dropped, since acting on the folder
+// covers them.
+func (m *Model) targets() []*analyzer.DiskNode {
+	if len(m.marked) == 0 {
+		if sel := m.selected(); sel != nil {
+			return []*analyzer.DiskNode{sel}
+		}
+		return nil
+	}
+
+	var targets []*analyzer.DiskNode
+	for n := range m.marked {
+		covered := false
+		for p := m.parents[n]; p != nil; p = m.parents[p] {
+			if m.marked[p] {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			targets = append(targets, n)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Path < targets[j].Path })
+	return targets
+}
+
+func (m *Model) runAction(p *pending) tea.Cmd {
+	fn := m.opts.Delete
+	if p.action == actionQuarantine {
+		fn = m.opts.Quarantine
+	}
+
+	return func() tea.Msg {
+		msg := actionDoneMsg{action: p.action}
+		for _, t := range p.targets {
+			if err := fn(t.Path); err != nil {
+				msg.errs = append(msg.errs, fmt.Errorf("%s: %w", t.Path, err))
+				continue
+			}
+			msg.done = append(msg.done, t)
+		}
+		return msg
+	}
+}
+
+func (m *Model) finishAction(msg actionDoneMsg) {
+	m.busy = false
+
+	var freed int64
+	for _, n := range msg.done {
+		freed += n.Size
+		m.remove(n)
+	}
+
+	verb := "Deleted"
+	if msg.action == actionQuarantine {
+		verb = "Quarantined"
+	}
+	m.status = fmt.Sprintf("%s %d items (%s)", verb, len(msg.done), utils.FormatBytes(freed))
+	if len(msg.errs) > 0 {
+		m.status += fmt.Sprintf("; %d failed: %v", len(msg.errs), msg.errs[0])
+	}
+
+	// The current folder may itself have been removed from a parent view.
+	for i, n := range m.stack {
+		if i > 0 && m.parents[n] == nil {
+			m.stack = m.stack[:i]
+			break
+		}
+	}
+	m.clampCursor()
+}
+
+// remove detaches n from the tree and subtracts its totals from its ancestors.
+func (m *Model) remove(n *analyzer.DiskNode) {
+	parent := m.parents[n]
+	if parent == nil {
+		return
+	}
+
+	for i, c := range parent.Children {
+		if c == n {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+			break
+		}
+	}
+	m.addTotals(parent, -n.Size, -n.ItemCount, -n.LargeFiles)
+
+	var forget func(*analyzer.DiskNode)
+	forget = func(x *analyzer.DiskNode) {
+		delete(m.marked, x)
+		delete(m.parents, x)
+		for _, c := range x.Children {
+			forget(c)
+		}
+	}
+	forget(n)
+}
+
+// addTotals applies a change in size and counts to n and its ancestors.
+func (m *Model) addTotals(n *analyzer.DiskNode, size int64, items, large int) {
+	for p := n; p != nil; p = m.parents[p] {
+		p.Size += size
+		p.ItemCount += items
+		p.LargeFiles += large
+	}
+}
+
+func (m *Model) listHeight() int {
+	// Header (3 lines) and footer (3 lines).
+	h := m.height - 6
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+var (
+	headerStyle = color.New(color.FgCyan, color.Bold)
+	cursorStyle = color.New(color.ReverseVideo)
+	markStyle   = color.New(color.FgYellow, color.Bold)
+	dimStyle    = color.New(color.FgHiBlack)
+	errorStyle  = color.New(color.FgRed)
+)
+
+// View implements tea.Model.
+func (m *Model) View() string {
+	var b strings.Builder
+	cur := m.current()
+
+	title := " Burrow Disk Explorer  " + cur.Path
+	if m.opts.ReadOnly {
+		title += "  [read-only]"
+	}
+	b.WriteString(headerStyle.Sprint(utils.TruncateString(title, m.width)) + "\n")
+	b.WriteString(fmt.Sprintf(" %s in %d items, sorted by %s", utils.FormatBytes(cur.Size), cur.ItemCount, m.sortBy))
+	if len(m.marked) > 0 {
+		var size int64
+		for n := range m.marked {
+			size += n.Size
+		}
+		b.WriteString(markStyle.Sprintf("  %d marked (%s)", len(m.marked), utils.FormatBytes(size)))
+	}
+	b.WriteString("\n" + strings.Repeat("─", m.width) + "\n")
+
+	children := cur.Children
+	h := m.listHeight()
+	for i := m.offset; i < m.offset+h; i++ {
+		if i >= len(children) {
+			b.WriteString("\n")
+			continue
+		}
+		line := m.row(children[i], cur.Size)
+		if i == m.cursor {
+			line = cursorStyle.Sprint(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(children) == 0 {
+		b.WriteString(dimStyle.Sprint("  (empty)") + "\n")
+	}
+
+	b.WriteString(strings.Repeat("─", m.width) + "\n")
+	switch {
+	case m.confirm != nil:
+		b.WriteString(markStyle.Sprintf(" %s %d items (%s)? [y/N] ",
+			m.confirm.action.verb(), len(m.confirm.targets), utils.FormatBytes(m.confirm.size)))
+	case strings.Contains(m.status, "failed") || strings.HasPrefix(m.status, "Cannot"):
+		b.WriteString(errorStyle.Sprint(" " + m.status))
+	default:
+		b.WriteString(" " + m.status)
+	}
+	b.WriteString("\n")
+
+	help := " ↑/↓ move  →/enter open  ←/backspace up  s sort  q quit"
+	if !m.opts.ReadOnly {
+		help = " ↑/↓ move  →/enter open  ←/backspace up  s sort  space mark  d delete  m quarantine  q quit"
+	}
+	b.WriteString(dimStyle.Sprint(utils.TruncateString(help, m.width)))
+
+	return b.String()
+}
+
+func (m *Model) row(n *analyzer.DiskNode, total int64) string {
+	mark := "   "
+	if m.marked[n] {
+		mark = "[*]"
+	}
+
+	pct := 0.0
+	if total > 0 {
+		pct = float64(n.Size) / float64(total) * 100
+	}
+	const barWidth = 10
+	filled := int(pct / 100 * barWidth)
+	if filled > barWidth {
+		filled = barWidth
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+
+	name := n.Name
+	switch {
+	case n.IsLink:
+		name += " -> " + n.LinkTarget
+	case n.IsDirectory:
+		name += string(filepath.Separator)
+	}
+
+	items := ""
+	if n.IsDirectory && !n.IsLink {
+		items = fmt.Sprintf("%d items", n.ItemCount)
+	}
+
+	prefix := fmt.Sprintf(" %s %s %5.1f%% %10s %12s  %s  ",
+		mark, bar, pct, utils.FormatBytes(n.Size), items, n.ModTime.Format("2006-01-02"))
+
+	nameWidth := m.width - len([]rune(prefix))
+	if nameWidth < 10 {
+		nameWidth = 10
+	}
+	return prefix + utils.TruncateString(name, nameWidth)
+}
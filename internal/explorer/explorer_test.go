@@ -0,0 +1,220 @@
+package explorer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zs0c131y/burrow/internal/analyzer"
+)
+
+func file(name string, size int64, mod time.Time) *analyzer.DiskNode {
+	return &analyzer.DiskNode{Name: name, Path: "/r/" + name, Size: size, ItemCount: 1, ModTime: mod}
+}
+
+func dir(name string, children ...*analyzer.DiskNode) *analyzer.DiskNode {
+	n := &analyzer.DiskNode{Name: name, Path: "/r/" + name, IsDirectory: true, Children: children}
+	for _, c := range children {
+		n.Size += c.Size
+		n.ItemCount += c.ItemCount
+	}
+	return n
+}
+
+// testTree builds:
+//
+//	root
+//	├── big/      (a 600, b 300)
+//	├── many/     (x 10, y 10, z 10)
+//	└── new.txt   100, newest
+func testTree() *analyzer.DiskNode {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	root := dir("root",
+		dir("many", file("x", 10, t0), file("y", 10, t0), file("z", 10, t0)),
+		file("new.txt", 100, t0.Add(48*time.Hour)),
+		dir("big", file("a", 600, t0), file("b", 300, t0)),
+	)
+	root.Children[0].ModTime = t0
+	root.Children[2].ModTime = t0.Add(time.Hour)
+	return root
+}
+
+func press(t *testing.T, m *Model, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		switch k {
+		case "up":
+			msg = tea.KeyMsg{Type: tea.KeyUp}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "left":
+			msg = tea.KeyMsg{Type: tea.KeyLeft}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		}
+
+		_, cmd := m.Update(msg)
+		// Run commands synchronously so tests see their results.
+		for cmd != nil {
+			out := cmd()
+			if _, ok := out.(tea.QuitMsg); ok || out == nil {
+				break
+			}
+			_, cmd = m.Update(out)
+		}
+	}
+}
+
+func names(nodes []*analyzer.DiskNode) string {
+	var s []string
+	for _, n := range nodes {
+		s = append(s, n.Name)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestNavigationAndSorting(t *testing.T) {
+	m := New(testTree(), Options{ReadOnly: true})
+
+	if got := names(m.current().Children); got != "big,new.txt,many" {
+		t.Fatalf("initial order = %s, want by size", got)
+	}
+
+	press(t, m, "s")
+	if got := names(m.current().Children); got != "many,big,new.txt" {
+		t.Errorf("count order = %s", got)
+	}
+	press(t, m, "s")
+	if got := names(m.current().Children); got != "new.txt,big,many" {
+		t.Errorf("mtime order = %s", got)
+	}
+	press(t, m, "s")
+
+	press(t, m, "enter")
+	if m.current().Name != "big" {
+		t.Fatalf("entered %s, want big", m.current().Name)
+	}
+	press(t, m, "down", "enter")
+	if m.current().Name != "big" {
+		t.Error("entering a file should do nothing")
+	}
+
+	press(t, m, "left")
+	if m.current().Name != "root" || m.selected().Name != "big" {
+		t.Errorf("after leaving: at %s with %s selected, want root with big", m.current().Name, m.selected().Name)
+	}
+
+	press(t, m, "down", "down", "down", "down")
+	if m.selected().Name != "many" {
+		t.Errorf("cursor should stop at the last entry, got %s", m.selected().Name)
+	}
+}
+
+func TestReadOnlyBlocksActions(t *testing.T) {
+	deleted := 0
+	m := New(testTree(), Options{
+		ReadOnly: true,
+		Delete:   func(string) error { deleted++; return nil },
+	})
+
+	press(t, m, " ", "d", "y")
+	if deleted != 0 || len(m.marked) != 0 || m.confirm != nil {
+		t.Errorf("read-only mode allowed an action: deleted=%d marked=%d", deleted, len(m.marked))
+	}
+	if !strings.Contains(m.View(), "read-only") {
+		t.Error("view should indicate read-only mode")
+	}
+}
+
+func TestDeleteMarkedWithConfirmation(t *testing.T) {
+	var deleted []string
+	root := testTree()
+	m := New(root, Options{
+		Delete: func(path string) error {
+			deleted = append(deleted, path)
+			if strings.HasSuffix(path, "/x") {
+				return errors.New("in use")
+			}
+			return nil
+		},
+	})
+
+	// Mark big, then enter many and mark x and y.
+	press(t, m, " ", "down", "enter", " ", " ")
+	if len(m.marked) != 3 {
+		t.Fatalf("marked %d items, want 3", len(m.marked))
+	}
+
+	press(t, m, "d", "n")
+	if len(deleted) != 0 || m.status != "Cancelled" {
+		t.Fatalf("declined confirmation still ran: %v", deleted)
+	}
+
+	press(t, m, "d")
+	if m.confirm == nil || len(m.confirm.targets) != 3 || m.confirm.size != 920 {
+		t.Fatalf("confirm = %+v, want 3 targets of 920 bytes", m.confirm)
+	}
+	press(t, m, "y")
+
+	if len(deleted) != 3 {
+		t.Fatalf("Delete called for %v", deleted)
+	}
+	if root.Size != 1030-910 || root.ItemCount != 6-3 {
+		t.Errorf("root totals = %d bytes, %d items; want 120, 3", root.Size, root.ItemCount)
+	}
+	if got := names(m.current().Children); got != "x,z" {
+		t.Errorf("many now holds %s, want x (failed) and z", got)
+	}
+	if !strings.Contains(m.status, "1 failed") {
+		t.Errorf("status = %q, want failure reported", m.status)
+	}
+	if len(m.marked) != 1 {
+		t.Errorf("%d items still marked, want only the failed one", len(m.marked))
+	}
+}
+
+func TestMarkedParentCoversChildren(t *testing.T) {
+	var quarantined []string
+	m := New(testTree(), Options{
+		Quarantine: func(path string) error { quarantined = append(quarantined, path); return nil },
+	})
+
+	// Mark a inside big, go back and mark big itself.
+	press(t, m, "enter", " ", "left", " ")
+	press(t, m, "m", "y")
+
+	if len(quarantined) != 1 || quarantined[0] != "/r/big" {
+		t.Errorf("quarantined %v, want only /r/big", quarantined)
+	}
+}
+
+func TestExpandSummarizedFolder(t *testing.T) {
+	root := testTree()
+	deep := &analyzer.DiskNode{Name: "deep", Path: "/r/deep", IsDirectory: true, Size: 500, ItemCount: 2}
+	root.Children = append(root.Children, deep)
+	root.Size += 500
+	root.ItemCount += 2
+
+	m := New(root, Options{
+		ReadOnly: true,
+		Expand: func(path string) (*analyzer.DiskNode, error) {
+			return dir("deep", file("p", 450, time.Time{}), file("q", 40, time.Time{})), nil
+		},
+	})
+
+	press(t, m, "down", "enter")
+	if m.current() != deep {
+		t.Fatalf("current = %s, want deep", m.current().Name)
+	}
+	if names(deep.Children) != "p,q" {
+		t.Errorf("deep children = %s", names(deep.Children))
+	}
+	if deep.Size != 490 || root.Size != 1030+490 {
+		t.Errorf("sizes after expand: deep %d, root %d", deep.Size, root.Size)
+	}
+}
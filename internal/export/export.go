@@ -0,0 +1,194 @@
+// Package export writes analyzer trees in formats other tools can read: a
+// JSON snapshot, CSV, the ncdu export format and a self-contained HTML
+// treemap.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zs0c131y/burrow/internal/analyzer"
+)
+
+// Export formats.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatNCDU = "ncdu"
+	FormatHTML = "html"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatJSON, FormatCSV, FormatNCDU, FormatHTML}
+
+// FormatFor returns the export format for a file, using format when it is set
+// and the file extension otherwise. ncdu exports are JSON files, so that
+// format has to be requested explicitly.
+func FormatFor(path, format string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		for _, f := range Formats {
+			if f == format {
+				return f, nil
+			}
+		}
+		return "", fmt.Errorf("unknown export format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".html", ".htm":
+		return FormatHTML, nil
+	}
+	return "", fmt.Errorf("cannot tell the export format of %s; set it with --export-format", path)
+}
+
+// Info describes the scan being exported.
+type Info struct {
+	Scanned time.Time
+	Version string
+}
+
+// Write exports root to w in the given format.
+func Write(w io.Writer, format string, root *analyzer.DiskNode, info Info) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, root, info)
+	case FormatCSV:
+		return WriteCSV(w, root)
+	case FormatNCDU:
+		return WriteNCDU(w, root, info)
+	case FormatHTML:
+		return WriteHTML(w, root, info)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// snapshotVersion is the schema version of JSON snapshots.
+const snapshotVersion = 1
+
+// Snapshot is the JSON form of an analyzer tree.
+type Snapshot struct {
+	Version int       `json:"version"`
+	Scanned time.Time `json:"scanned"`
+	Root    *Node     `json:"root"`
+}
+
+// Node is the JSON form of a DiskNode.
+type Node struct {
+	Name        string    `json:"name"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	Allocated   int64     `json:"allocated"`
+	ItemCount   int       `json:"item_count"`
+	IsDirectory bool      `json:"is_directory"`
+	IsLink      bool      `json:"is_link,omitempty"`
+	LinkTarget  string    `json:"link_target,omitempty"`
+	ModTime     time.Time `json:"mod_time"`
+	Children    []*Node   `json:"children,omitempty"`
+}
+
+func newNode(n *analyzer.DiskNode) *Node {
+	out := &Node{
+		Name:        n.Name,
+		Path:        n.Path,
+		Size:        n.Size,
+		Allocated:   n.Allocated,
+		ItemCount:   n.ItemCount,
+		IsDirectory: n.IsDirectory,
+		IsLink:      n.IsLink,
+		LinkTarget:  n.LinkTarget,
+		ModTime:     n.ModTime,
+	}
+	for _, child := range n.Children {
+		out.Children = append(out.Children, newNode(child))
+	}
+	return out
+}
+
+func (n *Node) diskNode() *analyzer.DiskNode {
+	out := &analyzer.DiskNode{
+		Name:        n.Name,
+		Path:        n.Path,
+		Size:        n.Size,
+		Allocated:   n.Allocated,
+		ItemCount:   n.ItemCount,
+		IsDirectory: n.IsDirectory,
+		IsLink:      n.IsLink,
+		LinkTarget:  n.LinkTarget,
+		ModTime:     n.ModTime,
+	}
+	for _, child := range n.Children {
+		out.Children = append(out.Children, child.diskNode())
+	}
+	return out
+}
+
+// WriteJSON writes root as a JSON snapshot that ReadJSON can load again.
+func WriteJSON(w io.Writer, root *analyzer.DiskNode, info Info) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Snapshot{
+		Version: snapshotVersion,
+		Scanned: info.Scanned,
+		Root:    newNode(root),
+	})
+}
+
+// ReadJSON loads a snapshot written by WriteJSON, returning the tree and the
+// time it was scanned.
+func ReadJSON(r io.Reader) (*analyzer.DiskNode, time.Time, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if s.Version > snapshotVersion {
+		return nil, time.Time{}, fmt.Errorf("snapshot version %d is newer than supported (%d)", s.Version, snapshotVersion)
+	}
+	if s.Root == nil {
+		return nil, time.Time{}, fmt.Errorf("snapshot has no root")
+	}
+	return s.Root.diskNode(), s.Scanned, nil
+}
+
+// WriteCSV writes one row per node with its path, size, item count and
+// modification time, parents before their children.
+func WriteCSV(w io.Writer, root *analyzer.DiskNode) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"path", "size", "count", "mtime"}); err != nil {
+		return err
+	}
+
+	var walk func(n *analyzer.DiskNode) error
+	walk = func(n *analyzer.DiskNode) error {
+		mtime := ""
+		if !n.ModTime.IsZero() {
+			mtime = n.ModTime.UTC().Format(time.RFC3339)
+		}
+		row := []string{n.Path, strconv.FormatInt(n.Size, 10), strconv.Itoa(n.ItemCount), mtime}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		for _, child := range n.Children {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
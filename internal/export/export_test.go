@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zs0c131y/burrow/internal/analyzer"
+)
+
+func sampleTree() *analyzer.DiskNode {
+	mod := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &analyzer.DiskNode{
+		Name: "data", Path: "/data", Size: 600, ItemCount: 3, IsDirectory: true, ModTime: mod,
+		Children: []*analyzer.DiskNode{
+			{Name: "movie.mkv", Path: "/data/movie.mkv", Size: 400, Allocated: 4096, ItemCount: 1, ModTime: mod},
+			{Name: "docs", Path: "/data/docs", Size: 200, ItemCount: 2, IsDirectory: true, ModTime: mod,
+				Children: []*analyzer.DiskNode{
+					{Name: "a <b>.txt", Path: "/data/docs/a <b>.txt", Size: 150, ItemCount: 1, ModTime: mod},
+					{Name: "c.log", Path: "/data/docs/c.log", Size: 50, ItemCount: 1, ModTime: mod},
+				}},
+		},
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	tests := []struct {
+		path, format, want string
+		wantErr            bool
+	}{
+		{"report.json", "", FormatJSON, false},
+		{"report.CSV", "", FormatCSV, false},
+		{"report.htm", "", FormatHTML, false},
+		{"report.json", "ncdu", FormatNCDU, false},
+		{"report.out", "", "", true},
+		{"report.json", "xml", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := FormatFor(tt.path, tt.format)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("FormatFor(%q, %q) = %q, %v", tt.path, tt.format, got, err)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	scanned := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, sampleTree(), Info{Scanned: scanned}); err != nil {
+		t.Fatal(err)
+	}
+
+	root, when, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON error: %v", err)
+	}
+	if !when.Equal(scanned) {
+		t.Errorf("scanned = %v, want %v", when, scanned)
+	}
+	if root.Size != 600 || len(root.Children) != 2 || root.Children[1].Children[0].Name != "a <b>.txt" {
+		t.Errorf("tree not restored: %+v", root)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, sampleTree()); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 6 {
+		t.Fatalf("got %d rows, want header + 5", len(rows))
+	}
+	if strings.Join(rows[0], ",") != "path,size,count,mtime" {
+		t.Errorf("header = %v", rows[0])
+	}
+	if strings.Join(rows[3], ",") != "/data/docs,200,2,2026-03-01T12:00:00Z" {
+		t.Errorf("row 3 = %v", rows[3])
+	}
+}
+
+func TestWriteNCDU(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNCDU(&buf, sampleTree(), Info{Version: "1.0.0", Scanned: time.Unix(1700000000, 0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc []json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("export is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(doc) != 4 || string(doc[0]) != "1" || string(doc[1]) != "2" {
+		t.Fatalf("unexpected header: %s", buf.String())
+	}
+
+	var root []json.RawMessage
+	if err := json.Unmarshal(doc[3], &root); err != nil {
+		t.Fatal(err)
+	}
+	var info, file ncduEntry
+	json.Unmarshal(root[0], &info)
+	json.Unmarshal(root[1], &file)
+	if info.Name != "/data" || file.Name != "movie.mkv" || file.ASize != 400 || file.DSize != 4096 {
+		t.Errorf("root = %+v, first child = %+v", info, file)
+	}
+
+	var docs []json.RawMessage
+	if err := json.Unmarshal(root[2], &docs); err != nil || len(docs) != 3 {
+		t.Errorf("docs directory should be an array of info + 2 files, got %s", root[2])
+	}
+}
+
+func TestSquarify(t *testing.T) {
+	r := Rect{0, 0, 600, 400}
+	sizes := []float64{6, 6, 4, 3, 2, 2, 1}
+
+	rects := squarify(sizes, r)
+	if len(rects) != len(sizes) {
+		t.Fatalf("got %d rects, want %d", len(rects), len(sizes))
+	}
+
+	var area float64
+	for i, rc := range rects {
+		want := sizes[i] / 24 * r.W * r.H
+		if math.Abs(rc.W*rc.H-want) > 0.01 {
+			t.Errorf("rect %d area = %.2f, want %.2f", i, rc.W*rc.H, want)
+		}
+		if rc.X < -0.01 || rc.Y < -0.01 || rc.X+rc.W > r.W+0.01 || rc.Y+rc.H > r.H+0.01 {
+			t.Errorf("rect %d = %+v lies outside %+v", i, rc, r)
+		}
+		area += rc.W * rc.H
+	}
+	if math.Abs(area-r.W*r.H) > 0.1 {
+		t.Errorf("total area = %.2f, want %.2f", area, r.W*r.H)
+	}
+
+	// The paper's example lays the two largest items out as one column.
+	if rects[0].X != 0 || rects[1].X != 0 || rects[0].W != rects[1].W {
+		t.Errorf("first row = %+v, %+v", rects[0], rects[1])
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, sampleTree(), Info{Version: "1.0.0", Scanned: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+
+	for _, external := range []string{"<script src", "<link", "url("} {
+		if strings.Contains(page, external) {
+			t.Errorf("report references external assets (%q)", external)
+		}
+	}
+	if !strings.Contains(page, "a &lt;b&gt;.txt") {
+		t.Error("file names should be escaped in the report")
+	}
+	if strings.Count(page, `class="t`) != 5 {
+		t.Errorf("expected 5 tiles, got %d", strings.Count(page, `class="t`))
+	}
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/zs0c131y/burrow/internal/analyzer"
+)
+
+// ncduEntry is the information object ncdu stores for every file and
+// directory.
+type ncduEntry struct {
+	Name   string `json:"name"`
+	ASize  int64  `json:"asize,omitempty"`
+	DSize  int64  `json:"dsize,omitempty"`
+	MTime  int64  `json:"mtime,omitempty"`
+	NotReg bool   `json:"notreg,omitempty"`
+}
+
+// WriteNCDU writes root in the ncdu JSON export format (version 1.2), which
+// can be browsed with 'ncdu -f'. ncdu computes directory sizes from the files
+// it is given, so folders that were summarized beyond the scan depth show as
+// empty; export a full scan to avoid that.
+func WriteNCDU(w io.Writer, root *analyzer.DiskNode, info Info) error {
+	bw := bufio.NewWriter(w)
+
+	header, err := json.Marshal(map[string]interface{}{
+		"progname":  "burrow",
+		"progver":   info.Version,
+		"timestamp": info.Scanned.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	bw.WriteString("[1,2,")
+	bw.Write(header)
+	bw.WriteString(",\n")
+
+	// ncdu names the root by its full path and everything else by name.
+	if err := writeNCDUNode(bw, root, root.Path); err != nil {
+		return err
+	}
+
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+func writeNCDUNode(w *bufio.Writer, n *analyzer.DiskNode, name string) error {
+	entry := ncduEntry{Name: name, NotReg: n.IsLink}
+	if !n.ModTime.IsZero() {
+		entry.MTime = n.ModTime.Unix()
+	}
+	if !n.IsDirectory {
+		entry.ASize = n.Size
+		entry.DSize = n.Allocated
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if !n.IsDirectory {
+		_, err = w.Write(data)
+		return err
+	}
+
+	w.WriteByte('[')
+	w.Write(data)
+	for _, child := range n.Children {
+		w.WriteString(",\n")
+		if err := writeNCDUNode(w, child, child.Name); err != nil {
+			return err
+		}
+	}
+	_, err = w.WriteString("]")
+	return err
+}
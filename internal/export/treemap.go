@@ -0,0 +1,249 @@
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/zs0c131y/burrow/internal/analyzer"
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// Treemap dimensions in CSS pixels.
+const (
+	treemapWidth  = 1200
+	treemapHeight = 800
+
+	// minTileArea is the smallest tile drawn; smaller ones would be invisible
+	// and only bloat the report.
+	minTileArea = 24
+	// labelHeight is the strip reserved for a folder's name above its
+	// children, once the folder is large enough to show it.
+	labelHeight = 16
+)
+
+// Rect is an axis-aligned rectangle in treemap coordinates.
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// squarify lays out sizes, which must be positive and sorted largest first,
+// within r so that each rectangle's area is proportional to its size and the
+// rectangles are as close to square as possible (Bruls, Huizing and van Wijk,
+// "Squarified Treemaps").
+func squarify(sizes []float64, r Rect) []Rect {
+	var total float64
+	for _, s := range sizes {
+		total += s
+	}
+	if total <= 0 || r.W <= 0 || r.H <= 0 {
+		return make([]Rect, len(sizes))
+	}
+
+	scale := r.W * r.H / total
+	areas := make([]float64, len(sizes))
+	for i, s := range sizes {
+		areas[i] = s * scale
+	}
+
+	rects := make([]Rect, 0, len(sizes))
+	for len(areas) > 0 {
+		side := min(r.W, r.H)
+
+		n := 1
+		for n < len(areas) && worstRatio(areas[:n+1], side) <= worstRatio(areas[:n], side) {
+			n++
+		}
+
+		var rowArea float64
+		for _, a := range areas[:n] {
+			rowArea += a
+		}
+
+		if r.W >= r.H {
+			// Lay the row out as a column along the left edge.
+			colW := rowArea / r.H
+			y := r.Y
+			for _, a := range areas[:n] {
+				h := a / colW
+				rects = append(rects, Rect{r.X, y, colW, h})
+				y += h
+			}
+			r.X += colW
+			r.W -= colW
+		} else {
+			// Lay the row out along the top edge.
+			rowH := rowArea / r.W
+			x := r.X
+			for _, a := range areas[:n] {
+				w := a / rowH
+				rects = append(rects, Rect{x, r.Y, w, rowH})
+				x += w
+			}
+			r.Y += rowH
+			r.H -= rowH
+		}
+
+		areas = areas[n:]
+	}
+
+	return rects
+}
+
+// worstRatio returns the largest aspect ratio among row's rectangles when
+// they are laid out along a side of the given length.
+func worstRatio(row []float64, side float64) float64 {
+	var sum float64
+	lo, hi := row[0], row[0]
+	for _, a := range row {
+		sum += a
+		lo = min(lo, a)
+		hi = max(hi, a)
+	}
+	s2 := side * side
+	return max(s2*hi/(sum*sum), sum*sum/(s2*lo))
+}
+
+// typeColors colors file tiles by type group.
+var typeColors = map[string]string{
+	analyzer.TypeVideo:      "#e15759",
+	analyzer.TypeImages:     "#f28e2b",
+	analyzer.TypeArchives:   "#b07aa1",
+	analyzer.TypeInstallers: "#edc948",
+	analyzer.TypeVMDisks:    "#9c755f",
+	analyzer.TypeISO:        "#ff9da7",
+	analyzer.TypeLogs:       "#76b7b2",
+	analyzer.TypeSource:     "#59a14f",
+	analyzer.TypeOther:      "#4e79a7",
+}
+
+// tile is one rectangle of the rendered treemap.
+type tile struct {
+	Style template.CSS
+	Label string
+	Title string
+	Dir   bool
+}
+
+func layoutTreemap(root *analyzer.DiskNode) []tile {
+	var tiles []tile
+
+	var place func(n *analyzer.DiskNode, r Rect, depth int)
+	place = func(n *analyzer.DiskNode, r Rect, depth int) {
+		t := tile{
+			Title: fmt.Sprintf("%s\n%s", n.Path, utils.FormatBytes(n.Size)),
+			Dir:   n.IsDirectory,
+		}
+
+		var bg string
+		if n.IsDirectory {
+			bg = fmt.Sprintf("hsl(210,15%%,%d%%)", max(55, 92-depth*6))
+			t.Title += fmt.Sprintf(", %d items", n.ItemCount)
+		} else {
+			bg = typeColors[analyzer.TypeGroup(n.Name)]
+		}
+		t.Style = template.CSS(fmt.Sprintf("left:%.1fpx;top:%.1fpx;width:%.1fpx;height:%.1fpx;background:%s",
+			r.X, r.Y, r.W, r.H, bg))
+
+		showLabel := r.W >= 40 && r.H >= labelHeight
+		if showLabel {
+			t.Label = n.Name
+		}
+		tiles = append(tiles, t)
+
+		if !n.IsDirectory || !showLabel || r.H < labelHeight*2 {
+			return
+		}
+
+		var children []*analyzer.DiskNode
+		var sizes []float64
+		for _, child := range n.Children {
+			if child.Size > 0 {
+				children = append(children, child)
+			}
+		}
+		sort.SliceStable(children, func(i, j int) bool {
+			return children[i].Size > children[j].Size
+		})
+		for _, child := range children {
+			sizes = append(sizes, float64(child.Size))
+		}
+
+		inner := Rect{r.X + 1, r.Y + labelHeight, r.W - 2, r.H - labelHeight - 1}
+		for i, cr := range squarify(sizes, inner) {
+			if cr.W*cr.H >= minTileArea {
+				place(children[i], cr, depth+1)
+			}
+		}
+	}
+
+	place(root, Rect{0, 0, treemapWidth, treemapHeight}, 0)
+	return tiles
+}
+
+var treemapTemplate = template.Must(template.New("treemap").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Disk usage: {{.Path}}</title>
+<style>
+body { font: 13px "Segoe UI", sans-serif; margin: 16px; color: #222; }
+h1 { font-size: 18px; margin: 0 0 4px; }
+.meta { color: #666; margin-bottom: 12px; }
+.map { position: relative; width: {{.Width}}px; height: {{.Height}}px; border: 1px solid #888; }
+.t { position: absolute; box-sizing: border-box; border: 1px solid rgba(0,0,0,.25); overflow: hidden;
+     white-space: nowrap; text-overflow: ellipsis; font-size: 11px; line-height: 14px; padding: 0 2px; }
+.t:hover { outline: 2px solid #000; z-index: 1; }
+.d { font-weight: 600; }
+.legend span { display: inline-block; margin-right: 12px; }
+.legend i { display: inline-block; width: 10px; height: 10px; margin-right: 4px; }
+</style>
+</head>
+<body>
+<h1>{{.Path}}</h1>
+<div class="meta">{{.Size}} in {{.Items}} items &middot; scanned {{.Scanned}} &middot; Burrow {{.Version}}</div>
+<div class="legend">{{range .Legend}}<span><i style="{{.Style}}"></i>{{.Label}}</span>{{end}}</div>
+<br>
+<div class="map">
+{{range .Tiles}}<div class="t{{if .Dir}} d{{end}}" style="{{.Style}}" title="{{.Title}}">{{.Label}}</div>
+{{end}}</div>
+</body>
+</html>
+`))
+
+// WriteHTML writes root as a self-contained HTML page with a squarified
+// treemap. Tiles too small to see are left out; every tile names its path and
+// size in a tooltip.
+func WriteHTML(w io.Writer, root *analyzer.DiskNode, info Info) error {
+	type legendEntry struct {
+		Style template.CSS
+		Label string
+	}
+
+	var legend []legendEntry
+	for _, group := range append(append([]string{}, analyzer.TypeGroups...), analyzer.TypeOther) {
+		legend = append(legend, legendEntry{
+			Style: template.CSS("background:" + typeColors[group]),
+			Label: group,
+		})
+	}
+
+	return treemapTemplate.Execute(w, struct {
+		Path, Size, Scanned, Version string
+		Items, Width, Height         int
+		Legend                       []legendEntry
+		Tiles                        []tile
+	}{
+		Path:    root.Path,
+		Size:    utils.FormatBytes(root.Size),
+		Items:   root.ItemCount,
+		Scanned: info.Scanned.Local().Format(time.RFC1123),
+		Version: info.Version,
+		Width:   treemapWidth,
+		Height:  treemapHeight,
+		Legend:  legend,
+		Tiles:   layoutTreemap(root),
+	})
+}
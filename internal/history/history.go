@@ -0,0 +1,117 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// FileName is the run history file inside the config directory.
+const FileName = "history.jsonl"
+
+// Entry records the outcome of one cleanup run.
+type Entry struct {
+	Time         time.Time     `json:"time"`
+	Command      string        `json:"command"`
+	Trigger      string        `json:"trigger"`
+	Job          string        `json:"job,omitempty"`
+	Profile      string        `json:"profile,omitempty"`
+	DryRun       bool          `json:"dry_run,omitempty"`
+	Success      bool          `json:"success"`
+	SpaceFreed   int64         `json:"space_freed"`
+	FilesRemoved int           `json:"files_removed"`
+	Duration     time.Duration `json:"duration"`
+	Error        string        `json:"error,omitempty"`
+
+	SpaceCompressed int64          `json:"space_compressed,omitempty"` // reclaimed by compressing logs
+	Skipped         map[string]int `json:"skipped,omitempty"`          // files left behind by reason
+}
+
+// Triggers describing what started a run.
+const (
+	TriggerManual    = "manual"
+	TriggerSchedule  = "schedule"
+	TriggerCatchUp   = "catch-up"
+	TriggerPressure  = "disk-pressure"
+	TriggerImmediate = "run-now"
+)
+
+var mu sync.Mutex
+
+// Path returns the location of the history file.
+func Path() (string, error) {
+	dir, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Append adds e to the history file.
+func Append(e Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot encode history entry: %w", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot open history file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("cannot write history file: %w", err)
+	}
+	return nil
+}
+
+// Read returns the most recent entries, oldest first. limit <= 0 returns all.
+// Lines that cannot be decoded are skipped.
+func Read(limit int) ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open history file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read history file: %w", err)
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,63 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendRead(t *testing.T) {
+	tmp := t.TempDir()
+	os.Setenv("APPDATA", tmp)
+	defer os.Unsetenv("APPDATA")
+
+	entries, err := Read(0)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Read on empty history = %v, %v", entries, err)
+	}
+
+	base := time.Date(2026, 5, 1, 3, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		e := Entry{
+			Time:       base.Add(time.Duration(i) * time.Hour),
+			Command:    "clean",
+			Trigger:    TriggerSchedule,
+			Job:        "nightly",
+			Success:    true,
+			SpaceFreed: int64(i+1) * 1024,
+		}
+		if err := Append(e); err != nil {
+			t.Fatalf("Append error: %v", err)
+		}
+	}
+
+	// A corrupt line must not hide the rest of the history.
+	path, _ := Path()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{not json\n")
+	f.Close()
+
+	if filepath.Dir(path) != filepath.Join(tmp, "Burrow") {
+		t.Errorf("history stored at %s, want inside the config dir", path)
+	}
+
+	entries, err = Read(0)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Read returned %d entries, want 3", len(entries))
+	}
+
+	last, err := Read(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last) != 2 || last[1].SpaceFreed != 3*1024 || !last[0].Time.Equal(base.Add(time.Hour)) {
+		t.Errorf("Read(2) = %+v, want the two newest entries oldest first", last)
+	}
+}
@@ -0,0 +1,48 @@
+// Package locks finds the processes that hold files open and schedules
+// locked files for deletion at the next restart.
+package locks
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupported is returned where the platform offers no way to do the
+// requested operation.
+var ErrUnsupported = errors.New("not supported on this platform")
+
+// maxLookups caps how many files Holders inspects in one call.
+const maxLookups = 200
+
+// Process identifies a process holding a file.
+type Process struct {
+	PID  int    `json:"pid"`
+	Name string `json:"name"`
+}
+
+// String formats the process as "name (pid N)".
+func (p Process) String() string {
+	if p.Name == "" {
+		return fmt.Sprintf("pid %d", p.PID)
+	}
+	return fmt.Sprintf("%s (pid %d)", p.Name, p.PID)
+}
+
+// Holders returns the processes holding each of paths open. Paths no process
+// could be found for are left out. Only the first maxLookups paths are
+// inspected.
+func Holders(paths []string) (map[string][]Process, error) {
+	if len(paths) > maxLookups {
+		paths = paths[:maxLookups]
+	}
+	if len(paths) == 0 {
+		return map[string][]Process{}, nil
+	}
+	return holders(paths)
+}
+
+// DeleteOnReboot asks the system to delete path when it next starts, before
+// any process can open it again.
+func DeleteOnReboot(path string) error {
+	return deleteOnReboot(path)
+}
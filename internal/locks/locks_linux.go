@@ -0,0 +1,81 @@
+//go:build linux
+
+package locks
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// procRoot is where process information is read from; tests point it at a
+// fake tree.
+var procRoot = "/proc"
+
+// holders scans the open file descriptors of every process. Processes whose
+// descriptors cannot be read, usually those of other users, are skipped.
+func holders(paths []string) (map[string][]Process, error) {
+	wanted := make(map[string]string, len(paths))
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		wanted[abs] = p
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]Process)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+
+		fdDir := filepath.Join(procRoot, e.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			target = strings.TrimSuffix(target, " (deleted)")
+			path, ok := wanted[target]
+			if !ok || seen[path] {
+				continue
+			}
+			seen[path] = true
+			result[path] = append(result[path], Process{PID: pid, Name: processName(pid)})
+		}
+	}
+
+	for _, procs := range result {
+		sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+	}
+	return result, nil
+}
+
+func processName(pid int) string {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// deleteOnReboot is not available: Linux lets open files be deleted, so a
+// file is never locked against removal in the first place.
+func deleteOnReboot(path string) error {
+	return ErrUnsupported
+}
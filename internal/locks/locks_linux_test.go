@@ -0,0 +1,100 @@
+//go:build linux
+
+package locks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeProc builds a /proc-like tree where each pid's fd folder links to the
+// given files.
+func fakeProc(t *testing.T, procs map[string][]string, names map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for pid, files := range procs {
+		fdDir := filepath.Join(root, pid, "fd")
+		if err := os.MkdirAll(fdDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for i, f := range files {
+			if err := os.Symlink(f, filepath.Join(fdDir, string(rune('3'+i)))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if name, ok := names[pid]; ok {
+			if err := os.WriteFile(filepath.Join(root, pid, "comm"), []byte(name+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return root
+}
+
+func TestHoldersFromProc(t *testing.T) {
+	dir := t.TempDir()
+	cache := filepath.Join(dir, "cache.db")
+	log := filepath.Join(dir, "app.log")
+	free := filepath.Join(dir, "free.tmp")
+
+	procRoot = fakeProc(t,
+		map[string][]string{
+			"412":  {cache, "/dev/null", cache},
+			"97":   {log + " (deleted)", cache},
+			"self": {free},
+			"1000": {"socket:[1234]"},
+		},
+		map[string]string{"412": "chrome", "97": "logger"},
+	)
+	defer func() { procRoot = "/proc" }()
+
+	got, err := Holders([]string{cache, log, free})
+	if err != nil {
+		t.Fatalf("Holders error: %v", err)
+	}
+	want := map[string][]Process{
+		cache: {{PID: 97, Name: "logger"}, {PID: 412, Name: "chrome"}},
+		log:   {{PID: 97, Name: "logger"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Holders = %v, want %v", got, want)
+	}
+}
+
+func TestProcessString(t *testing.T) {
+	if s := (Process{PID: 4, Name: "System"}).String(); s != "System (pid 4)" {
+		t.Errorf("String = %q", s)
+	}
+	if s := (Process{PID: 4}).String(); s != "pid 4" {
+		t.Errorf("String without name = %q", s)
+	}
+}
+
+func TestDeleteOnRebootUnsupported(t *testing.T) {
+	if err := DeleteOnReboot("/tmp/x"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("DeleteOnReboot = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestHoldersFindsOwnProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "held.tmp")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := Holders([]string{path})
+	if err != nil {
+		t.Fatalf("Holders error: %v", err)
+	}
+	for _, p := range got[path] {
+		if p.PID == os.Getpid() {
+			return
+		}
+	}
+	t.Errorf("Holders(%s) = %v, want this process (pid %d)", path, got[path], os.Getpid())
+}
@@ -0,0 +1,11 @@
+//go:build !windows && !linux
+
+package locks
+
+func holders(paths []string) (map[string][]Process, error) {
+	return nil, ErrUnsupported
+}
+
+func deleteOnReboot(path string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,139 @@
+package locks
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	rstrtmgr                = windows.NewLazySystemDLL("rstrtmgr.dll")
+	procRmStartSession      = rstrtmgr.NewProc("RmStartSession")
+	procRmRegisterResources = rstrtmgr.NewProc("RmRegisterResources")
+	procRmGetList           = rstrtmgr.NewProc("RmGetList")
+	procRmEndSession        = rstrtmgr.NewProc("RmEndSession")
+)
+
+// Restart Manager sizes and results.
+const (
+	cchRmSessionKey = 32
+	cchRmMaxAppName = 255
+	cchRmMaxSvcName = 63
+	errorMoreData   = 234
+)
+
+// rmUniqueProcess is RM_UNIQUE_PROCESS.
+type rmUniqueProcess struct {
+	ProcessID        uint32
+	ProcessStartTime windows.Filetime
+}
+
+// rmProcessInfo is RM_PROCESS_INFO. Every field is 4-byte aligned, so the
+// layout is the same on 32- and 64-bit Windows.
+type rmProcessInfo struct {
+	Process          rmUniqueProcess
+	AppName          [cchRmMaxAppName + 1]uint16
+	ServiceShortName [cchRmMaxSvcName + 1]uint16
+	ApplicationType  uint32
+	AppStatus        uint32
+	TSSessionID      uint32
+	Restartable      int32
+}
+
+// holders asks the Restart Manager which processes use each path. A
+// session reports processes for all of its files together, so each path
+// gets a session of its own.
+func holders(paths []string) (map[string][]Process, error) {
+	if err := procRmStartSession.Find(); err != nil {
+		return nil, ErrUnsupported
+	}
+
+	result := make(map[string][]Process)
+	var lastErr error
+	for _, path := range paths {
+		procs, err := fileHolders(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if len(procs) > 0 {
+			result[path] = procs
+		}
+	}
+	if len(result) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+func fileHolders(path string) ([]Process, error) {
+	var session uint32
+	key := make([]uint16, cchRmSessionKey+1)
+	if ret, _, _ := procRmStartSession.Call(uintptr(unsafe.Pointer(&session)), 0, uintptr(unsafe.Pointer(&key[0]))); ret != 0 {
+		return nil, fmt.Errorf("RmStartSession failed: %w", windows.Errno(ret))
+	}
+	defer procRmEndSession.Call(uintptr(session))
+
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	if ret, _, _ := procRmRegisterResources.Call(uintptr(session), 1, uintptr(unsafe.Pointer(&name)), 0, 0, 0, 0); ret != 0 {
+		return nil, fmt.Errorf("RmRegisterResources failed: %w", windows.Errno(ret))
+	}
+
+	var infos []rmProcessInfo
+	for attempt := 0; attempt < 3; attempt++ {
+		var needed, count uint32
+		var reasons uint32
+		var first uintptr
+		count = uint32(len(infos))
+		if count > 0 {
+			first = uintptr(unsafe.Pointer(&infos[0]))
+		}
+		ret, _, _ := procRmGetList.Call(uintptr(session),
+			uintptr(unsafe.Pointer(&needed)),
+			uintptr(unsafe.Pointer(&count)),
+			first,
+			uintptr(unsafe.Pointer(&reasons)))
+		switch ret {
+		case 0:
+			return toProcesses(infos[:count]), nil
+		case errorMoreData:
+			infos = make([]rmProcessInfo, needed)
+		default:
+			return nil, fmt.Errorf("RmGetList failed: %w", windows.Errno(ret))
+		}
+	}
+	return nil, fmt.Errorf("RmGetList: process list keeps changing")
+}
+
+func toProcesses(infos []rmProcessInfo) []Process {
+	procs := make([]Process, 0, len(infos))
+	for _, info := range infos {
+		pid := int(info.Process.ProcessID)
+		name := windows.UTF16ToString(info.AppName[:])
+		if p, err := process.NewProcess(int32(pid)); err == nil {
+			if exe, err := p.Name(); err == nil && exe != "" {
+				name = exe
+			}
+		}
+		procs = append(procs, Process{PID: pid, Name: name})
+	}
+	return procs
+}
+
+// deleteOnReboot registers path with MoveFileEx to be deleted when Windows
+// next starts. It needs administrator rights.
+func deleteOnReboot(path string) error {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	if err := windows.MoveFileEx(name, nil, windows.MOVEFILE_DELAY_UNTIL_REBOOT); err != nil {
+		return fmt.Errorf("cannot schedule %s for deletion: %w", path, err)
+	}
+	return nil
+}
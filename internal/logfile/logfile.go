@@ -0,0 +1,151 @@
+// Package logfile writes Burrow's own logs to files that rotate by size.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// DirName is the folder inside the config directory that holds the logs.
+const DirName = "logs"
+
+// Dir returns the folder Burrow writes its logs to, creating it if needed.
+func Dir() (string, error) {
+	config, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(config, DirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("cannot create log folder: %w", err)
+	}
+	return dir, nil
+}
+
+// Writer appends to a log file. Before a write would take the file past
+// maxSize bytes it is renamed to name.1, older files move up to name.2 and
+// so on, and files beyond keep are deleted.
+type Writer struct {
+	path    string
+	maxSize int64
+	keep    int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// Open opens the log file at path for appending. maxSize <= 0 disables
+// rotation; keep is the number of rotated files to retain.
+func Open(path string, maxSize int64, keep int) (*Writer, error) {
+	w := &Writer{path: path, maxSize: maxSize, keep: keep}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("cannot open log file: %w", err)
+	}
+	w.f = f
+	w.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating the file first if p would not fit.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate starts a new log file now.
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+// rotate shifts the rotated files up by one and reopens an empty log. The
+// file is closed first because Windows cannot rename an open file.
+func (w *Writer) rotate() error {
+	if w.f != nil {
+		w.f.Close()
+		w.f = nil
+	}
+
+	if w.keep <= 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rotate log file: %w", err)
+		}
+		return w.open()
+	}
+
+	os.Remove(rotatedName(w.path, w.keep))
+	for i := w.keep - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedName(w.path, i), rotatedName(w.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("cannot rotate log file: %w", err)
+		}
+	}
+	if err := os.Rename(w.path, rotatedName(w.path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot rotate log file: %w", err)
+	}
+	return w.open()
+}
+
+// Close closes the log file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// Files returns the log file at path followed by its rotated copies that
+// exist, newest first.
+func Files(path string, keep int) []string {
+	var files []string
+	for i := 0; i <= keep; i++ {
+		name := path
+		if i > 0 {
+			name = rotatedName(path, i)
+		}
+		if _, err := os.Stat(name); err == nil {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+func rotatedName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,89 @@
+package logfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriterRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	w, err := Open(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, line := range []string{"aaaaaa\n", "bbbbbb\n", "cccccc\n", "dddddd\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write error: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "dddddd\n",
+		path + ".1": "cccccc\n",
+		path + ".2": "bbbbbb\n",
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Base(name), err)
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("more than keep rotated files retained")
+	}
+
+	got := Files(path, 2)
+	if !reflect.DeepEqual(got, []string{path, path + ".1", path + ".2"}) {
+		t.Errorf("Files = %v", got)
+	}
+}
+
+func TestWriterAppendsToExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.log")
+	if err := os.WriteFile(path, []byte("old line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := Open(path, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(strings.Repeat("x", 100) + "\n"))
+	w.Close()
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "old line\n") || len(data) != 110 {
+		t.Errorf("log = %q, want the old line kept and no rotation", data)
+	}
+	if _, err := w.Write([]byte("late\n")); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+}
+
+func TestWriterKeepZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	w, err := Open(path, 8, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("first\n"))
+	w.Write([]byte("second\n"))
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "second\n" {
+		t.Errorf("log = %q, want only the latest line", data)
+	}
+	if files := Files(path, 3); len(files) != 1 {
+		t.Errorf("Files = %v, want no rotated copies", files)
+	}
+}
@@ -11,8 +11,9 @@ import (
 
 // OptimizeManager handles system optimization tasks.
 type OptimizeManager struct {
-	debug  bool
-	dryRun bool
+	debug    bool
+	dryRun   bool
+	disabled map[string]bool
 }
 
 // OptimizeTask represents a single optimization action.
@@ -41,7 +42,27 @@ func NewOptimizeManager(debug, dryRun bool) *OptimizeManager {
 	}
 }
 
-// AnalyzeSystem returns the list of available optimization tasks.
+// SetDisabledTasks excludes the named tasks from AnalyzeSystem.
+func (om *OptimizeManager) SetDisabledTasks(names []string) {
+	om.disabled = make(map[string]bool, len(names))
+	for _, name := range names {
+		om.disabled[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+}
+
+// IsKnownTask reports whether name identifies an optimization task.
+func IsKnownTask(name string) bool {
+	tasks, _ := (&OptimizeManager{}).AnalyzeSystem()
+	for _, task := range tasks {
+		if strings.EqualFold(task.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeSystem returns the list of available optimization tasks,
+// excluding any disabled with SetDisabledTasks.
 func (om *OptimizeManager) AnalyzeSystem() ([]*OptimizeTask, error) {
 	tasks := []*OptimizeTask{
 		{
@@ -95,7 +116,14 @@ func (om *OptimizeManager) AnalyzeSystem() ([]*OptimizeTask, error) {
 		},
 	}
 
-	return tasks, nil
+	enabled := tasks[:0]
+	for _, task := range tasks {
+		if !om.disabled[task.Name] {
+			enabled = append(enabled, task)
+		}
+	}
+
+	return enabled, nil
 }
 
 // ExecuteOptimization runs all provided tasks and returns results.
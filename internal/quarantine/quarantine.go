@@ -0,0 +1,305 @@
+// Package quarantine moves files out of the way instead of deleting them, so
+// that they can be restored until the quarantine is purged.
+package quarantine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// DirName is the quarantine folder inside the config directory.
+const DirName = "quarantine"
+
+const manifestName = "manifest.json"
+
+// Item is one quarantined file or directory.
+type Item struct {
+	ID            string    `json:"id"`
+	OriginalPath  string    `json:"original_path"`
+	StoredPath    string    `json:"stored_path"`
+	Size          int64     `json:"size"`
+	IsDir         bool      `json:"is_dir"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+var mu sync.Mutex
+
+// Dir returns the quarantine folder, creating it if needed.
+func Dir() (string, error) {
+	base, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, DirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("cannot create quarantine directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Move quarantines path. Items on another volume than the quarantine folder
+// are copied and then removed from their original location.
+func Move(path string) (Item, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return Item{}, fmt.Errorf("cannot quarantine %s: %w", path, err)
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return Item{}, err
+	}
+
+	size := info.Size()
+	isDir := info.IsDir() && !utils.IsLink(path, info)
+	if isDir {
+		size, _, _ = utils.GetDirSize(path)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Item{}, err
+	}
+	slot := filepath.Join(dir, id)
+	if err := os.Mkdir(slot, 0o755); err != nil {
+		return Item{}, fmt.Errorf("cannot create quarantine slot: %w", err)
+	}
+
+	item := Item{
+		ID:            id,
+		OriginalPath:  path,
+		StoredPath:    filepath.Join(slot, filepath.Base(path)),
+		Size:          size,
+		IsDir:         isDir,
+		QuarantinedAt: time.Now(),
+	}
+
+	if err := move(path, item.StoredPath); err != nil {
+		os.RemoveAll(slot)
+		return Item{}, fmt.Errorf("cannot quarantine %s: %w", path, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	items, err := load(dir)
+	if err != nil {
+		return Item{}, err
+	}
+	items = append(items, item)
+	if err := save(dir, items); err != nil {
+		return Item{}, err
+	}
+	return item, nil
+}
+
+// List returns the quarantined items, oldest first.
+func List() ([]Item, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return load(dir)
+}
+
+// Restore moves an item back to its original location. It fails if
+// something already exists there.
+func Restore(id string) (Item, error) {
+	return takeOut(id, func(item Item) error {
+		if _, err := os.Lstat(item.OriginalPath); err == nil {
+			return fmt.Errorf("%s already exists", item.OriginalPath)
+		}
+		if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0o755); err != nil {
+			return err
+		}
+		return move(item.StoredPath, item.OriginalPath)
+	})
+}
+
+// Purge permanently deletes a quarantined item.
+func Purge(id string) (Item, error) {
+	return takeOut(id, func(item Item) error {
+		return os.RemoveAll(filepath.Dir(item.StoredPath))
+	})
+}
+
+// PurgeOlderThan permanently deletes items quarantined before cutoff and
+// returns them.
+func PurgeOlderThan(cutoff time.Time) ([]Item, error) {
+	items, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []Item
+	for _, item := range items {
+		if !item.QuarantinedAt.Before(cutoff) {
+			continue
+		}
+		if _, err := Purge(item.ID); err != nil {
+			return purged, err
+		}
+		purged = append(purged, item)
+	}
+	return purged, nil
+}
+
+// takeOut runs fn on the item with the given ID and drops it from the
+// manifest if fn succeeds.
+func takeOut(id string, fn func(Item) error) (Item, error) {
+	dir, err := Dir()
+	if err != nil {
+		return Item{}, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	items, err := load(dir)
+	if err != nil {
+		return Item{}, err
+	}
+
+	for i, item := range items {
+		if item.ID != id {
+			continue
+		}
+		if err := fn(item); err != nil {
+			return item, err
+		}
+		os.Remove(filepath.Join(dir, id))
+		items = append(items[:i], items[i+1:]...)
+		return item, save(dir, items)
+	}
+	return Item{}, fmt.Errorf("no quarantined item with id %q", id)
+}
+
+func load(dir string) ([]Item, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read quarantine manifest: %w", err)
+	}
+
+	var items []Item
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid quarantine manifest: %w", err)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].QuarantinedAt.Before(items[j].QuarantinedAt) })
+	return items, nil
+}
+
+func save(dir string, items []Item) error {
+	if items == nil {
+		items = []Item{}
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode quarantine manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestName), data, 0o644); err != nil {
+		return fmt.Errorf("cannot write quarantine manifest: %w", err)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(b[:]), nil
+}
+
+// move renames src to dst, falling back to copy and delete when they are on
+// different volumes.
+func move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return utils.SafeDelete(src, 3)
+}
+
+func copyTree(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if utils.IsLink(src, info) {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, dst, info.Mode())
+	}
+
+	if err := os.MkdirAll(dst, info.Mode().Perm()|0o700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := copyTree(filepath.Join(src, e.Name()), filepath.Join(dst, e.Name())); err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
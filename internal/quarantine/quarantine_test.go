@@ -0,0 +1,121 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	os.Setenv("APPDATA", t.TempDir())
+	t.Cleanup(func() { os.Unsetenv("APPDATA") })
+
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "cache", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]int{
+		filepath.Join(src, "report.log"):            300,
+		filepath.Join(src, "cache", "a.bin"):        1000,
+		filepath.Join(src, "cache", "sub", "b.bin"): 500,
+	}
+	for p, n := range files {
+		if err := os.WriteFile(p, make([]byte, n), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return src
+}
+
+func TestMoveRestore(t *testing.T) {
+	src := setup(t)
+	dirPath := filepath.Join(src, "cache")
+
+	item, err := Move(dirPath)
+	if err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	if !item.IsDir || item.Size != 1500 {
+		t.Errorf("item = %+v, want directory of 1500 bytes", item)
+	}
+	if _, err := os.Stat(dirPath); !os.IsNotExist(err) {
+		t.Error("original directory should be gone after Move")
+	}
+	if _, err := os.Stat(filepath.Join(item.StoredPath, "sub", "b.bin")); err != nil {
+		t.Errorf("quarantined content missing: %v", err)
+	}
+
+	items, err := List()
+	if err != nil || len(items) != 1 || items[0].ID != item.ID {
+		t.Fatalf("List = %+v, %v", items, err)
+	}
+
+	// Restoring onto an existing path must not overwrite it.
+	if err := os.Mkdir(dirPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(item.ID); err == nil {
+		t.Error("Restore should refuse to overwrite an existing path")
+	}
+	os.Remove(dirPath)
+
+	if _, err := Restore(item.ID); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dirPath, "sub", "b.bin")); err != nil {
+		t.Errorf("restored content missing: %v", err)
+	}
+	if items, _ := List(); len(items) != 0 {
+		t.Errorf("manifest still lists %d items after restore", len(items))
+	}
+}
+
+func TestPurge(t *testing.T) {
+	src := setup(t)
+
+	old, err := Move(filepath.Join(src, "report.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	recent, err := Move(filepath.Join(src, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := PurgeOlderThan(recent.QuarantinedAt)
+	if err != nil {
+		t.Fatalf("PurgeOlderThan error: %v", err)
+	}
+	if len(purged) != 1 || purged[0].ID != old.ID {
+		t.Errorf("purged = %+v, want only %s", purged, old.ID)
+	}
+	if _, err := os.Stat(old.StoredPath); !os.IsNotExist(err) {
+		t.Error("purged item still on disk")
+	}
+
+	if _, err := Purge(recent.ID); err != nil {
+		t.Fatalf("Purge error: %v", err)
+	}
+	if _, err := Purge(recent.ID); err == nil {
+		t.Error("purging an unknown id should fail")
+	}
+	if items, _ := List(); len(items) != 0 {
+		t.Errorf("List after purge = %+v", items)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	src := setup(t)
+	dst := filepath.Join(t.TempDir(), "copy")
+
+	if err := copyTree(filepath.Join(src, "cache"), dst); err != nil {
+		t.Fatalf("copyTree error: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "sub", "b.bin"))
+	if err != nil || info.Size() != 500 {
+		t.Errorf("copied file = %v, %v", info, err)
+	}
+}
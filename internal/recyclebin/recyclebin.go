@@ -0,0 +1,166 @@
+// Package recyclebin reads the Windows Recycle Bin. Every drive keeps a
+// $Recycle.Bin folder with one subfolder per user SID; each deleted item is
+// stored as a $R file or folder next to a $I file that records its original
+// path, size and deletion time.
+package recyclebin
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// FolderName is the Recycle Bin folder at the root of every drive.
+const FolderName = "$Recycle.Bin"
+
+// Info is the metadata of one deleted item, as stored in its $I file.
+type Info struct {
+	OriginalPath string
+	Size         int64
+	Deleted      time.Time
+}
+
+// Entry is one item in a user's Recycle Bin.
+type Entry struct {
+	Info
+	// InfoPath is the $I metadata file and DataPath the $R file or folder
+	// holding the deleted item.
+	InfoPath string
+	DataPath string
+}
+
+// ErrInvalidInfo is returned for $I files that are truncated or of an unknown
+// version.
+var ErrInvalidInfo = errors.New("invalid recycle bin metadata")
+
+// $I layouts. Version 1 (Windows Vista to 8.1) stores the path in a fixed
+// MAX_PATH field; version 2 (Windows 10 and later) stores its length first.
+const (
+	infoHeaderSize = 24
+	v1PathChars    = 260
+)
+
+// ParseInfo decodes the contents of a $I file.
+func ParseInfo(data []byte) (Info, error) {
+	if len(data) < infoHeaderSize {
+		return Info{}, ErrInvalidInfo
+	}
+
+	version := binary.LittleEndian.Uint64(data[0:8])
+	info := Info{
+		Size:    int64(binary.LittleEndian.Uint64(data[8:16])),
+		Deleted: filetimeToTime(binary.LittleEndian.Uint64(data[16:24])),
+	}
+
+	var name []byte
+	switch version {
+	case 1:
+		if len(data) < infoHeaderSize+v1PathChars*2 {
+			return Info{}, ErrInvalidInfo
+		}
+		name = data[infoHeaderSize : infoHeaderSize+v1PathChars*2]
+	case 2:
+		if len(data) < infoHeaderSize+4 {
+			return Info{}, ErrInvalidInfo
+		}
+		chars := int(binary.LittleEndian.Uint32(data[24:28]))
+		if chars < 0 || len(data) < infoHeaderSize+4+chars*2 {
+			return Info{}, ErrInvalidInfo
+		}
+		name = data[infoHeaderSize+4 : infoHeaderSize+4+chars*2]
+	default:
+		return Info{}, fmt.Errorf("%w: version %d", ErrInvalidInfo, version)
+	}
+
+	info.OriginalPath = decodeUTF16(name)
+	return info, nil
+}
+
+// filetimeToTime converts a Windows FILETIME, in 100-nanosecond intervals
+// since 1601-01-01 UTC, to a time.Time.
+func filetimeToTime(ft uint64) time.Time {
+	if ft == 0 {
+		return time.Time{}
+	}
+	// 11644473600 seconds separate 1601-01-01 from the Unix epoch.
+	const epochDelta = 116444736000000000
+	return time.Unix(0, (int64(ft)-epochDelta)*100).UTC()
+}
+
+// decodeUTF16 decodes little-endian UTF-16 up to the first NUL.
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u := binary.LittleEndian.Uint16(b[i:])
+		if u == 0 {
+			break
+		}
+		units = append(units, u)
+	}
+	return string(utf16.Decode(units))
+}
+
+// ReadBin lists the items in one user's Recycle Bin folder, such as
+// C:\$Recycle.Bin\S-1-5-21-...-1001. $I files that cannot be parsed are
+// skipped, as are $R items without metadata, which Windows itself does not
+// show.
+func ReadBin(dir string) ([]Entry, error) {
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, de := range des {
+		name := de.Name()
+		if de.IsDir() || !strings.HasPrefix(name, "$I") {
+			continue
+		}
+
+		infoPath := filepath.Join(dir, name)
+		data, err := os.ReadFile(infoPath)
+		if err != nil {
+			continue
+		}
+		info, err := ParseInfo(data)
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			Info:     info,
+			InfoPath: infoPath,
+			DataPath: filepath.Join(dir, "$R"+name[2:]),
+		})
+	}
+	return entries, nil
+}
+
+// Remove permanently deletes the item and its metadata. The $I file is
+// removed last so that a failure leaves the item visible in the Recycle Bin.
+func (e Entry) Remove() error {
+	if err := os.RemoveAll(e.DataPath); err != nil {
+		return err
+	}
+	if err := os.Remove(e.InfoPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Bin is the Recycle Bin folder of one user on one drive.
+type Bin struct {
+	Drive string
+	Path  string
+}
+
+// Bins returns the current user's Recycle Bin folder on every fixed drive
+// that has one.
+func Bins() ([]Bin, error) {
+	return userBins()
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package recyclebin
+
+// userBins returns no bins; the Recycle Bin only exists on Windows.
+func userBins() ([]Bin, error) {
+	return nil, nil
+}
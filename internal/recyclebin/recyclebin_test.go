@@ -0,0 +1,120 @@
+package recyclebin
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const fixtureBin = "testdata/S-1-5-21-1000"
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		file    string
+		path    string
+		size    int64
+		deleted time.Time
+	}{
+		{"$IA1B2C3.docx", `C:\Users\ana\Documents\report.docx`, 12, time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)},
+		{"$ID4E5F6", `C:\Users\ana\Pictures\Trip ✈`, 5, time.Date(2025, 6, 1, 8, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join(fixtureBin, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := ParseInfo(data)
+		if err != nil {
+			t.Fatalf("ParseInfo(%s) error: %v", tt.file, err)
+		}
+		if info.OriginalPath != tt.path || info.Size != tt.size || !info.Deleted.Equal(tt.deleted) {
+			t.Errorf("ParseInfo(%s) = %+v", tt.file, info)
+		}
+	}
+}
+
+func TestParseInfoInvalid(t *testing.T) {
+	unknown := make([]byte, 64)
+	binary.LittleEndian.PutUint64(unknown, 3)
+
+	truncated := make([]byte, 28)
+	binary.LittleEndian.PutUint64(truncated, 2)
+	binary.LittleEndian.PutUint32(truncated[24:], 40)
+
+	for name, data := range map[string][]byte{
+		"short":      {2, 0, 0},
+		"unknown":    unknown,
+		"truncated":  truncated,
+		"v1 no path": append([]byte{1, 0, 0, 0, 0, 0, 0, 0}, make([]byte, 20)...),
+	} {
+		if _, err := ParseInfo(data); !errors.Is(err, ErrInvalidInfo) {
+			t.Errorf("%s: err = %v, want ErrInvalidInfo", name, err)
+		}
+	}
+}
+
+func TestReadBin(t *testing.T) {
+	entries, err := ReadBin(fixtureBin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2 (broken metadata and orphans skipped)", len(entries))
+	}
+	for _, e := range entries {
+		want := "$R" + filepath.Base(e.InfoPath)[2:]
+		if filepath.Base(e.DataPath) != want {
+			t.Errorf("DataPath = %s, want %s", e.DataPath, want)
+		}
+	}
+}
+
+func TestEntryRemove(t *testing.T) {
+	dir := t.TempDir()
+	copyDir(t, fixtureBin, dir)
+
+	entries, err := ReadBin(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := e.Remove(); err != nil {
+			t.Fatalf("Remove(%s) error: %v", e.OriginalPath, err)
+		}
+		for _, p := range []string{e.InfoPath, e.DataPath} {
+			if _, err := os.Lstat(p); !os.IsNotExist(err) {
+				t.Errorf("%s still exists", p)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "desktop.ini")); err != nil {
+		t.Error("desktop.ini should be left alone")
+	}
+}
+
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
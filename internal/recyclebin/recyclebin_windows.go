@@ -0,0 +1,41 @@
+package recyclebin
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// userBins looks for X:\$Recycle.Bin\<SID> of the current user on every fixed
+// drive.
+func userBins() ([]Bin, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, err
+	}
+	sid := user.User.Sid.String()
+
+	mask, err := windows.GetLogicalDrives()
+	if err != nil {
+		return nil, err
+	}
+
+	var bins []Bin
+	for i := 0; i < 26; i++ {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+		drive := string(rune('A'+i)) + ":"
+		root, err := windows.UTF16PtrFromString(drive + `\`)
+		if err != nil || windows.GetDriveType(root) != windows.DRIVE_FIXED {
+			continue
+		}
+
+		path := filepath.Join(drive+`\`, FolderName, sid)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			bins = append(bins, Bin{Drive: drive, Path: path})
+		}
+	}
+	return bins, nil
+}
@@ -0,0 +1,58 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"abc * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) should fail", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2026, 3, 10, 14, 7, 30, 0, time.UTC) // Tuesday
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 10, 14, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 10, 14, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 3, 11, 3, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{"30 2 * * sun", time.Date(2026, 3, 15, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2026, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * mon-fri", time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2026, 3, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			c, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("ParseCron error: %v", err)
+			}
+			if got := c.Next(from); !got.Equal(tc.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tc.want)
+			}
+		})
+	}
+}
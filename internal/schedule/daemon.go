@@ -0,0 +1,210 @@
+package schedule
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/zs0c131y/burrow/internal/history"
+)
+
+// PollInterval is the longest the daemon sleeps before re-reading the job
+// definitions, so that edits made with `wm schedule` are picked up.
+const PollInterval = time.Minute
+
+// RunFunc executes a job and returns its outcome. The daemon fills in the
+// time, job name and trigger before recording the entry.
+type RunFunc func(ctx context.Context, job Job) history.Entry
+
+// Daemon runs scheduled jobs in-process.
+type Daemon struct {
+	loadJobs func() ([]Job, error)
+	state    *State
+	run      RunFunc
+
+	// Record stores a finished run; it defaults to history.Append.
+	Record func(history.Entry) error
+	// Logf reports daemon activity; it defaults to discarding messages.
+	Logf func(format string, args ...interface{})
+
+	now     func() time.Time
+	jitter  func(max time.Duration) time.Duration
+	planned map[string]plan
+}
+
+// plan is the next run chosen for a job. It is kept between ticks so that
+// the random jitter is drawn once per run rather than on every poll.
+type plan struct {
+	cron    string
+	base    time.Time
+	due     time.Time
+	trigger string
+}
+
+// NewDaemon creates a daemon that reads jobs with loadJobs, tracks last-run
+// times in state and executes due jobs with run.
+func NewDaemon(state *State, loadJobs func() ([]Job, error), run RunFunc) *Daemon {
+	return &Daemon{
+		loadJobs: loadJobs,
+		state:    state,
+		run:      run,
+		Record:   history.Append,
+		Logf:     func(string, ...interface{}) {},
+		now:      time.Now,
+		jitter: func(max time.Duration) time.Duration {
+			if max <= 0 {
+				return 0
+			}
+			return time.Duration(rand.Int63n(int64(max)))
+		},
+		planned: make(map[string]plan),
+	}
+}
+
+// Tick runs every job that is due at now and returns the earliest time a
+// job is planned for afterwards, or the zero time if nothing is planned.
+//
+// A job whose previous slot passed while the daemon was not running is run
+// immediately if it has CatchUp set; several missed slots collapse into a
+// single catch-up run. Without CatchUp the missed slots are skipped.
+func (d *Daemon) Tick(ctx context.Context, now time.Time) time.Time {
+	jobs, err := d.loadJobs()
+	if err != nil {
+		d.Logf("cannot load jobs: %v", err)
+		return time.Time{}
+	}
+
+	var next time.Time
+	active := make(map[string]bool)
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		if job.Disabled {
+			continue
+		}
+		active[job.Name] = true
+
+		p, ok := d.planFor(job, now)
+		if !ok {
+			continue
+		}
+
+		if now.Before(p.due) {
+			if next.IsZero() || p.due.Before(next) {
+				next = p.due
+			}
+			continue
+		}
+
+		d.runJob(ctx, job, p.trigger, now)
+
+		if p, ok := d.planFor(job, d.now()); ok && (next.IsZero() || p.due.Before(next)) {
+			next = p.due
+		}
+	}
+
+	for name := range d.planned {
+		if !active[name] {
+			delete(d.planned, name)
+		}
+	}
+
+	return next
+}
+
+func (d *Daemon) planFor(job Job, now time.Time) (plan, bool) {
+	sched, err := ParseCron(job.Cron)
+	if err != nil {
+		d.Logf("job %s: %v", job.Name, err)
+		return plan{}, false
+	}
+
+	last := d.state.LastRun[job.Name]
+	if last.IsZero() {
+		last = job.Created
+	}
+	if last.IsZero() {
+		last = now
+	}
+
+	base := sched.Next(last)
+	if base.IsZero() {
+		return plan{}, false
+	}
+
+	if p, ok := d.planned[job.Name]; ok && p.cron == job.Cron && p.base.Equal(base) {
+		return p, true
+	}
+
+	p := plan{cron: job.Cron, base: base, trigger: history.TriggerSchedule}
+
+	if base.Before(now) {
+		if job.CatchUp {
+			p.trigger = history.TriggerCatchUp
+			p.due = now.Add(d.jitter(job.Jitter))
+			d.Logf("job %s missed its run at %s; catching up", job.Name, base.Format(time.RFC3339))
+			d.planned[job.Name] = p
+			return p, true
+		}
+		base = sched.Next(now)
+		p.base = base
+	}
+
+	p.due = base.Add(d.jitter(job.Jitter))
+	d.planned[job.Name] = p
+	return p, true
+}
+
+func (d *Daemon) runJob(ctx context.Context, job Job, trigger string, now time.Time) {
+	d.Logf("running job %s (%s)", job.Name, trigger)
+
+	entry := d.run(ctx, job)
+	entry.Time = now
+	entry.Job = job.Name
+	entry.Trigger = trigger
+
+	if err := d.Record(entry); err != nil {
+		d.Logf("job %s: cannot record history: %v", job.Name, err)
+	}
+
+	d.state.LastRun[job.Name] = now
+	delete(d.planned, job.Name)
+	if err := d.state.Save(); err != nil {
+		d.Logf("cannot save daemon state: %v", err)
+	}
+
+	if entry.Success {
+		d.Logf("job %s finished", job.Name)
+	} else {
+		d.Logf("job %s failed: %s", job.Name, entry.Error)
+	}
+}
+
+// Run ticks until ctx is cancelled, sleeping until the next planned job or
+// PollInterval, whichever comes first.
+func (d *Daemon) Run(ctx context.Context) {
+	for {
+		now := d.now()
+		next := d.Tick(ctx, now)
+
+		wait := PollInterval
+		if !next.IsZero() {
+			if until := next.Sub(d.now()); until < wait {
+				wait = until
+			}
+		}
+		if wait < time.Second {
+			wait = time.Second
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/zs0c131y/burrow/internal/history"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestDaemon(t *testing.T, jobs []Job, clock *fakeClock) (*Daemon, *[]history.Entry) {
+	t.Helper()
+	os.Setenv("APPDATA", t.TempDir())
+	t.Cleanup(func() { os.Unsetenv("APPDATA") })
+
+	state, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var recorded []history.Entry
+	d := NewDaemon(state,
+		func() ([]Job, error) { return jobs, nil },
+		func(ctx context.Context, job Job) history.Entry {
+			return history.Entry{Command: "clean", Success: true}
+		},
+	)
+	d.now = clock.now
+	d.jitter = func(max time.Duration) time.Duration { return max / 2 }
+	d.Record = func(e history.Entry) error {
+		recorded = append(recorded, e)
+		return nil
+	}
+	return d, &recorded
+}
+
+func TestDaemonRunsDueJobWithJitter(t *testing.T) {
+	created := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{t: created}
+	jobs := []Job{{Name: "temp", Cron: "0 3 * * *", Jitter: 10 * time.Minute, Created: created}}
+
+	d, recorded := newTestDaemon(t, jobs, clock)
+	ctx := context.Background()
+
+	next := d.Tick(ctx, clock.t)
+	want := time.Date(2026, 5, 2, 3, 5, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Fatalf("next run = %s, want %s (03:00 plus jitter)", next, want)
+	}
+
+	clock.t = time.Date(2026, 5, 2, 3, 1, 0, 0, time.UTC)
+	d.Tick(ctx, clock.t)
+	if len(*recorded) != 0 {
+		t.Fatal("job ran before its jittered due time")
+	}
+
+	clock.t = want
+	next = d.Tick(ctx, clock.t)
+	if len(*recorded) != 1 {
+		t.Fatalf("recorded %d runs, want 1", len(*recorded))
+	}
+	entry := (*recorded)[0]
+	if entry.Job != "temp" || entry.Trigger != history.TriggerSchedule {
+		t.Errorf("entry = %+v", entry)
+	}
+	if wantNext := time.Date(2026, 5, 3, 3, 5, 0, 0, time.UTC); !next.Equal(wantNext) {
+		t.Errorf("next run after completion = %s, want %s", next, wantNext)
+	}
+
+	reloaded, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.LastRun["temp"].Equal(want) {
+		t.Errorf("persisted last run = %s, want %s", reloaded.LastRun["temp"], want)
+	}
+}
+
+func TestDaemonCatchUp(t *testing.T) {
+	lastRun := time.Date(2026, 5, 1, 3, 0, 0, 0, time.UTC)
+	clock := &fakeClock{t: time.Date(2026, 5, 4, 9, 30, 0, 0, time.UTC)}
+
+	jobs := []Job{
+		{Name: "catchup", Cron: "0 3 * * *", CatchUp: true},
+		{Name: "skip", Cron: "0 3 * * *"},
+	}
+	d, recorded := newTestDaemon(t, jobs, clock)
+	d.state.LastRun["catchup"] = lastRun
+	d.state.LastRun["skip"] = lastRun
+
+	next := d.Tick(context.Background(), clock.t)
+
+	if len(*recorded) != 1 {
+		t.Fatalf("recorded %d runs, want 1 (missed slots collapse into one catch-up)", len(*recorded))
+	}
+	if got := (*recorded)[0]; got.Job != "catchup" || got.Trigger != history.TriggerCatchUp {
+		t.Errorf("entry = %+v, want catch-up run of job catchup", got)
+	}
+	if want := time.Date(2026, 5, 5, 3, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("next run = %s, want %s", next, want)
+	}
+}
+
+func TestDaemonSkipsDisabledJobs(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 5, 4, 9, 30, 0, 0, time.UTC)}
+	jobs := []Job{{Name: "off", Cron: "* * * * *", CatchUp: true, Disabled: true, Created: clock.t.Add(-time.Hour)}}
+
+	d, recorded := newTestDaemon(t, jobs, clock)
+	if next := d.Tick(context.Background(), clock.t); !next.IsZero() {
+		t.Errorf("next = %s, want zero for disabled job", next)
+	}
+	if len(*recorded) != 0 {
+		t.Error("disabled job should not run")
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.lock")
+
+	lock, err := AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock error: %v", err)
+	}
+
+	// The lockfile appears with its PID already written, and the temporary
+	// file it was linked from is gone.
+	if data, _ := os.ReadFile(path); string(data) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lockfile holds %q, want this PID", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("lock folder holds %d entries, want only the lockfile", len(entries))
+	}
+
+	if _, err := AcquireLock(path); err == nil {
+		t.Error("second AcquireLock should fail while the lock is held")
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release error: %v", err)
+	}
+
+	// A lockfile naming a process that no longer exists is stale.
+	if err := os.WriteFile(path, []byte(strconv.Itoa(1<<22+7)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = AcquireLock(path)
+	if err != nil {
+		t.Fatalf("AcquireLock should replace a stale lockfile: %v", err)
+	}
+	_ = lock.Release()
+}
+
+func TestJobValidate(t *testing.T) {
+	if err := (Job{Name: "ok", Cron: "@weekly"}).Validate(); err != nil {
+		t.Errorf("valid job rejected: %v", err)
+	}
+	if err := (Job{Cron: "@weekly"}).Validate(); err == nil {
+		t.Error("job without a name should be rejected")
+	}
+	if err := (Job{Name: "bad", Cron: "every day"}).Validate(); err == nil {
+		t.Error("job with an invalid schedule should be rejected")
+	}
+}
@@ -0,0 +1,161 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+const (
+	jobsFileName  = "schedule.json"
+	stateFileName = "schedule-state.json"
+)
+
+// Job is a recurring cleanup definition.
+type Job struct {
+	Name       string        `json:"name"`
+	Cron       string        `json:"cron"`
+	Categories []string      `json:"categories,omitempty"`
+	Profile    string        `json:"profile,omitempty"`
+	Jitter     time.Duration `json:"jitter,omitempty"`
+	CatchUp    bool          `json:"catch_up"`
+	Disabled   bool          `json:"disabled,omitempty"`
+	Created    time.Time     `json:"created"`
+}
+
+// Validate checks that the job has a name and a parsable schedule.
+func (j Job) Validate() error {
+	if strings.TrimSpace(j.Name) == "" {
+		return fmt.Errorf("job name is required")
+	}
+	if strings.ContainsAny(j.Name, " \t") {
+		return fmt.Errorf("job name %q must not contain spaces", j.Name)
+	}
+	if _, err := ParseCron(j.Cron); err != nil {
+		return err
+	}
+	if j.Jitter < 0 {
+		return fmt.Errorf("jitter must not be negative")
+	}
+	return nil
+}
+
+// jobsFile is the on-disk format of schedule.json.
+type jobsFile struct {
+	Jobs []Job `json:"jobs"`
+}
+
+func configFile(name string) (string, error) {
+	dir, err := utils.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// LoadJobs reads the job definitions, sorted by name.
+func LoadJobs() ([]Job, error) {
+	path, err := configFile(jobsFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", jobsFileName, err)
+	}
+
+	var f jobsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", jobsFileName, err)
+	}
+
+	sort.Slice(f.Jobs, func(i, j int) bool { return f.Jobs[i].Name < f.Jobs[j].Name })
+	return f.Jobs, nil
+}
+
+// SaveJobs writes the job definitions.
+func SaveJobs(jobs []Job) error {
+	path, err := configFile(jobsFileName)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(jobsFile{Jobs: jobs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode jobs: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", jobsFileName, err)
+	}
+	return nil
+}
+
+// FindJob returns the index of the named job, or -1.
+func FindJob(jobs []Job, name string) int {
+	for i, j := range jobs {
+		if strings.EqualFold(j.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// State records when each job last ran. It is written only by the daemon.
+type State struct {
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+// LoadState reads the daemon state, returning an empty state if none exists.
+func LoadState() (*State, error) {
+	st := &State{LastRun: make(map[string]time.Time)}
+
+	path, err := configFile(stateFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", stateFileName, err)
+	}
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", stateFileName, err)
+	}
+	if st.LastRun == nil {
+		st.LastRun = make(map[string]time.Time)
+	}
+	return st, nil
+}
+
+// Save writes the daemon state.
+func (s *State) Save() error {
+	path, err := configFile(stateFileName)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode state: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("cannot write %s: %w", stateFileName, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	lockFileName        = "daemon.lock"
+	cleanupLockFileName = "cleanup.lock"
+)
+
+// Lock is an exclusive lockfile held by a running daemon.
+type Lock struct {
+	path string
+}
+
+// DefaultLockPath returns the lockfile location in the config directory.
+func DefaultLockPath() (string, error) {
+	return configFile(lockFileName)
+}
+
+// CleanupLockPath returns the location of the lockfile held while any
+// cleanup runs, whether started by the daemon, by wm watch or by hand.
+func CleanupLockPath() (string, error) {
+	return configFile(cleanupLockFileName)
+}
+
+// AcquireLock creates the lockfile at path containing the current PID. If a
+// live process already holds the lock an error naming it is returned; a
+// lockfile left behind by a process that has exited is replaced. The PID is
+// written to a temporary file that is then linked into place, so the
+// lockfile never exists without its PID for another process to misread.
+func AcquireLock(path string) (*Lock, error) {
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		return nil, fmt.Errorf("cannot write lockfile %s: %w", path, err)
+	}
+	defer os.Remove(tmp)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(tmp, path)
+		if err == nil {
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("cannot create lockfile %s: %w", path, err)
+		}
+
+		pid, alive := lockOwner(path)
+		if alive {
+			return nil, fmt.Errorf("another Burrow process is already running (PID %d, lockfile %s)", pid, path)
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot remove stale lockfile %s: %w", path, err)
+		}
+	}
+
+	return nil, fmt.Errorf("cannot acquire lockfile %s", path)
+}
+
+// lockOwner returns the PID recorded in the lockfile and whether that process
+// is still running. Unreadable lockfiles are treated as stale.
+func lockOwner(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	if pid == os.Getpid() {
+		return pid, true
+	}
+
+	exists, err := process.PidExists(int32(pid))
+	return pid, err == nil && exists
+}
+
+// Release removes the lockfile.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove lockfile %s: %w", l.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package trash
+
+import "encoding/binary"
+
+// shFileOpStruct is SHFILEOPSTRUCTW as laid out by 32-bit Windows, which packs
+// it to one byte: the fields after fFlags start at offset 18, so they are
+// kept as raw bytes. Burrow leaves them zero apart from reading
+// fAnyOperationsAborted back.
+type shFileOpStruct struct {
+	hwnd   uintptr
+	wFunc  uint32
+	pFrom  *uint16
+	pTo    *uint16
+	fFlags uint16
+	rest   [12]byte
+}
+
+func (op *shFileOpStruct) aborted() bool {
+	return binary.LittleEndian.Uint32(op.rest[0:4]) != 0
+}
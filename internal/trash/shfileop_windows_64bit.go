@@ -0,0 +1,20 @@
+//go:build windows && !386
+
+package trash
+
+// shFileOpStruct is SHFILEOPSTRUCTW with the natural alignment used by 64-bit
+// Windows.
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+func (op *shFileOpStruct) aborted() bool {
+	return op.fAnyOperationsAborted != 0
+}
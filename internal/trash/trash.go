@@ -0,0 +1,31 @@
+// Package trash sends files to the platform's trash instead of deleting them,
+// so that they can be restored with the usual desktop tools: the Recycle Bin
+// on Windows and the freedesktop.org Trash elsewhere.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrUnavailable is returned for files on a volume without a trash, such as
+// network shares. Such files are left in place.
+var ErrUnavailable = errors.New("no trash available for this location")
+
+// Move sends the file or directory at path to the trash.
+func Move(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("cannot move %s to the trash: %w", path, err)
+	}
+	if err := moveToTrash(path, info); err != nil {
+		return fmt.Errorf("cannot move %s to the trash: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build !windows
+
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zs0c131y/burrow/pkg/utils"
+)
+
+// moveToTrash implements the freedesktop.org Trash specification. Files on
+// the same filesystem as the user's data directory go to the home trash;
+// files on other mounts go to the trash at the top of that mount.
+func moveToTrash(path string, info os.FileInfo) error {
+	dir, topdir, err := trashDirFor(path, info)
+	if err != nil {
+		return err
+	}
+	return trashInto(dir, topdir, path)
+}
+
+// homeTrash returns $XDG_DATA_HOME/Trash, defaulting to
+// ~/.local/share/Trash.
+func homeTrash() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "Trash"), nil
+}
+
+// trashDirFor picks the trash directory for path. topdir is empty for the
+// home trash, which records absolute paths; otherwise it is the mount point
+// the recorded paths are relative to.
+func trashDirFor(path string, info os.FileInfo) (dir, topdir string, err error) {
+	dev, ok := utils.DeviceID(path, info)
+	if !ok {
+		return "", "", ErrUnavailable
+	}
+
+	home, err := homeTrash()
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(home, 0o700); err != nil {
+		return "", "", err
+	}
+	if homeInfo, err := os.Stat(home); err == nil {
+		if homeDev, ok := utils.DeviceID(home, homeInfo); ok && homeDev == dev {
+			return home, "", nil
+		}
+	}
+
+	topdir = mountPoint(path, dev)
+	uid := strconv.Itoa(os.Getuid())
+
+	// An administrator-provided $topdir/.Trash must be a real directory
+	// with the sticky bit set; each user gets a subdirectory in it.
+	shared := filepath.Join(topdir, ".Trash")
+	if fi, err := os.Lstat(shared); err == nil && fi.IsDir() && fi.Mode()&os.ModeSticky != 0 {
+		dir = filepath.Join(shared, uid)
+		if err := os.MkdirAll(dir, 0o700); err == nil {
+			return dir, topdir, nil
+		}
+	}
+
+	dir = filepath.Join(topdir, ".Trash-"+uid)
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", "", ErrUnavailable
+	}
+	if fi, err := os.Lstat(dir); err != nil || !fi.IsDir() {
+		return "", "", ErrUnavailable
+	}
+	return dir, topdir, nil
+}
+
+// mountPoint returns the topmost directory above path on the device dev.
+func mountPoint(path string, dev uint64) string {
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		info, err := os.Stat(parent)
+		if err != nil {
+			return dir
+		}
+		if d, ok := utils.DeviceID(parent, info); !ok || d != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// trashInto moves path into the trash directory dir. The .trashinfo file is
+// created first, exclusively, to claim a name in the trash.
+func trashInto(dir, topdir, path string) error {
+	files := filepath.Join(dir, "files")
+	info := filepath.Join(dir, "info")
+	for _, d := range []string{files, info} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			return err
+		}
+	}
+
+	recorded := path
+	if topdir != "" {
+		rel, err := filepath.Rel(topdir, path)
+		if err != nil {
+			return err
+		}
+		recorded = rel
+	}
+	content := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		escapePath(recorded), time.Now().Format("2006-01-02T15:04:05"))
+
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	for n := 1; ; n++ {
+		name := base
+		if n > 1 {
+			name = stem + "." + strconv.Itoa(n) + ext
+		}
+
+		infoPath := filepath.Join(info, name+".trashinfo")
+		f, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(content)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			return err
+		}
+
+		if err := os.Rename(path, filepath.Join(files, name)); err != nil {
+			os.Remove(infoPath)
+			return err
+		}
+		return nil
+	}
+}
+
+// escapePath percent-encodes each component of p as the specification
+// requires, keeping the separators.
+func escapePath(p string) string {
+	parts := strings.Split(filepath.ToSlash(p), "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,81 @@
+//go:build !windows
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveToHomeTrash(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	trashDir := filepath.Join(base, "data", "Trash")
+
+	src := filepath.Join(base, "my report.txt")
+	for i := 0; i < 2; i++ {
+		if err := os.WriteFile(src, []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := Move(src); err != nil {
+			t.Fatalf("Move error: %v", err)
+		}
+		if _, err := os.Lstat(src); !os.IsNotExist(err) {
+			t.Fatal("file is still in its original location")
+		}
+	}
+
+	for _, name := range []string{"my report.txt", "my report.2.txt"} {
+		if _, err := os.Stat(filepath.Join(trashDir, "files", name)); err != nil {
+			t.Errorf("trashed file %s missing: %v", name, err)
+		}
+		info, err := os.ReadFile(filepath.Join(trashDir, "info", name+".trashinfo"))
+		if err != nil {
+			t.Fatalf("trashinfo for %s missing: %v", name, err)
+		}
+		lines := strings.Split(string(info), "\n")
+		if lines[0] != "[Trash Info]" {
+			t.Errorf("trashinfo header = %q", lines[0])
+		}
+		if want := "Path=" + escapePath(src); lines[1] != want {
+			t.Errorf("trashinfo path = %q, want %q", lines[1], want)
+		}
+		if !strings.HasPrefix(lines[2], "DeletionDate=") || len(lines[2]) != len("DeletionDate=2006-01-02T15:04:05") {
+			t.Errorf("trashinfo date = %q", lines[2])
+		}
+	}
+}
+
+func TestMoveDirectoryToTrash(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+
+	dir := filepath.Join(base, "leftovers")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "sub", "a.dat"), []byte("x"), 0o644)
+
+	if err := Move(dir); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "data", "Trash", "files", "leftovers", "sub", "a.dat")); err != nil {
+		t.Errorf("directory contents not in the trash: %v", err)
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"/home/user/file.txt", "/home/user/file.txt"},
+		{"/home/user/my file.txt", "/home/user/my%20file.txt"},
+		{"docs/100%.txt", "docs/100%25.txt"},
+		{"/tmp/naïve", "/tmp/na%C3%AFve"},
+	}
+	for _, tt := range tests {
+		if got := escapePath(tt.in); got != tt.want {
+			t.Errorf("escapePath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
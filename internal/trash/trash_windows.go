@@ -0,0 +1,61 @@
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	shell32              = windows.NewLazySystemDLL("shell32.dll")
+	procSHFileOperationW = shell32.NewProc("SHFileOperationW")
+)
+
+// SHFileOperation values used to recycle a file without any UI, except the
+// warning shown when a file is too large for the Recycle Bin and would be
+// deleted for good.
+const (
+	foDelete           = 0x0003
+	fofSilent          = 0x0004
+	fofNoConfirmation  = 0x0010
+	fofAllowUndo       = 0x0040
+	fofNoErrorUI       = 0x0400
+	fofWantNukeWarning = 0x4000
+)
+
+// moveToTrash sends path to the Recycle Bin through the shell. Only fixed
+// drives have a Recycle Bin; the shell would delete files on other drives
+// permanently, so they are refused.
+func moveToTrash(path string, _ os.FileInfo) error {
+	root, err := windows.UTF16PtrFromString(filepath.VolumeName(path) + `\`)
+	if err != nil {
+		return err
+	}
+	if windows.GetDriveType(root) != windows.DRIVE_FIXED {
+		return ErrUnavailable
+	}
+
+	// pFrom is a list of names ended by an extra NUL.
+	from, err := windows.UTF16FromString(path)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0)
+
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI | fofWantNukeWarning,
+	}
+	ret, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op)))
+	if ret != 0 {
+		return fmt.Errorf("SHFileOperation failed with code 0x%x", ret)
+	}
+	if op.aborted() {
+		return fmt.Errorf("the operation was cancelled")
+	}
+	return nil
+}
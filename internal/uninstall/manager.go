@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/zs0c131y/burrow/internal/trash"
 	"github.com/zs0c131y/burrow/pkg/models"
 	"github.com/zs0c131y/burrow/pkg/utils"
 	"golang.org/x/sys/windows/registry"
@@ -17,6 +18,7 @@ import (
 type UninstallManager struct {
 	debug  bool
 	dryRun bool
+	trash  bool
 }
 
 // UninstallResult captures the outcome of an uninstall operation.
@@ -40,6 +42,12 @@ func NewUninstallManager(debug, dryRun bool) *UninstallManager {
 	}
 }
 
+// SetTrash makes UninstallApplication send leftover files to the Recycle Bin
+// instead of deleting them.
+func (um *UninstallManager) SetTrash(on bool) {
+	um.trash = on
+}
+
 // registrySource tracks which root and path an application was discovered from,
 // so we can correctly remove it later.
 type registrySource struct {
@@ -217,7 +225,7 @@ func (um *UninstallManager) UninstallApplication(app *models.Application) *Unins
 			result.Errors = append(result.Errors, fmt.Sprintf("Cannot measure %s: %v", loc, err))
 			continue
 		}
-		if err := utils.SafeDelete(loc, 3); err != nil {
+		if err := um.removeLeftover(loc); err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("Cannot remove %s: %v", loc, err))
 		} else {
 			result.FilesRemoved += count
@@ -279,6 +287,15 @@ func (um *UninstallManager) runNativeUninstaller(app *models.Application) error
 	return nil
 }
 
+// removeLeftover deletes a leftover location, or sends it to the Recycle Bin
+// when trashing is enabled.
+func (um *UninstallManager) removeLeftover(path string) error {
+	if um.trash {
+		return trash.Move(path)
+	}
+	return utils.SafeDelete(path, 3)
+}
+
 func (um *UninstallManager) findRelatedLocations(app *models.Application) []string {
 	var locations []string
 
@@ -0,0 +1,219 @@
+// Package watch triggers cleanup when a volume runs low on free space.
+package watch
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Volume is a snapshot of one mounted volume's capacity.
+type Volume struct {
+	Mount string
+	Total uint64
+	Free  uint64
+}
+
+// FreePercent returns the free space as a percentage of capacity.
+func (v Volume) FreePercent() float64 {
+	if v.Total == 0 {
+		return 0
+	}
+	return float64(v.Free) / float64(v.Total) * 100
+}
+
+// Policy decides when a volume is low on space.
+//
+// A volume is low when its free space drops below the larger of
+// MinFreePercent of its capacity and MinFreeBytes. After a volume triggers a
+// cleanup it does not trigger again until its free space has recovered by
+// Hysteresis percentage points above that threshold, and never sooner than
+// Cooldown after the previous cleanup.
+type Policy struct {
+	MinFreePercent float64
+	MinFreeBytes   uint64
+	Hysteresis     float64
+	Cooldown       time.Duration
+}
+
+// Threshold returns the free-space level below which v is low.
+func (p Policy) Threshold(v Volume) uint64 {
+	t := uint64(p.MinFreePercent / 100 * float64(v.Total))
+	if p.MinFreeBytes > t {
+		t = p.MinFreeBytes
+	}
+	return t
+}
+
+// rearmLevel returns the free space v must reach before it may trigger again.
+func (p Policy) rearmLevel(v Volume) uint64 {
+	return p.Threshold(v) + uint64(p.Hysteresis/100*float64(v.Total))
+}
+
+// Enabled reports whether the policy has any threshold set.
+func (p Policy) Enabled() bool {
+	return p.MinFreePercent > 0 || p.MinFreeBytes > 0
+}
+
+type volumeState struct {
+	tripped bool
+	lastRun time.Time
+}
+
+// CleanProfile returns the configuration profile cleanups run with: the
+// --profile flag when it was given, else the configured watch.profile.
+func CleanProfile(flagProfile, configured string) string {
+	if flagProfile != "" {
+		return flagProfile
+	}
+	return configured
+}
+
+// Monitor tracks volumes between polls and reports those that need cleanup.
+type Monitor struct {
+	policy  Policy
+	mounts  map[string]bool
+	volumes func() ([]Volume, error)
+	state   map[string]*volumeState
+
+	// Logf reports state changes; it defaults to discarding messages.
+	Logf func(format string, args ...interface{})
+
+	now func() time.Time
+}
+
+// NewMonitor creates a monitor for the given mount points, or for every
+// fixed volume when mounts is empty.
+func NewMonitor(policy Policy, mounts []string) *Monitor {
+	m := &Monitor{
+		policy:  policy,
+		volumes: Volumes,
+		state:   make(map[string]*volumeState),
+		Logf:    func(string, ...interface{}) {},
+		now:     time.Now,
+	}
+	if len(mounts) > 0 {
+		m.mounts = make(map[string]bool)
+		for _, mount := range mounts {
+			m.mounts[normalizeMount(mount)] = true
+		}
+	}
+	return m
+}
+
+// Check polls the volumes once and returns those that crossed the threshold
+// and are armed and out of their cooldown. The caller is expected to run a
+// cleanup for each volume returned.
+func (m *Monitor) Check() ([]Volume, error) {
+	vols, err := m.volumes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	var low []Volume
+
+	for _, v := range vols {
+		key := normalizeMount(v.Mount)
+		if m.mounts != nil && !m.mounts[key] {
+			continue
+		}
+		if v.Total == 0 {
+			continue
+		}
+
+		st, ok := m.state[key]
+		if !ok {
+			st = &volumeState{}
+			m.state[key] = st
+		}
+
+		if st.tripped {
+			if v.Free >= m.policy.rearmLevel(v) {
+				st.tripped = false
+				m.Logf("%s recovered: %.1f%% free", v.Mount, v.FreePercent())
+			}
+			continue
+		}
+
+		if v.Free >= m.policy.Threshold(v) {
+			continue
+		}
+
+		if !st.lastRun.IsZero() && now.Sub(st.lastRun) < m.policy.Cooldown {
+			continue
+		}
+
+		st.tripped = true
+		st.lastRun = now
+		low = append(low, v)
+	}
+
+	return low, nil
+}
+
+// Run polls every interval until ctx is cancelled and calls onLow for each
+// volume that needs cleanup.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration, onLow func(context.Context, Volume)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		low, err := m.Check()
+		if err != nil {
+			m.Logf("cannot read disk usage: %v", err)
+		}
+		for _, v := range low {
+			if ctx.Err() != nil {
+				return
+			}
+			onLow(ctx, v)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Volumes returns the usage of every mounted volume with a file system.
+func Volumes() ([]Volume, error) {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var vols []Volume
+	for _, partition := range partitions {
+		if partition.Fstype == "" {
+			continue
+		}
+		usage, err := disk.Usage(partition.Mountpoint)
+		if err != nil || usage == nil {
+			continue
+		}
+		vols = append(vols, Volume{Mount: partition.Mountpoint, Total: usage.Total, Free: usage.Free})
+	}
+	return vols, nil
+}
+
+// OnVolume reports whether path lies on the volume mounted at mount, e.g.
+// C:\Windows\Temp on "C:". A root mount of "/" holds every absolute path.
+func OnVolume(path, mount string) bool {
+	p := normalizeMount(strings.ReplaceAll(path, `\`, "/"))
+	m := normalizeMount(strings.ReplaceAll(mount, `\`, "/"))
+	return m == "/" || p == m || strings.HasPrefix(p, m+"/")
+}
+
+// normalizeMount makes "c:", "C:\" and "C:" compare equal.
+func normalizeMount(mount string) string {
+	mount = strings.ToUpper(strings.TrimSpace(mount))
+	if len(mount) > 1 {
+		mount = strings.TrimRight(mount, `\/`)
+	}
+	return mount
+}
@@ -0,0 +1,140 @@
+package watch
+
+import (
+	"testing"
+	"time"
+)
+
+const gb = 1 << 30
+
+func TestPolicyThreshold(t *testing.T) {
+	v := Volume{Mount: "C:", Total: 100 * gb}
+
+	tests := []struct {
+		policy Policy
+		want   uint64
+	}{
+		{Policy{MinFreePercent: 10}, 10 * gb},
+		{Policy{MinFreeBytes: 20 * gb}, 20 * gb},
+		{Policy{MinFreePercent: 10, MinFreeBytes: 5 * gb}, 10 * gb},
+		{Policy{MinFreePercent: 10, MinFreeBytes: 15 * gb}, 15 * gb},
+	}
+
+	for _, tc := range tests {
+		if got := tc.policy.Threshold(v); got != tc.want {
+			t.Errorf("Threshold(%+v) = %d, want %d", tc.policy, got, tc.want)
+		}
+	}
+}
+
+func TestMonitorHysteresisAndCooldown(t *testing.T) {
+	free := uint64(50 * gb)
+	clock := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	m := NewMonitor(Policy{MinFreePercent: 10, Hysteresis: 5, Cooldown: time.Hour}, []string{`c:\`})
+	m.now = func() time.Time { return clock }
+	m.volumes = func() ([]Volume, error) {
+		return []Volume{
+			{Mount: "C:", Total: 100 * gb, Free: free},
+			{Mount: "D:", Total: 100 * gb, Free: 1 * gb},
+		}, nil
+	}
+
+	check := func(step string, want int) {
+		t.Helper()
+		low, err := m.Check()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(low) != want {
+			t.Fatalf("%s: %d volumes triggered, want %d", step, len(low), want)
+		}
+		if want > 0 && low[0].Mount != "C:" {
+			t.Fatalf("%s: triggered %s, want only the watched mount C:", step, low[0].Mount)
+		}
+	}
+
+	check("plenty of space", 0)
+
+	free = 9 * gb
+	check("below threshold", 1)
+
+	clock = clock.Add(2 * time.Hour)
+	check("still low after cooldown", 0)
+
+	free = 12 * gb
+	check("above threshold but inside hysteresis band", 0)
+	free = 9 * gb
+	check("dropped again without re-arming", 0)
+
+	free = 16 * gb
+	check("recovered past hysteresis band", 0)
+
+	clock = clock.Add(10 * time.Minute)
+	free = 9 * gb
+	check("low again after re-arm", 1)
+
+	free = 20 * gb
+	check("recovered", 0)
+	clock = clock.Add(10 * time.Minute)
+	free = 9 * gb
+	check("low again within cooldown", 0)
+
+	clock = clock.Add(time.Hour)
+	check("cooldown elapsed", 1)
+}
+
+func TestMonitorAllVolumes(t *testing.T) {
+	m := NewMonitor(Policy{MinFreeBytes: 5 * gb}, nil)
+	m.volumes = func() ([]Volume, error) {
+		return []Volume{
+			{Mount: "C:", Total: 100 * gb, Free: 1 * gb},
+			{Mount: "D:", Total: 100 * gb, Free: 1 * gb},
+			{Mount: "E:", Total: 0, Free: 0},
+		}, nil
+	}
+
+	low, err := m.Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(low) != 2 {
+		t.Errorf("%d volumes triggered, want 2 (zero-capacity volumes ignored)", len(low))
+	}
+}
+
+func TestCleanProfile(t *testing.T) {
+	tests := []struct {
+		flag, configured, want string
+	}{
+		{"", "", ""},
+		{"", "aggressive", "aggressive"},
+		{"dev", "aggressive", "dev"},
+		{"dev", "", "dev"},
+	}
+	for _, tt := range tests {
+		if got := CleanProfile(tt.flag, tt.configured); got != tt.want {
+			t.Errorf("CleanProfile(%q, %q) = %q, want %q", tt.flag, tt.configured, got, tt.want)
+		}
+	}
+}
+
+func TestOnVolume(t *testing.T) {
+	tests := []struct {
+		path, mount string
+		want        bool
+	}{
+		{`C:\Windows\Temp`, "C:", true},
+		{`c:\Users\ana\AppData\Local\Temp`, `C:\`, true},
+		{`D:\Cache`, "C:", false},
+		{`C:`, "C:", true},
+		{"/var/tmp", "/", true},
+		{"/mnt/data/cache", "/mnt/data", true},
+		{"/mnt/database", "/mnt/data", false},
+	}
+	for _, tt := range tests {
+		if got := OnVolume(tt.path, tt.mount); got != tt.want {
+			t.Errorf("OnVolume(%q, %q) = %v, want %v", tt.path, tt.mount, got, tt.want)
+		}
+	}
+}
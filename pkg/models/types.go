@@ -2,15 +2,39 @@ package models
 
 // CleanupTarget represents a path that can be cleaned.
 type CleanupTarget struct {
-	Name        string
-	Path        string
-	Description string
-	Size        int64
-	ItemCount   int
-	Category    CleanupCategory
-	Protected   bool
+	Name           string
+	Path           string
+	Description    string
+	Size           int64
+	ItemCount      int
+	Category       CleanupCategory
+	Protected      bool
+	ProtectedItems int
+	Action         CleanupAction
+
+	// BlockedSize and BlockedItems are the part of Size that was in use or
+	// could not be deleted when the target was sized, and will most likely
+	// be left behind.
+	BlockedSize  int64
+	BlockedItems int
+}
+
+// GuaranteedSize is the part of Size nothing was found to stand in the way
+// of removing.
+func (t *CleanupTarget) GuaranteedSize() int64 {
+	return t.Size - t.BlockedSize
 }
 
+// CleanupAction is what cleaning a target does with its files.
+type CleanupAction string
+
+const (
+	// ActionDelete removes the files. It is the zero value.
+	ActionDelete CleanupAction = ""
+	// ActionCompress replaces old files with verified compressed archives.
+	ActionCompress CleanupAction = "compress"
+)
+
 // CleanupCategory identifies the type of cleanup target.
 type CleanupCategory string
 
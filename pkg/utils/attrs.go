@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// Attributes are the file attributes the analyzer reports. On Windows they
+// come from the file's attribute bits; elsewhere they are derived from the
+// name and mode.
+type Attributes uint8
+
+const (
+	// AttrHidden is the Windows hidden attribute, or a dotfile elsewhere.
+	AttrHidden Attributes = 1 << iota
+	// AttrSystem is the Windows system attribute.
+	AttrSystem
+	// AttrReadOnly is the read-only attribute, or a mode without write bits.
+	AttrReadOnly
+	// AttrReparsePoint marks reparse points on Windows and symbolic links
+	// elsewhere.
+	AttrReparsePoint
+	// AttrOffline marks files whose data is not stored locally, such as
+	// cloud-only OneDrive placeholders.
+	AttrOffline
+)
+
+var attributeNames = []struct {
+	attr Attributes
+	name string
+}{
+	{AttrHidden, "hidden"},
+	{AttrSystem, "system"},
+	{AttrReadOnly, "readonly"},
+	{AttrReparsePoint, "reparse-point"},
+	{AttrOffline, "offline"},
+}
+
+// Has reports whether all of the attributes in flags are set.
+func (a Attributes) Has(flags Attributes) bool {
+	return a&flags == flags
+}
+
+// String lists the set attributes, e.g. "hidden,system".
+func (a Attributes) String() string {
+	var names []string
+	for _, n := range attributeNames {
+		if a.Has(n.attr) {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// GetAttributes returns the attributes of the entry named name, described by
+// info from os.Lstat or a directory listing.
+func GetAttributes(name string, info os.FileInfo) Attributes {
+	return getAttributes(name, info)
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"strings"
+)
+
+// getAttributes treats dotfiles as hidden and entries without any write
+// permission as read-only. Unix has no system or offline attribute.
+func getAttributes(name string, info os.FileInfo) Attributes {
+	var a Attributes
+	if strings.HasPrefix(name, ".") {
+		a |= AttrHidden
+	}
+	if info.Mode().Perm()&0o222 == 0 {
+		a |= AttrReadOnly
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		a |= AttrReparsePoint
+	}
+	return a
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// Attribute bits of cloud files whose content is fetched on access; they are
+// not all defined by x/sys/windows.
+const (
+	fileAttributeRecallOnOpen       = 0x00040000
+	fileAttributeRecallOnDataAccess = 0x00400000
+)
+
+// getAttributes reads the Windows attribute bits from info. A file is
+// offline when its data lives in the cloud and would be downloaded on access.
+func getAttributes(_ string, info os.FileInfo) Attributes {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return 0
+	}
+	fa := data.FileAttributes
+
+	var a Attributes
+	if fa&windows.FILE_ATTRIBUTE_HIDDEN != 0 {
+		a |= AttrHidden
+	}
+	if fa&windows.FILE_ATTRIBUTE_SYSTEM != 0 {
+		a |= AttrSystem
+	}
+	if fa&windows.FILE_ATTRIBUTE_READONLY != 0 {
+		a |= AttrReadOnly
+	}
+	if fa&windows.FILE_ATTRIBUTE_REPARSE_POINT != 0 {
+		a |= AttrReparsePoint
+	}
+	if fa&(windows.FILE_ATTRIBUTE_OFFLINE|fileAttributeRecallOnOpen|fileAttributeRecallOnDataAccess) != 0 {
+		a |= AttrOffline
+	}
+	return a
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ExcludeList matches paths against exclude glob patterns. A pattern without
+// a path separator, such as "node_modules" or "*.vhdx", matches an entry by
+// name anywhere in the tree. A pattern with a separator matches whole path
+// components: an absolute pattern matches from the root, and a relative one
+// such as "build/cache" matches the end of a path. Matching ignores case on
+// Windows.
+type ExcludeList struct {
+	patterns []string
+}
+
+// NewExcludeList validates patterns and returns a list matching them. Empty
+// patterns are ignored.
+func NewExcludeList(patterns []string) (*ExcludeList, error) {
+	l := &ExcludeList{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p = filepath.Clean(filepath.FromSlash(p))
+		if runtime.GOOS == "windows" {
+			p = strings.ToLower(p)
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+		}
+		l.patterns = append(l.patterns, p)
+	}
+	return l, nil
+}
+
+// ReadExcludeFile reads exclude patterns from a file, one per line. Blank
+// lines and lines starting with '#' are skipped.
+func ReadExcludeFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exclude file: %w", err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read exclude file: %w", err)
+	}
+	return patterns, nil
+}
+
+// Patterns returns the normalized patterns in the list.
+func (l *ExcludeList) Patterns() []string {
+	if l == nil {
+		return nil
+	}
+	return l.patterns
+}
+
+// Len returns the number of patterns in the list.
+func (l *ExcludeList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.patterns)
+}
+
+// Match reports whether path is excluded. A nil list excludes nothing.
+func (l *ExcludeList) Match(path string) bool {
+	if l.Len() == 0 {
+		return false
+	}
+
+	path = filepath.Clean(path)
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+	}
+	sep := string(filepath.Separator)
+
+	for _, p := range l.patterns {
+		if !strings.Contains(p, sep) {
+			if ok, _ := filepath.Match(p, filepath.Base(path)); ok {
+				return true
+			}
+			continue
+		}
+
+		if filepath.IsAbs(p) || strings.HasPrefix(p, sep) {
+			if ok, _ := filepath.Match(p, path); ok {
+				return true
+			}
+			continue
+		}
+
+		// Match the pattern against the last as many components of path
+		// as it has.
+		parts := strings.Split(path, sep)
+		n := strings.Count(p, sep) + 1
+		if n > len(parts) {
+			continue
+		}
+		if ok, _ := filepath.Match(p, strings.Join(parts[len(parts)-n:], sep)); ok {
+			return true
+		}
+	}
+	return false
+}
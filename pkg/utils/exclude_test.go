@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExcludeListMatch(t *testing.T) {
+	root := filepath.FromSlash("/data")
+	list, err := NewExcludeList([]string{"node_modules", "*.vhdx", "build/cache", "/data/vms/*", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.Len() != 4 {
+		t.Errorf("Len() = %d, want 4", list.Len())
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/node_modules", true},
+		{"src/node_modules_old", false},
+		{"disks/win.vhdx", true},
+		{"app/build/cache", true},
+		{"build/cache/x", false},
+		{"cache", false},
+		{"vms/ubuntu", true},
+		{"old/vms/ubuntu", false},
+		{"src/main.go", false},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(root, filepath.FromSlash(tt.path))
+		if got := list.Match(path); got != tt.want {
+			t.Errorf("Match(%s) = %v, want %v", path, got, tt.want)
+		}
+	}
+
+	var none *ExcludeList
+	if none.Match(root) {
+		t.Error("a nil list should exclude nothing")
+	}
+}
+
+func TestNewExcludeListInvalid(t *testing.T) {
+	if _, err := NewExcludeList([]string{"[abc"}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestReadExcludeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "excludes.txt")
+	content := "# caches\nnode_modules\n\n  *.iso  \n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := ReadExcludeFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) != 2 || patterns[0] != "node_modules" || patterns[1] != "*.iso" {
+		t.Errorf("patterns = %q", patterns)
+	}
+}
+
+func TestGetDirStatsExclude(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "node_modules", "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "node_modules", "pkg", "index.js"), make([]byte, 300), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), make([]byte, 100), 0o644)
+
+	list, _ := NewExcludeList([]string{"node_modules"})
+	stats, err := GetDirStats(tmpDir, WalkOptions{Exclude: list.Match, OneFileSystem: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Size != 100 || stats.Files != 1 || stats.Excluded != 1 {
+		t.Errorf("stats = %+v, want 100 bytes in 1 file with 1 exclusion", stats)
+	}
+}
+
+func TestGetDirStatsOneFileSystem(t *testing.T) {
+	tmpDir := t.TempDir()
+	other, err := os.MkdirTemp("/dev/shm", "burrow-")
+	if err != nil {
+		t.Skip("no second filesystem available")
+	}
+	defer os.RemoveAll(other)
+
+	rootInfo, _ := os.Stat(tmpDir)
+	otherInfo, _ := os.Stat(other)
+	rootDev, ok1 := DeviceID(tmpDir, rootInfo)
+	otherDev, ok2 := DeviceID(other, otherInfo)
+	if !ok1 || !ok2 || rootDev == otherDev {
+		t.Skip("no second filesystem available")
+	}
+
+	os.WriteFile(filepath.Join(other, "mounted.bin"), make([]byte, 500), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "local.bin"), make([]byte, 100), 0o644)
+	if err := os.Symlink(other, filepath.Join(tmpDir, "mnt")); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	for _, tc := range []struct {
+		oneFS           bool
+		size            int64
+		files, excluded int
+	}{
+		{false, 600, 2, 0},
+		{true, 100, 1, 1},
+	} {
+		stats, err := GetDirStats(tmpDir, WalkOptions{FollowLinks: true, Tracker: NewLinkTracker(), OneFileSystem: tc.oneFS})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Size != tc.size || stats.Files != tc.files || stats.Excluded != tc.excluded {
+			t.Errorf("OneFileSystem=%v: stats = %+v, want %d bytes in %d files with %d exclusions",
+				tc.oneFS, stats, tc.size, tc.files, tc.excluded)
+		}
+	}
+}
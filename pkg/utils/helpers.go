@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -57,23 +60,122 @@ func FormatDuration(d time.Duration) string {
 }
 
 // GetDirSize calculates total size of a directory recursively.
-// Returns (totalBytes, fileCount, error). Inaccessible files are silently skipped.
+// Returns (totalBytes, fileCount, error). Inaccessible files are silently skipped
+// and links are not followed. Use GetDirStats to apply exclusions or to stay on
+// one filesystem.
 func GetDirSize(path string) (int64, int, error) {
-	var size int64
-	var count int
+	stats, err := GetDirStats(path, WalkOptions{})
+	return stats.Size, stats.Files, err
+}
+
+// DirStats summarizes the contents of a directory tree.
+type DirStats struct {
+	Size     int64
+	Files    int
+	Links    int
+	Excluded int
+	// ExcludedFiles counts the files at or beneath the excluded entries.
+	ExcludedFiles int
+
+	// Blocked and BlockedFiles are the bytes and files of the tree that
+	// WalkOptions.Probe rejected.
+	Blocked      int64
+	BlockedFiles int
+}
+
+// GetDirStats calculates the size of a directory tree according to opts.
+// The root is always resolved, but links found beneath it are only counted
+// unless opts.FollowLinks is set. Entries rejected by opts.Exclude, and with
+// opts.OneFileSystem directories on another filesystem, are counted but not
+// sized. Inaccessible entries are silently skipped.
+func GetDirStats(path string, opts WalkOptions) (DirStats, error) {
+	var stats DirStats
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return stats, err
+	}
+
+	if !info.IsDir() {
+		stats.Size = info.Size()
+		stats.Files = 1
+		if opts.Probe != nil && opts.Probe(path) != nil {
+			stats.Blocked = stats.Size
+			stats.BlockedFiles = 1
+		}
+		return stats, nil
+	}
+
+	if opts.FollowLinks {
+		if opts.Tracker == nil {
+			opts.Tracker = NewLinkTracker()
+		}
+		opts.Tracker.Visit(path)
+	}
 
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+	if opts.OneFileSystem {
+		dev, ok := DeviceID(path, info)
+		opts.OneFileSystem = ok
+		opts.device = dev
+	}
+
+	walkDirStats(path, opts, &stats)
+	return stats, nil
+}
+
+func walkDirStats(dirPath string, opts WalkOptions, stats *DirStats) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		fullPath := filepath.Join(dirPath, entry.Name())
+
+		if opts.Exclude != nil && opts.Exclude(fullPath) {
+			stats.Excluded++
+			stats.ExcludedFiles += countFiles(fullPath, entry)
+			continue
+		}
+
+		info, err := entry.Info()
 		if err != nil {
-			return nil
+			continue
 		}
-		if !info.IsDir() {
-			size += info.Size()
-			count++
+
+		if IsLink(fullPath, info) {
+			stats.Links++
+			if !opts.FollowLinks {
+				continue
+			}
+			target, err := os.Stat(fullPath)
+			if err != nil {
+				continue
+			}
+			info = target
 		}
-		return nil
-	})
 
-	return size, count, err
+		if info.IsDir() {
+			if opts.FollowLinks && !opts.Tracker.Visit(fullPath) {
+				continue
+			}
+			if opts.OneFileSystem {
+				if dev, ok := DeviceID(fullPath, info); ok && dev != opts.device {
+					stats.Excluded++
+					continue
+				}
+			}
+			walkDirStats(fullPath, opts, stats)
+			continue
+		}
+
+		stats.Size += info.Size()
+		stats.Files++
+		if opts.Probe != nil && opts.Probe(fullPath) != nil {
+			stats.Blocked += info.Size()
+			stats.BlockedFiles++
+		}
+	}
 }
 
 // PathExists checks if a path exists.
@@ -156,6 +258,7 @@ func GetSystemPaths() map[string]string {
 }
 
 // SafeDelete attempts to delete a file or directory with retry logic for locked files.
+// A link is removed on its own; its target is never touched.
 func SafeDelete(path string, maxRetries int) error {
 	if maxRetries <= 0 {
 		maxRetries = 1
@@ -164,7 +267,7 @@ func SafeDelete(path string, maxRetries int) error {
 	var lastErr error
 
 	for i := 0; i < maxRetries; i++ {
-		info, err := os.Stat(path)
+		info, err := os.Lstat(path)
 		if os.IsNotExist(err) {
 			return nil
 		}
@@ -174,7 +277,7 @@ func SafeDelete(path string, maxRetries int) error {
 			continue
 		}
 
-		if info.IsDir() {
+		if info.IsDir() && !IsLink(path, info) {
 			lastErr = os.RemoveAll(path)
 		} else {
 			lastErr = os.Remove(path)
@@ -191,45 +294,185 @@ func SafeDelete(path string, maxRetries int) error {
 }
 
 // CleanDirectory removes files from a directory, skipping locked/protected files.
+// Links inside the directory are removed without following them.
 // Returns: (bytes freed, files removed, skipped count, error).
 func CleanDirectory(dirPath string, maxRetries int) (int64, int, int, error) {
-	var totalSize int64
-	var filesRemoved int
-	var filesSkipped int
+	stats, err := CleanDirectoryWithOptions(dirPath, CleanOptions{MaxRetries: maxRetries})
+	return stats.Freed, stats.Removed, stats.Skipped, err
+}
 
-	entries, err := os.ReadDir(dirPath)
+// CleanOptions controls CleanDirectoryWithOptions.
+type CleanOptions struct {
+	MaxRetries int
+	Walk       WalkOptions
+	// Report, when set, is called for every file and link the clean
+	// removes or leaves behind, and for every folder it cannot read. err is
+	// nil for a removed entry and ErrExcluded for one rejected by
+	// Walk.Exclude.
+	Report func(path string, size int64, err error)
+	// Context, when set, stops the clean once it is done. Files removed
+	// until then stay counted.
+	Context context.Context
+	// DryRun walks and reports the clean as if it removed every entry it
+	// reaches, without removing anything.
+	DryRun bool
+}
+
+// ErrExcluded is reported for entries a clean left alone because they were
+// excluded.
+var ErrExcluded = errors.New("excluded")
+
+// CleanStats summarizes the outcome of CleanDirectoryWithOptions.
+type CleanStats struct {
+	Freed    int64
+	Removed  int
+	Skipped  int
+	Links    int
+	Excluded int
+	// ExcludedFiles counts the files at or beneath the excluded entries.
+	ExcludedFiles int
+}
+
+// CleanDirectoryWithOptions removes the contents of dirPath according to opts,
+// leaving dirPath itself in place. By default a link is unlinked on its own and
+// never deleted through; with opts.Walk.FollowLinks the contents of linked
+// directories are cleaned as well, entering each target at most once. Entries
+// rejected by opts.Walk.Exclude are left untouched, along with everything
+// beneath them, and counted in CleanStats.Excluded and ExcludedFiles.
+func CleanDirectoryWithOptions(dirPath string, opts CleanOptions) (CleanStats, error) {
+	var stats CleanStats
+
+	if opts.Walk.FollowLinks {
+		if opts.Walk.Tracker == nil {
+			opts.Walk.Tracker = NewLinkTracker()
+		}
+		opts.Walk.Tracker.Visit(dirPath)
+	}
+
+	err := cleanDir(dirPath, opts, &stats)
+	return stats, err
+}
+
+// countFiles returns the number of files at or beneath entry, found at
+// path, without following links.
+func countFiles(path string, entry fs.DirEntry) int {
+	if !entry.IsDir() {
+		return 1
+	}
+	var n int
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+// readDir lists a directory for cleanDir; tests replace it to simulate
+// unreadable folders.
+var readDir = os.ReadDir
+
+func cleanDir(dirPath string, opts CleanOptions, stats *CleanStats) error {
+	entries, err := readDir(dirPath)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("cannot read directory %s: %w", dirPath, err)
+		return fmt.Errorf("cannot read directory %s: %w", dirPath, err)
 	}
 
 	for _, entry := range entries {
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return opts.Context.Err()
+		}
+
 		fullPath := filepath.Join(dirPath, entry.Name())
 
-		if entry.IsDir() {
-			size, removed, skipped, _ := CleanDirectory(fullPath, maxRetries)
-			totalSize += size
-			filesRemoved += removed
-			filesSkipped += skipped
+		if opts.Walk.Exclude != nil && opts.Walk.Exclude(fullPath) {
+			stats.Excluded++
+			stats.ExcludedFiles += countFiles(fullPath, entry)
+			opts.report(fullPath, 0, ErrExcluded)
+			continue
+		}
 
-			_ = os.Remove(fullPath)
-		} else {
-			info, err := os.Stat(fullPath)
-			if err != nil {
-				filesSkipped++
-				continue
+		info, err := entry.Info()
+		if err != nil {
+			stats.Skipped++
+			opts.report(fullPath, 0, err)
+			continue
+		}
+
+		if IsLink(fullPath, info) {
+			stats.Links++
+			if opts.Walk.FollowLinks {
+				if target, err := os.Stat(fullPath); err == nil && target.IsDir() && opts.Walk.Tracker.Visit(fullPath) {
+					if err := cleanSubdir(fullPath, opts, stats); err != nil {
+						return err
+					}
+				}
 			}
-			fileSize := info.Size()
+			opts.report(fullPath, 0, opts.remove(fullPath))
+			continue
+		}
 
-			if err := SafeDelete(fullPath, maxRetries); err != nil {
-				filesSkipped++
-			} else {
-				totalSize += fileSize
-				filesRemoved++
+		if info.IsDir() {
+			if opts.Walk.FollowLinks && !opts.Walk.Tracker.Visit(fullPath) {
+				continue
+			}
+			if err := cleanSubdir(fullPath, opts, stats); err != nil {
+				return err
 			}
+			_ = opts.remove(fullPath)
+			continue
+		}
+
+		if err := opts.delete(fullPath); err != nil {
+			stats.Skipped++
+			opts.report(fullPath, info.Size(), err)
+		} else {
+			stats.Freed += info.Size()
+			stats.Removed++
+			opts.report(fullPath, info.Size(), nil)
 		}
 	}
 
-	return totalSize, filesRemoved, filesSkipped, nil
+	return nil
+}
+
+// cleanSubdir cleans a folder found inside the one being cleaned. A folder
+// that cannot be read is reported and skipped so the rest of the clean goes
+// on; only a stop of opts.Context is returned.
+func cleanSubdir(dirPath string, opts CleanOptions, stats *CleanStats) error {
+	err := cleanDir(dirPath, opts, stats)
+	if err == nil {
+		return nil
+	}
+	if opts.Context != nil && opts.Context.Err() != nil {
+		return err
+	}
+	stats.Skipped++
+	opts.report(dirPath, 0, err)
+	return nil
+}
+
+func (opts CleanOptions) report(path string, size int64, err error) {
+	if opts.Report != nil {
+		opts.Report(path, size, err)
+	}
+}
+
+// remove unlinks a link or empty folder unless opts.DryRun is set.
+func (opts CleanOptions) remove(path string) error {
+	if opts.DryRun {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// delete deletes a file unless opts.DryRun is set.
+func (opts CleanOptions) delete(path string) error {
+	if opts.DryRun {
+		return nil
+	}
+	return SafeDelete(path, opts.MaxRetries)
 }
 
 // GetConfigDir returns the Burrow configuration directory, creating it if needed.
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -248,6 +250,242 @@ func TestCleanDirectory(t *testing.T) {
 	}
 }
 
+func TestCleanDirectoryReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.tmp", "keep.tmp"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("12345"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reported := make(map[string]error)
+	stats, err := CleanDirectoryWithOptions(tmpDir, CleanOptions{
+		MaxRetries: 1,
+		Walk:       WalkOptions{Exclude: func(path string) bool { return filepath.Base(path) == "keep.tmp" }},
+		Report: func(path string, size int64, err error) {
+			reported[filepath.Base(path)] = err
+			if err == nil && size != 5 {
+				t.Errorf("%s reported with size %d, want 5", path, size)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Removed != 1 || stats.Excluded != 1 || len(reported) != 2 {
+		t.Fatalf("stats = %+v, reported %v", stats, reported)
+	}
+	if reported["a.tmp"] != nil {
+		t.Errorf("a.tmp reported error %v", reported["a.tmp"])
+	}
+	if !errors.Is(reported["keep.tmp"], ErrExcluded) {
+		t.Errorf("keep.tmp reported %v, want ErrExcluded", reported["keep.tmp"])
+	}
+}
+
+func TestCleanDirectoryDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "a.tmp"), []byte("12345"), 0o644)
+	os.WriteFile(filepath.Join(tmpDir, "sub", "b.tmp"), []byte("123"), 0o644)
+
+	var reported []string
+	stats, err := CleanDirectoryWithOptions(tmpDir, CleanOptions{
+		MaxRetries: 1,
+		DryRun:     true,
+		Report: func(path string, size int64, err error) {
+			if err != nil {
+				t.Errorf("%s reported error %v", path, err)
+			}
+			reported = append(reported, filepath.Base(path))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Removed != 2 || stats.Freed != 8 || len(reported) != 2 {
+		t.Errorf("stats = %+v, reported %v", stats, reported)
+	}
+	for _, name := range []string{"a.tmp", filepath.Join("sub", "b.tmp")} {
+		if !PathExists(filepath.Join(tmpDir, name)) {
+			t.Errorf("dry run removed %s", name)
+		}
+	}
+}
+
+func TestCleanDirectoryReportsUnreadableFolder(t *testing.T) {
+	tmpDir := t.TempDir()
+	locked := filepath.Join(tmpDir, "locked")
+	for _, path := range []string{filepath.Join(locked, "a.tmp"), filepath.Join(tmpDir, "b.tmp")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("12345"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	denied := &os.PathError{Op: "open", Path: locked, Err: os.ErrPermission}
+	readDir = func(name string) ([]os.DirEntry, error) {
+		if name == locked {
+			return nil, denied
+		}
+		return os.ReadDir(name)
+	}
+	defer func() { readDir = os.ReadDir }()
+
+	reported := make(map[string]error)
+	stats, err := CleanDirectoryWithOptions(tmpDir, CleanOptions{
+		MaxRetries: 1,
+		Report:     func(path string, size int64, err error) { reported[path] = err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Removed != 1 || stats.Skipped != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if !errors.Is(reported[locked], os.ErrPermission) {
+		t.Errorf("unreadable folder reported %v, want a permission error", reported[locked])
+	}
+	if _, err := os.Stat(filepath.Join(locked, "a.tmp")); err != nil {
+		t.Errorf("file in unreadable folder touched: %v", err)
+	}
+}
+
+// createLinkTree builds a root directory containing a regular file, a symlink
+// to a directory outside the root, and a symlink back to the root itself.
+func createLinkTree(t *testing.T) (root, outside string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink tests require Linux")
+	}
+
+	base := t.TempDir()
+	root = filepath.Join(base, "root")
+	outside = filepath.Join(base, "outside")
+	for _, d := range []string{root, outside} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "a.txt"), make([]byte, 100), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "b.txt"), make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "external")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(root, "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	return root, outside
+}
+
+func TestGetDirStatsLinksNotFollowed(t *testing.T) {
+	root, _ := createLinkTree(t)
+
+	stats, err := GetDirStats(root, WalkOptions{})
+	if err != nil {
+		t.Fatalf("GetDirStats error: %v", err)
+	}
+
+	if stats.Size != 100 || stats.Files != 1 {
+		t.Errorf("GetDirStats = %d bytes / %d files, want 100 / 1", stats.Size, stats.Files)
+	}
+	if stats.Links != 2 {
+		t.Errorf("GetDirStats links = %d, want 2", stats.Links)
+	}
+
+	size, count, _ := GetDirSize(root)
+	if size != 100 || count != 1 {
+		t.Errorf("GetDirSize = %d / %d, want 100 / 1", size, count)
+	}
+}
+
+func TestGetDirStatsFollowLinksDetectsLoop(t *testing.T) {
+	root, _ := createLinkTree(t)
+
+	done := make(chan DirStats, 1)
+	go func() {
+		stats, _ := GetDirStats(root, WalkOptions{FollowLinks: true})
+		done <- stats
+	}()
+
+	select {
+	case stats := <-done:
+		if stats.Size != 1100 || stats.Files != 2 {
+			t.Errorf("GetDirStats = %d bytes / %d files, want 1100 / 2", stats.Size, stats.Files)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("GetDirStats did not terminate on a symlink loop")
+	}
+}
+
+func TestCleanDirectoryDoesNotDeleteThroughLinks(t *testing.T) {
+	root, outside := createLinkTree(t)
+
+	stats, err := CleanDirectoryWithOptions(root, CleanOptions{MaxRetries: 1})
+	if err != nil {
+		t.Fatalf("CleanDirectoryWithOptions error: %v", err)
+	}
+
+	if stats.Removed != 1 || stats.Freed != 100 {
+		t.Errorf("removed %d files / %d bytes, want 1 / 100", stats.Removed, stats.Freed)
+	}
+	if stats.Links != 2 {
+		t.Errorf("links = %d, want 2", stats.Links)
+	}
+	if !PathExists(filepath.Join(outside, "b.txt")) {
+		t.Error("file outside the cleaned directory was deleted through a link")
+	}
+	if _, err := os.Lstat(filepath.Join(root, "external")); !os.IsNotExist(err) {
+		t.Error("link inside the cleaned directory should be removed")
+	}
+}
+
+func TestCleanDirectoryFollowLinks(t *testing.T) {
+	root, outside := createLinkTree(t)
+
+	stats, err := CleanDirectoryWithOptions(root, CleanOptions{
+		MaxRetries: 1,
+		Walk:       WalkOptions{FollowLinks: true},
+	})
+	if err != nil {
+		t.Fatalf("CleanDirectoryWithOptions error: %v", err)
+	}
+
+	if stats.Removed != 2 {
+		t.Errorf("removed %d files, want 2", stats.Removed)
+	}
+	if PathExists(filepath.Join(outside, "b.txt")) {
+		t.Error("file in followed link target should be removed")
+	}
+	if !PathExists(outside) {
+		t.Error("link target directory itself should be left in place")
+	}
+}
+
+func TestSafeDeleteLink(t *testing.T) {
+	root, outside := createLinkTree(t)
+
+	if err := SafeDelete(filepath.Join(root, "external"), 1); err != nil {
+		t.Fatalf("SafeDelete link error: %v", err)
+	}
+	if !PathExists(filepath.Join(outside, "b.txt")) {
+		t.Error("SafeDelete removed the link target's contents")
+	}
+}
+
 func TestExpandEnvPath(t *testing.T) {
 	os.Setenv("BURROW_TEST_VAR", "hello")
 	defer os.Unsetenv("BURROW_TEST_VAR")
@@ -300,3 +538,89 @@ func containsSubstring(s, sub string) bool {
 	}
 	return false
 }
+
+func TestAttributesString(t *testing.T) {
+	tests := []struct {
+		attrs Attributes
+		want  string
+	}{
+		{0, ""},
+		{AttrHidden, "hidden"},
+		{AttrHidden | AttrSystem, "hidden,system"},
+		{AttrReadOnly | AttrOffline, "readonly,offline"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.attrs.String(); got != tt.want {
+			t.Errorf("Attributes(%d).String() = %q, want %q", tt.attrs, got, tt.want)
+		}
+	}
+
+	if !(AttrHidden | AttrSystem).Has(AttrHidden) || AttrHidden.Has(AttrHidden|AttrSystem) {
+		t.Error("Has should report whether all given attributes are set")
+	}
+}
+
+func TestGetAttributesUnix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("dotfile and permission attributes are Unix only")
+	}
+
+	tmpDir := t.TempDir()
+	tests := []struct {
+		name string
+		perm os.FileMode
+		want Attributes
+	}{
+		{"plain.txt", 0o644, 0},
+		{".profile", 0o644, AttrHidden},
+		{"locked.txt", 0o444, AttrReadOnly},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(tmpDir, tt.name)
+		if err := os.WriteFile(path, []byte("x"), tt.perm); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := GetAttributes(tt.name, info); got != tt.want {
+			t.Errorf("GetAttributes(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	link := filepath.Join(tmpDir, "link")
+	if err := os.Symlink(filepath.Join(tmpDir, "plain.txt"), link); err != nil {
+		t.Fatal(err)
+	}
+	info, _ := os.Lstat(link)
+	if got := GetAttributes("link", info); !got.Has(AttrReparsePoint) {
+		t.Errorf("symlink attributes = %q, want reparse-point", got)
+	}
+}
+
+func TestGetDirStatsProbe(t *testing.T) {
+	root := t.TempDir()
+	busy := filepath.Join(root, "busy.db")
+	os.WriteFile(busy, make([]byte, 300), 0o644)
+	os.WriteFile(filepath.Join(root, "free.tmp"), make([]byte, 100), 0o644)
+
+	probe := func(path string) error {
+		if path == busy {
+			return errors.New("in use")
+		}
+		return nil
+	}
+	stats, err := GetDirStats(root, WalkOptions{Probe: probe})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Size != 400 || stats.Files != 2 {
+		t.Errorf("GetDirStats = %d bytes / %d files, want 400 / 2", stats.Size, stats.Files)
+	}
+	if stats.Blocked != 300 || stats.BlockedFiles != 1 {
+		t.Errorf("blocked = %d bytes / %d files, want 300 / 1", stats.Blocked, stats.BlockedFiles)
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"os"
+	"sync"
+)
+
+// WalkOptions controls how directory traversal treats symbolic links and
+// junctions. The zero value reports links without following them.
+type WalkOptions struct {
+	// FollowLinks descends into linked directories. Loops and targets that
+	// were already visited are detected through Tracker and entered only once.
+	FollowLinks bool
+	// Tracker records visited directories. It may be shared between several
+	// traversals of the same tree; when nil and FollowLinks is set, a new
+	// tracker is created for the traversal.
+	Tracker *LinkTracker
+	// Exclude, when set, reports whether the entry at path must be skipped.
+	// Excluded directories are not descended into.
+	Exclude func(path string) bool
+	// OneFileSystem skips directories on a different filesystem or volume
+	// than the root of the traversal, such as mounted network shares. It is
+	// honoured by GetDirStats; skipped directories count as excluded.
+	OneFileSystem bool
+	// Probe, when set, is called by GetDirStats for every file it sizes.
+	// Files it returns an error for are still counted, and also added to
+	// Blocked.
+	Probe func(path string) error
+
+	// device is the filesystem of the traversal root, set by GetDirStats.
+	device uint64
+}
+
+// LinkTracker remembers the directories entered during a link-following
+// traversal so that loops and repeated link targets are visited only once.
+// It is safe for concurrent use.
+type LinkTracker struct {
+	mu   sync.Mutex
+	seen map[fileID]bool
+}
+
+// NewLinkTracker creates an empty LinkTracker.
+func NewLinkTracker() *LinkTracker {
+	return &LinkTracker{seen: make(map[fileID]bool)}
+}
+
+// Visit records the directory at path, resolving any link, and reports
+// whether it had not been visited before. Directories whose identity cannot
+// be determined are always reported as new.
+func (t *LinkTracker) Visit(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+
+	id, ok := getFileID(path, info)
+	if !ok {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.seen[id] {
+		return false
+	}
+	t.seen[id] = true
+	return true
+}
+
+// DeviceID identifies the filesystem or volume holding the entry at path,
+// described by info. Links are resolved on Windows; elsewhere info must come
+// from os.Stat for the target's filesystem to be reported.
+func DeviceID(path string, info os.FileInfo) (uint64, bool) {
+	id, ok := getFileID(path, info)
+	return id.device(), ok
+}
+
+// IsLink reports whether the entry at path is a symbolic link, junction or
+// other reparse point that refers to another location. info must come from
+// os.Lstat or a directory listing so that the link itself is described.
+func IsLink(path string, info os.FileInfo) bool {
+	if info.Mode()&os.ModeSymlink != 0 {
+		return true
+	}
+	return isNameSurrogate(path, info)
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID uniquely identifies a file or directory on the local machine.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+func (id fileID) device() uint64 {
+	return id.dev
+}
+
+func (id fileID) fileIndex() uint64 {
+	return id.ino
+}
+
+func newFileID(device, index uint64) fileID {
+	return fileID{dev: device, ino: index}
+}
+
+// getFileID returns the device and inode numbers of the file described by info.
+func getFileID(_ string, info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
+
+// isNameSurrogate reports whether path is a link that os.Lstat does not flag
+// as a symlink. On Unix every link is a symlink, so this is always false.
+func isNameSurrogate(_ string, _ os.FileInfo) bool {
+	return false
+}
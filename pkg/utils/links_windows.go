@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// fileID uniquely identifies a file or directory on the local machine.
+type fileID struct {
+	volume uint32
+	index  uint64
+}
+
+func (id fileID) device() uint64 {
+	return uint64(id.volume)
+}
+
+func (id fileID) fileIndex() uint64 {
+	return id.index
+}
+
+func newFileID(device, index uint64) fileID {
+	return fileID{volume: uint32(device), index: index}
+}
+
+// getFileID opens path, following any reparse point, and returns the volume
+// serial number and file index of the target.
+func getFileID(path string, _ os.FileInfo) (fileID, bool) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return fileID{}, false
+	}
+
+	h, err := windows.CreateFile(p, 0,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer windows.CloseHandle(h)
+
+	var data windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &data); err != nil {
+		return fileID{}, false
+	}
+
+	return fileID{
+		volume: data.VolumeSerialNumber,
+		index:  uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow),
+	}, true
+}
+
+// isNameSurrogate reports whether path is a reparse point that redirects to
+// another location, such as a directory junction or volume mount point.
+// Reparse points that merely decorate a file, like OneDrive placeholders or
+// deduplicated files, are not name surrogates and are treated as regular entries.
+func isNameSurrogate(path string, info os.FileInfo) bool {
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok || attrs.FileAttributes&windows.FILE_ATTRIBUTE_REPARSE_POINT == 0 {
+		return false
+	}
+
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+
+	var data windows.Win32finddata
+	h, err := windows.FindFirstFile(p, &data)
+	if err != nil {
+		return false
+	}
+	_ = windows.FindClose(h)
+
+	// Bit 29 of a reparse tag marks it as a name surrogate.
+	return data.Reserved0&0x20000000 != 0
+}
@@ -2,7 +2,12 @@
 
 package utils
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
 
 // IsAdmin checks if the current process has administrator privileges.
 // On non-Windows platforms, this always returns false.
@@ -20,3 +25,25 @@ func RequireAdmin() error {
 func GetWindowsVersion() string {
 	return "Not Windows"
 }
+
+// IsLocked reports whether err means a file is busy in another process.
+func IsLocked(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.ETXTBSY)
+}
+
+// ProbeDelete reports whether path can be deleted now. Other platforms let
+// open files be deleted, so nothing is probed.
+func ProbeDelete(path string) error {
+	return nil
+}
+
+// SyncDir flushes the directory entries of dir to disk, so a file renamed
+// into it survives a crash.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/registry"
@@ -62,3 +64,31 @@ func GetWindowsVersion() string {
 
 	return fmt.Sprintf("%s (Build %s)", product, build)
 }
+
+// IsLocked reports whether err means a file is open in another process.
+func IsLocked(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION)
+}
+
+// ProbeDelete reports whether path can be deleted now. It opens the file for
+// deletion while sharing everything, which fails when another process holds
+// it open without delete sharing or when access is denied.
+func ProbeDelete(path string) error {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	h, err := windows.CreateFile(p, windows.DELETE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS|windows.FILE_FLAG_OPEN_REPARSE_POINT, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return windows.CloseHandle(h)
+}
+
+// SyncDir flushes the directory entries of dir to disk. NTFS journals its
+// metadata, so a completed rename is already durable and nothing is done.
+func SyncDir(dir string) error {
+	return nil
+}
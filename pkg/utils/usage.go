@@ -0,0 +1,59 @@
+package utils
+
+import "os"
+
+// FileUsage describes how a regular file occupies the disk.
+type FileUsage struct {
+	// Allocated is the space the file takes on disk: whole clusters or
+	// blocks, the compressed size of compressed files and only the allocated
+	// ranges of sparse files.
+	Allocated int64
+	// Links is the number of hard links to the file, or 0 when it could
+	// not be read.
+	Links int
+
+	id    fileID
+	hasID bool
+}
+
+// GetFileUsage returns the disk usage of the regular file at path. info must
+// come from os.Lstat or a directory listing. It reports false when the
+// platform cannot tell, in which case the apparent size is the best estimate.
+func GetFileUsage(path string, info os.FileInfo) (FileUsage, bool) {
+	return getFileUsage(path, info)
+}
+
+// Identity returns the identity of a file with several hard links as a pair
+// of numbers that can be stored between runs. It reports false when the
+// identity is unknown.
+func (u FileUsage) Identity() (device, index uint64, ok bool) {
+	if !u.hasID {
+		return 0, 0, false
+	}
+	return u.id.device(), u.id.fileIndex(), true
+}
+
+// WithIdentity returns u with an identity returned earlier by Identity.
+func (u FileUsage) WithIdentity(device, index uint64) FileUsage {
+	u.id = newFileID(device, index)
+	u.hasID = true
+	return u
+}
+
+// VisitFile records a file with several hard links and reports whether it is
+// the first time the file has been seen. Files with a single link, or whose
+// identity is unknown, are always reported as new.
+func (t *LinkTracker) VisitFile(u FileUsage) bool {
+	if u.Links <= 1 || !u.hasID {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.seen[u.id] {
+		return false
+	}
+	t.seen[u.id] = true
+	return true
+}
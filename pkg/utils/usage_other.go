@@ -0,0 +1,23 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// getFileUsage reads the block count and link count from the stat data in
+// info. Blocks are always 512 bytes, whatever the filesystem block size.
+func getFileUsage(_ string, info os.FileInfo) (FileUsage, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return FileUsage{}, false
+	}
+	return FileUsage{
+		Allocated: int64(st.Blocks) * 512,
+		Links:     int(st.Nlink),
+		id:        fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)},
+		hasID:     true,
+	}, true
+}
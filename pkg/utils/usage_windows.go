@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                   = windows.NewLazySystemDLL("kernel32.dll")
+	procGetCompressedFileSizeW = kernel32.NewProc("GetCompressedFileSizeW")
+	procGetDiskFreeSpaceW      = kernel32.NewProc("GetDiskFreeSpaceW")
+)
+
+// fileStandardInfo mirrors FILE_STANDARD_INFO.
+type fileStandardInfo struct {
+	AllocationSize int64
+	EndOfFile      int64
+	NumberOfLinks  uint32
+	DeletePending  uint8
+	Directory      uint8
+}
+
+// sizeAttributes mark the files whose allocation can only be read through a
+// handle: their on-disk size differs from the rounded-up apparent size.
+const sizeAttributes = windows.FILE_ATTRIBUTE_COMPRESSED | windows.FILE_ATTRIBUTE_SPARSE_FILE | windows.FILE_ATTRIBUTE_REPARSE_POINT
+
+// getFileUsage derives the allocation of ordinary files from the volume's
+// cluster size; compressed, sparse and reparse-point files have theirs read
+// from the file. Files are opened, without following reparse points so that
+// cloud placeholders are not recalled, to read their link count and
+// identity, except on volumes that do not support hard links.
+func getFileUsage(path string, info os.FileInfo) (FileUsage, bool) {
+	attrs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return FileUsage{}, false
+	}
+	vol, ok := volumeOf(path)
+	if !ok {
+		return FileUsage{}, false
+	}
+	u := FileUsage{Allocated: (info.Size() + vol.cluster - 1) / vol.cluster * vol.cluster}
+	special := attrs.FileAttributes&sizeAttributes != 0
+	if !special && !vol.hardLinks {
+		return u, true
+	}
+
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return FileUsage{}, false
+	}
+
+	h, err := windows.CreateFile(p, windows.FILE_READ_ATTRIBUTES,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE|windows.FILE_SHARE_DELETE,
+		nil, windows.OPEN_EXISTING, windows.FILE_FLAG_BACKUP_SEMANTICS|windows.FILE_FLAG_OPEN_REPARSE_POINT, 0)
+	if err != nil {
+		// The rounded size stands for an ordinary file; only its links
+		// are unknown.
+		return u, !special
+	}
+	defer windows.CloseHandle(h)
+
+	var data windows.ByHandleFileInformation
+	if err := windows.GetFileInformationByHandle(h, &data); err == nil {
+		u.Links = int(data.NumberOfLinks)
+		u.id = fileID{
+			volume: data.VolumeSerialNumber,
+			index:  uint64(data.FileIndexHigh)<<32 | uint64(data.FileIndexLow),
+		}
+		u.hasID = true
+	}
+	if !special {
+		return u, true
+	}
+
+	var std fileStandardInfo
+	if err := windows.GetFileInformationByHandleEx(h, windows.FileStandardInfo,
+		(*byte)(unsafe.Pointer(&std)), uint32(unsafe.Sizeof(std))); err != nil {
+		return FileUsage{}, false
+	}
+	u.Allocated = std.AllocationSize
+	if attrs.FileAttributes&(windows.FILE_ATTRIBUTE_COMPRESSED|windows.FILE_ATTRIBUTE_SPARSE_FILE) != 0 {
+		if size, ok := compressedFileSize(p); ok {
+			u.Allocated = size
+		}
+	}
+	return u, true
+}
+
+// compressedFileSize returns the bytes a compressed or sparse file occupies.
+func compressedFileSize(p *uint16) (int64, bool) {
+	var high uint32
+	low, _, err := procGetCompressedFileSizeW.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&high)))
+	if uint32(low) == 0xFFFFFFFF && err != windows.ERROR_SUCCESS {
+		return 0, false
+	}
+	return int64(high)<<32 | int64(uint32(low)), true
+}
+
+// volumeInfo describes the volume a file is on.
+type volumeInfo struct {
+	cluster   int64 // allocation unit in bytes
+	hardLinks bool  // whether files can have several hard links
+}
+
+// volumes caches the volumeInfo of each volume root.
+var volumes sync.Map
+
+// volumeOf returns the volumeInfo of the volume holding path.
+func volumeOf(path string) (volumeInfo, bool) {
+	root := filepath.VolumeName(path) + `\`
+	if v, ok := volumes.Load(root); ok {
+		return v.(volumeInfo), true
+	}
+
+	p, err := windows.UTF16PtrFromString(root)
+	if err != nil {
+		return volumeInfo{}, false
+	}
+	var sectorsPerCluster, bytesPerSector, free, total uint32
+	ret, _, _ := procGetDiskFreeSpaceW.Call(uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&sectorsPerCluster)), uintptr(unsafe.Pointer(&bytesPerSector)),
+		uintptr(unsafe.Pointer(&free)), uintptr(unsafe.Pointer(&total)))
+	if ret == 0 || sectorsPerCluster == 0 || bytesPerSector == 0 {
+		return volumeInfo{}, false
+	}
+
+	vol := volumeInfo{cluster: int64(sectorsPerCluster) * int64(bytesPerSector), hardLinks: true}
+	var flags uint32
+	if err := windows.GetVolumeInformation(p, nil, 0, nil, nil, &flags, nil, 0); err == nil {
+		vol.hardLinks = flags&windows.FILE_SUPPORTS_HARD_LINKS != 0
+	}
+	volumes.Store(root, vol)
+	return vol, true
+}
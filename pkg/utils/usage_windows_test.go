@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetFileUsageHardLinkedOrdinaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(original, make([]byte, 5000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.bin")
+	if err := os.Link(original, link); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	tracker := NewLinkTracker()
+	var size int64
+	for _, path := range []string{original, link} {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, ok := GetFileUsage(path, info)
+		if !ok {
+			t.Fatalf("GetFileUsage(%s) reported no usage", path)
+		}
+		if u.Links != 2 {
+			t.Errorf("%s has %d links, want 2", path, u.Links)
+		}
+		if tracker.VisitFile(u) {
+			size += info.Size()
+		}
+	}
+	if size != 5000 {
+		t.Errorf("counted %d bytes, want 5000 (hard link counted once)", size)
+	}
+}